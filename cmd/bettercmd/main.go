@@ -0,0 +1,230 @@
+// Command bettercmd runs the shell script templates with the parts escaped by the
+// rules of package command, thus the scripts in any language get the same
+// injection safety.
+//
+// Usage:
+//
+//	bettercmd run [flags] template [parts...]
+//	bettercmd dry-run [-shell sh] template [parts...]
+//	bettercmd lint [-posix] template [parts...]
+//
+// For example:
+//
+//	bettercmd run -timeout 10m -retries 2 'tar czf %s %s' out.tgz "$dir"
+//
+// The run command exits with the exit code of the script, 1 if it fails to start,
+// and 2 for the usage errors. With -log, the events of the script are written to
+// stderr as JSON lines.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/futurist/better-command/command"
+)
+
+// hostilePart is substituted for the parts of template by lint if no parts given,
+// which breaks the script if not escaped properly
+const hostilePart = "a b;c|d&e$(f)`g`'h\"i\\j*k\nl"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the bettercmd with args, returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "run":
+		return runCommand(args[1:], stdout, stderr)
+	case "dry-run":
+		return dryRun(args[1:], stdout, stderr)
+	case "lint":
+		return lint(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "bettercmd: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  bettercmd run [flags] template [parts...]
+  bettercmd dry-run [-shell sh] template [parts...]
+  bettercmd lint [-posix] template [parts...]
+
+Run "bettercmd <command> -h" for the flags of command.
+`)
+}
+
+// newFlagSet returns the flag set of command name, the usage is written to w
+func newFlagSet(name string, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() {
+		fmt.Fprintf(w, "Usage: bettercmd %s [flags] template [parts...]\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseTemplate parses the flags, returns the template and parts, ok is false if
+// the args are invalid
+func parseTemplate(fs *flag.FlagSet, args []string) (string, []string, bool) {
+	if err := fs.Parse(args); err != nil {
+		return "", nil, false
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(fs.Output(), "bettercmd: missing template")
+		fs.Usage()
+		return "", nil, false
+	}
+	return fs.Arg(0), fs.Args()[1:], true
+}
+
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("run", stderr)
+	shell := fs.String("shell", "sh", "the shell to run the template by `shell -c`")
+	timeout := fs.Duration("timeout", 0, "kill the script after the timeout, 0 for no timeout")
+	retries := fs.Int("retries", 0, "retry the script up to n times if it exits with non-zero status")
+	backoff := fs.Duration("backoff", time.Second, "the delay before the first retry, doubled for each retry")
+	strict := fs.Bool("strict", false, "run the script with `set -euo pipefail`")
+	logJSON := fs.Bool("log", false, "write the events to stderr as JSON lines")
+	template, parts, ok := parseTemplate(fs, args)
+	if !ok {
+		return 2
+	}
+
+	// the events are written with the stderr of script
+	stderr = &syncWriter{w: stderr}
+	c := command.New([]string{*shell, "-c", template}, parts...).Stdout(stdout).Stderr(stderr)
+	c.Stdin(os.Stdin)
+	if *strict {
+		c.StrictShell()
+	}
+	if *retries > 0 {
+		c.Retry(*retries, *backoff)
+	}
+	if *timeout > 0 {
+		c.Timeout(*timeout)
+	}
+	logger := &eventLogger{w: stderr, enabled: *logJSON}
+	start := time.Now()
+	c.OnStart(func(c *command.Command) {
+		logger.log("start", map[string]interface{}{"pid": c.Pid, "args": c.Args})
+	})
+	err := c.Run()
+	code, exited := c.ExitCode()
+	fields := map[string]interface{}{"duration": time.Since(start).String()}
+	if exited {
+		fields["code"] = code
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	logger.log("exit", fields)
+
+	var exitErr interface{ ExitCode() int }
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+		return exitErr.ExitCode()
+	}
+	if !logger.enabled {
+		fmt.Fprintln(stderr, "bettercmd:", err)
+	}
+	return 1
+}
+
+// syncWriter serializes the writes to w
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// eventLogger writes the events as JSON lines if enabled
+type eventLogger struct {
+	w       io.Writer
+	enabled bool
+}
+
+func (l *eventLogger) log(event string, fields map[string]interface{}) {
+	if !l.enabled {
+		return
+	}
+	fields["time"] = time.Now().Format(time.RFC3339Nano)
+	fields["event"] = event
+	b, _ := json.Marshal(fields)
+	fmt.Fprintf(l.w, "%s\n", b)
+}
+
+func dryRun(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("dry-run", stderr)
+	shell := fs.String("shell", "sh", "the shell to run the template by `shell -c`")
+	template, parts, ok := parseTemplate(fs, args)
+	if !ok {
+		return 2
+	}
+	c := command.New([]string{*shell, "-c", template}, parts...)
+	if c.LastError != nil {
+		fmt.Fprintln(stderr, "bettercmd:", c.LastError)
+		return 1
+	}
+	fmt.Fprintln(stdout, command.QuoteArgs(c.Args))
+	return 0
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("lint", stderr)
+	posix := fs.Bool("posix", false, "also check the template is strict POSIX sh")
+	template, parts, ok := parseTemplate(fs, args)
+	if !ok {
+		return 2
+	}
+	if len(parts) == 0 {
+		n, err := command.CountParts(template)
+		if err != nil {
+			fmt.Fprintln(stderr, "bettercmd:", err)
+			return 1
+		}
+		for ; n > 0; n-- {
+			parts = append(parts, hostilePart)
+		}
+	}
+	failed := false
+	if err := command.ValidateSafe(template, parts...); err != nil {
+		fmt.Fprintln(stderr, "bettercmd:", err)
+		failed = true
+	}
+	if *posix {
+		if err := command.CheckPOSIX(template); err != nil {
+			fmt.Fprintln(stderr, "bettercmd:", err)
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+	fmt.Fprintln(stdout, "ok")
+	return 0
+}
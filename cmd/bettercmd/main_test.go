@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"run", "echo %s; exit 3", "a;b"}, &stdout, &stderr)
+	if code != 3 || stdout.String() != "a;b\n" {
+		t.Fatalf("got code %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"run", "-log", "-timeout", "5s", "true"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got log %q", stderr.String())
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event["event"] != "exit" || event["code"] != float64(0) {
+		t.Fatalf("got event %v", event)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"dry-run", "tar czf %s %s", "out.tgz", "my dir"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	if got, want := stdout.String(), `sh -c 'tar czf out.tgz my\ dir'`+"\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLint(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", "-posix", "echo %s"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	stderr.Reset()
+	// the part of %s is escaped, and %g is kept as is
+	if code := run([]string{"lint", "printf %g%s", "*"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	stderr.Reset()
+	// the parts are counted like New, the %F is kept as is
+	if code := run([]string{"lint", "date +%F; echo %s %s"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	if code := run([]string{"lint", "echo 'a"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("got code %d", code)
+	}
+	stderr.Reset()
+	if code := run([]string{"lint", "-posix", "[[ -n %s ]]"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "bash-ism") {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{nil, {"unknown"}, {"run"}, {"run", "-unknown", "true"}} {
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("got code %d for %q", code, args)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package command
+
+import "strings"
+
+// quoteAppleScript returns s quoted as AppleScript string literal
+func quoteAppleScript(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// adminScript returns the AppleScript to run args by `do shell script` with
+// administrator privileges, which shows the native password dialog with prompt.
+func adminScript(args []string, dir, prompt string) string {
+	script := QuoteArgs(args)
+	if dir != "" {
+		script = "cd " + Quote(dir) + " && " + script
+	}
+	s := "do shell script " + quoteAppleScript(script)
+	if prompt != "" {
+		s += " with prompt " + quoteAppleScript(prompt)
+	}
+	return s + " with administrator privileges without altering line endings"
+}
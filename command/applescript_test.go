@@ -0,0 +1,11 @@
+package command
+
+import "testing"
+
+func TestAdminScript(t *testing.T) {
+	got := adminScript([]string{"sh", "-c", `echo "a b"`}, "/tmp/x y", `need "root"`)
+	want := `do shell script "cd '/tmp/x y' && sh -c 'echo \"a b\"'" with prompt "need \"root\"" with administrator privileges without altering line endings`
+	if got != want {
+		t.Fatal(got)
+	}
+}
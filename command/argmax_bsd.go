@@ -0,0 +1,19 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package command
+
+import "syscall"
+
+// maxArgLen is the limit of a single arg, 0 if only the total is limited
+const maxArgLen = 0
+
+// ArgMax returns the limit of the args and the environment in bytes, which is
+// the kern.argmax sysctl.
+func ArgMax() int {
+	n, err := syscall.SysctlUint32("kern.argmax")
+	if err != nil || n == 0 {
+		return 256 << 10
+	}
+	return int(n)
+}
@@ -0,0 +1,28 @@
+//go:build linux
+// +build linux
+
+package command
+
+import "syscall"
+
+// maxArgLen is the MAX_ARG_STRLEN of Linux, the limit of a single arg or env
+const maxArgLen = 32 * 4096
+
+// ArgMax returns the limit of the args and the environment in bytes, it's a
+// quarter of the stack size limit like sysconf(_SC_ARG_MAX), at least 128KiB,
+// and at most 6MiB which is 3/4 of the default stack size.
+func ArgMax() int {
+	const lower, upper = 128 << 10, 6 << 20
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_STACK, &rlim); err != nil {
+		return lower
+	}
+	n := rlim.Cur / 4
+	if n > upper {
+		return upper
+	}
+	if n < lower {
+		return lower
+	}
+	return int(n)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package command
+
+// maxArgLen is the limit of a single arg, 0 if only the total is limited
+const maxArgLen = 0
+
+// ArgMax returns the limit of the args and the environment in bytes, it's
+// 128KiB as the minimal on the other platforms.
+func ArgMax() int {
+	return 128 << 10
+}
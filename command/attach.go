@@ -0,0 +1,101 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// attachPollInterval is the interval checking if the attached process exited,
+// since it can't be waited by the current process on POSIX
+const attachPollInterval = 100 * time.Millisecond
+
+// Handle is a running process not started by the current process, like the one
+// started by a previous incarnation of daemon and recorded in a pid file, see
+// [Attach]. It can be waited on, signaled and stopped like a spawned [Command].
+type Handle struct {
+	Pid     int
+	process *os.Process
+	done    chan struct{}
+	err     error
+}
+
+// Attach returns the Handle of the running process pid, or an error if it's not
+// running.
+//
+// On POSIX, the exit of process is detected by polling, and its exit status is
+// unknown unless it's a child of the current process, and the pid may be reused
+// by another process after it exited, thus the pid file should be checked before.
+func Attach(pid int) (*Handle, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("Attach: invalid pid %d", pid)
+	}
+	p, err := findProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("Attach: %w", err)
+	}
+	h := &Handle{Pid: pid, process: p, done: make(chan struct{})}
+	go func() {
+		h.err = waitProcess(p)
+		close(h.done)
+	}()
+	return h, nil
+}
+
+// Done returns a channel that's closed when the process exited
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Running reports whether the process is still running
+func (h *Handle) Running() bool {
+	select {
+	case <-h.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Wait waits for the process to exit, the error is an [ExitStatusError] if the
+// exit status is known and not 0.
+func (h *Handle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Signal sends sig to the process
+func (h *Handle) Signal(sig os.Signal) error {
+	if !h.Running() {
+		return os.ErrProcessDone
+	}
+	return h.process.Signal(sig)
+}
+
+// Stop terminates the process by the steps of policy, then kills it if it's still
+// running, and waits for it to exit like Wait.
+func (h *Handle) Stop(policy KillPolicy) error {
+	for i, step := range policy {
+		if err := checkKillSignal(step.Signal); err != nil {
+			return fmt.Errorf("Stop: step %d: %w", i, err)
+		}
+		if err := h.Signal(step.Signal); err != nil {
+			if errors.Is(err, os.ErrProcessDone) {
+				return h.Wait()
+			}
+			break
+		}
+		t := time.NewTimer(step.Wait)
+		select {
+		case <-h.done:
+			t.Stop()
+			return h.err
+		case <-t.C:
+		}
+	}
+	if err := h.Signal(os.Kill); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("Stop: %w", err)
+	}
+	return h.Wait()
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// findProcess returns the process of pid if it's running
+func findProcess(pid int) (*os.Process, error) {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return nil, err
+	}
+	if isZombie(pid) {
+		return nil, os.ErrProcessDone
+	}
+	return p, nil
+}
+
+// waitProcess waits for p to exit, by wait(2) if it's a child of the current
+// process, otherwise by polling with the signal 0, the zombie is exited since
+// it's reaped by the other process
+func waitProcess(p *os.Process) error {
+	if ps, err := p.Wait(); err == nil {
+		if code := ps.ExitCode(); code != 0 {
+			return &ExitStatusError{Code: code}
+		}
+		return nil
+	}
+	for {
+		if err := syscall.Kill(p.Pid, 0); err != nil && err != syscall.EPERM || isZombie(p.Pid) {
+			return nil
+		}
+		time.Sleep(attachPollInterval)
+	}
+}
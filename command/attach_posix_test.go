@@ -0,0 +1,54 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestAttach(t *testing.T) {
+	// the orphaned sleep is not a child of the current process
+	out, err := NewSh(`sleep 0.3 >/dev/null 2>&1 & echo $!`).KillScope(ChildOnly).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	h, err := Attach(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Running() {
+		t.Fatal("should be running")
+	}
+	start := time.Now()
+	if err := h.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond || d > 5*time.Second {
+		t.Fatalf("waited %v", d)
+	}
+	if _, err := Attach(pid); err == nil {
+		t.Fatal("want error of exited process")
+	}
+
+	out, err = NewSh(`trap '' TERM; sleep 10 >/dev/null 2>&1 & echo $!`).KillScope(ChildOnly).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+	if h, err = Attach(pid); err != nil {
+		t.Fatal(err)
+	}
+	start = time.Now()
+	if err := h.Stop(KillPolicy{{Signal: syscall.SIGTERM, Wait: 100 * time.Millisecond}}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond || d > 5*time.Second || h.Running() {
+		t.Fatalf("stopped in %v", d)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package command
+
+import "os"
+
+// findProcess returns the process of pid if it's running
+func findProcess(pid int) (*os.Process, error) {
+	return os.FindProcess(pid)
+}
+
+// waitProcess waits for p to exit
+func waitProcess(p *os.Process) error {
+	ps, err := p.Wait()
+	if err != nil {
+		return err
+	}
+	if code := ps.ExitCode(); code != 0 {
+		return &ExitStatusError{Code: code}
+	}
+	return nil
+}
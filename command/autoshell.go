@@ -0,0 +1,68 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ShellEnv is the environment variable to override the shell chosen by [DetectShell],
+// like BETTER_COMMAND_SHELL="busybox sh".
+const ShellEnv = "BETTER_COMMAND_SHELL"
+
+// shellCandidates is the fallback chain of [DetectShell]
+var shellCandidates = [][]string{{"bash"}, {"dash"}, {"sh"}, {"busybox", "sh"}}
+
+// DetectShell returns the first available shell of bash, dash, sh and busybox sh,
+// or the shell from [ShellEnv] if set, the result may contain multiple args, like
+// []string{"busybox", "sh"}.
+func DetectShell() ([]string, error) {
+	if v := strings.Fields(os.Getenv(ShellEnv)); len(v) > 0 {
+		return v, nil
+	}
+	for _, v := range shellCandidates {
+		if _, err := exec.LookPath(v[0]); err == nil {
+			return v, nil
+		}
+	}
+	return nil, errors.New("no shell found")
+}
+
+// AutoShell replace the shell of [NewSh], [NewBash] with [DetectShell],
+// the chosen shell can be checked in Args.
+func (c *Command) AutoShell() *Command {
+	i := c.scriptIndex()
+	if i < 2 {
+		c.LastError = errors.New("AutoShell: no shell script found")
+		return c
+	}
+	shell, err := DetectShell()
+	if err != nil {
+		c.LastError = fmt.Errorf("AutoShell: %w", err)
+		return c
+	}
+	args := append([]string{}, c.Cmd.Args[:i-2]...)
+	args = append(args, shell...)
+	c.Cmd.Args = append(args, c.Cmd.Args[i-1:]...)
+	if i == 2 {
+		c.setPath(shell[0])
+	}
+	return c
+}
+
+// setPath resolve name to the Path of command like [exec.Command]
+func (c *Command) setPath(name string) {
+	path := name
+	if filepath.Base(name) == name {
+		var err error
+		if path, err = exec.LookPath(name); err != nil {
+			c.LastError = err
+			return
+		}
+	}
+	c.Cmd.Path = path
+	clearLookPathErr(c.Cmd)
+}
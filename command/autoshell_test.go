@@ -0,0 +1,35 @@
+package command
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAutoShell(t *testing.T) {
+	cmd := NewSh(`printf %s`, "ok").AutoShell()
+	if cmd.LastError != nil {
+		t.Fatal(cmd.LastError)
+	}
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ok" {
+		t.Fatal("output should be ok", string(b))
+	}
+}
+
+func TestAutoShellEnv(t *testing.T) {
+	os.Setenv(ShellEnv, "sh -e")
+	defer os.Unsetenv(ShellEnv)
+	cmd := NewBash(`printf ok`).AutoShell()
+	if diff := cmp.Diff(cmd.Args, []string{"sh", "-e", "-c", "printf ok"}); diff != "" {
+		t.Fatal(diff)
+	}
+	b, err := cmd.Output()
+	if err != nil || string(b) != "ok" {
+		t.Fatal("output should be ok", string(b), err)
+	}
+}
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the command fails fast by [Command.Breaker]
+var ErrCircuitOpen = errors.New("circuit open")
+
+// breakerOptions is the options of [Command.Breaker]
+type breakerOptions struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+// BreakerOption is the option of [Command.Breaker]
+type BreakerOption func(*breakerOptions)
+
+// BreakerThreshold opens the circuit after n consecutive failures, 5 by default
+func BreakerThreshold(n int) BreakerOption {
+	return func(o *breakerOptions) { o.threshold = n }
+}
+
+// BreakerCooldown keeps the circuit open for d before a trial run, 30s by default
+func BreakerCooldown(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) { o.cooldown = d }
+}
+
+// circuitBreaker is the state of a named breaker
+type circuitBreaker struct {
+	mu       sync.Mutex
+	opts     breakerOptions
+	failures int
+	openedAt time.Time
+	// trial is true when a trial run is in flight after the cooldown
+	trial bool
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*circuitBreaker{}
+)
+
+// Breaker guards the command by the circuit breaker of name, which is shared by
+// the commands of the same name. The circuit opens after consecutive failures,
+// including the timeouts and start errors, then the commands fail fast with
+// [ErrCircuitOpen] for the cooldown. After the cooldown, a single trial run is
+// allowed, which closes the circuit if succeeded, or opens it again.
+//
+// The opts are applied when the breaker of name is first used.
+func (c *Command) Breaker(name string, opts ...BreakerOption) *Command {
+	o := breakerOptions{threshold: 5, cooldown: 30 * time.Second}
+	for _, f := range opts {
+		f(&o)
+	}
+	if o.threshold < 1 || o.cooldown < 0 {
+		c.LastError = fmt.Errorf("Breaker: invalid threshold %d or cooldown %v", o.threshold, o.cooldown)
+		return c
+	}
+	breakersMu.Lock()
+	b, ok := breakers[name]
+	if !ok {
+		b = &circuitBreaker{opts: o}
+		breakers[name] = b
+	}
+	breakersMu.Unlock()
+	return c.prepare(func(c *Command) error {
+		trial, err := b.allow(c.now())
+		if err != nil {
+			return fmt.Errorf("Breaker: %s: %w", name, err)
+		}
+		c.OnExit(func(c *Command) {
+			// the canceled commands are failures, like the timeouts
+			failed := c.Ctx.Err() != nil || c.ProcessState == nil || !c.ProcessState.Success()
+			b.done(trial, failed, c.now())
+		})
+		return nil
+	})
+}
+
+// allow returns nil if the command can run, and whether it's the trial run
+func (b *circuitBreaker) allow(now time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.opts.threshold {
+		return false, nil
+	}
+	if b.trial || now.Sub(b.openedAt) < b.opts.cooldown {
+		return false, ErrCircuitOpen
+	}
+	b.trial = true
+	return true, nil
+}
+
+// done records the result of a run
+func (b *circuitBreaker) done(trial, failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		b.trial = false
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.opts.threshold {
+		b.openedAt = now
+	}
+}
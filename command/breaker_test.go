@@ -0,0 +1,57 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// resetBreaker removes the breaker of name, thus the test can run repeatedly
+func resetBreaker(name string) {
+	breakersMu.Lock()
+	delete(breakers, name)
+	breakersMu.Unlock()
+}
+
+func TestBreaker(t *testing.T) {
+	resetBreaker("TestBreaker")
+	run := func(script string) error {
+		return NewSh(script).Breaker("TestBreaker", BreakerThreshold(2), BreakerCooldown(100*time.Millisecond)).Run()
+	}
+	for i := 0; i < 2; i++ {
+		if err := run("exit 1"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatal(err)
+		}
+	}
+	if err := run("true"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want circuit open, got", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	// the failed trial opens the circuit again
+	if err := run("exit 1"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatal(err)
+	}
+	if err := run("true"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want circuit open, got", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if err := run("true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("true"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBreakerTimeout(t *testing.T) {
+	resetBreaker("TestBreakerTimeout")
+	cmd := func() *Command {
+		return NewSh("sleep 1").Breaker("TestBreakerTimeout", BreakerThreshold(1)).Timeout(50 * time.Millisecond)
+	}
+	if err := cmd().Run(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatal(err)
+	}
+	if err := cmd().Run(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("want circuit open, got", err)
+	}
+}
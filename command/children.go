@@ -0,0 +1,50 @@
+package command
+
+import "fmt"
+
+// ProcInfo is the information of a process
+type ProcInfo struct {
+	Pid  int
+	Ppid int
+	// Name is the command name of the process
+	Name string
+	// RSS is the resident set size in bytes, 0 if not supported
+	RSS int64
+}
+
+// Children returns the descendant processes of the running command, not including
+// the command itself, the children are before the grandchildren. It's read from
+// /proc on Linux, ps on other unix, and Toolhelp on windows where RSS is 0.
+func (c *Command) Children() ([]ProcInfo, error) {
+	c.mu.RLock()
+	pid := c.Pid
+	c.mu.RUnlock()
+	if pid == 0 {
+		return nil, fmt.Errorf("Children: %w", errNotStarted)
+	}
+	procs, err := listProcs()
+	if err != nil {
+		return nil, fmt.Errorf("Children: %w", err)
+	}
+	return descendants(procs, pid), nil
+}
+
+// descendants returns the descendants of pid in procs, in breadth-first order
+func descendants(procs []ProcInfo, pid int) []ProcInfo {
+	children := make(map[int][]ProcInfo)
+	for _, p := range procs {
+		if p.Pid != p.Ppid {
+			children[p.Ppid] = append(children[p.Ppid], p)
+		}
+	}
+	var res []ProcInfo
+	queue := []int{pid}
+	for len(queue) > 0 {
+		for _, p := range children[queue[0]] {
+			res = append(res, p)
+			queue = append(queue, p.Pid)
+		}
+		queue = queue[1:]
+	}
+	return res
+}
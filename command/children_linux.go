@@ -0,0 +1,23 @@
+//go:build linux
+// +build linux
+
+package command
+
+import "os"
+
+// listProcs returns the running processes from /proc
+func listProcs() ([]ProcInfo, error) {
+	stats, err := listProcStats()
+	if err != nil {
+		return nil, err
+	}
+	pageSize := int64(os.Getpagesize())
+	procs := make([]ProcInfo, 0, len(stats))
+	for _, st := range stats {
+		if st.State == "Z" {
+			continue
+		}
+		procs = append(procs, ProcInfo{Pid: st.Pid, Ppid: st.Ppid, Name: st.Comm, RSS: st.RSS * pageSize})
+	}
+	return procs, nil
+}
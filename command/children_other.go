@@ -0,0 +1,35 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package command
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcs returns the running processes by ps
+func listProcs() ([]ProcInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,stat=,comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []ProcInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || strings.HasPrefix(fields[3], "Z") {
+			continue
+		}
+		var p ProcInfo
+		p.Pid, _ = strconv.Atoi(fields[0])
+		p.Ppid, _ = strconv.Atoi(fields[1])
+		rss, _ := strconv.ParseInt(fields[2], 10, 64)
+		// rss of ps is in KiB
+		p.RSS = rss << 10
+		p.Name = filepath.Base(strings.Join(fields[4:], " "))
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
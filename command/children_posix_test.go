@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChildren(t *testing.T) {
+	c := NewSh(`sleep 1 & sh -c 'sleep 1; true'; wait`)
+	if _, err := c.Children(); err == nil {
+		t.Fatal("want error before spawn")
+	}
+	if err := c.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Wait()
+	defer c.Stop()
+	var procs []ProcInfo
+	for i := 0; i < 100; i++ {
+		var err error
+		if procs, err = c.Children(); err != nil {
+			t.Fatal(err)
+		}
+		if len(procs) >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	names := map[string]int{}
+	for _, p := range procs {
+		names[p.Name]++
+	}
+	if len(procs) != 3 || names["sleep"] != 2 || names["sh"] != 1 {
+		t.Fatalf("got %+v", procs)
+	}
+	if procs[2].Name != "sleep" || procs[2].Ppid != procs[0].Pid && procs[2].Ppid != procs[1].Pid {
+		t.Fatalf("grandchild not last: %+v", procs)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	procs := []ProcInfo{{Pid: 1}, {Pid: 2, Ppid: 1}, {Pid: 3, Ppid: 2}, {Pid: 4, Ppid: 1}, {Pid: 5, Ppid: 9}}
+	got := descendants(procs, 1)
+	if len(got) != 3 || got[0].Pid != 2 || got[1].Pid != 4 || got[2].Pid != 3 {
+		t.Fatalf("got %+v", got)
+	}
+}
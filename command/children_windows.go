@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// listProcs returns the running processes by the Toolhelp snapshot
+func listProcs() ([]ProcInfo, error) {
+	h, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(h)
+	var e syscall.ProcessEntry32
+	e.Size = uint32(unsafe.Sizeof(e))
+	var procs []ProcInfo
+	for err = syscall.Process32First(h, &e); err == nil; err = syscall.Process32Next(h, &e) {
+		procs = append(procs, ProcInfo{
+			Pid:  int(e.ProcessID),
+			Ppid: int(e.ParentProcessID),
+			Name: syscall.UTF16ToString(e.ExeFile[:]),
+		})
+	}
+	if err != syscall.ERROR_NO_MORE_FILES {
+		return nil, err
+	}
+	return procs, nil
+}
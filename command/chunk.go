@@ -0,0 +1,173 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// argHeadroom is kept free from [ArgMax] like xargs does, since the limit
+// also counts things like the auxiliary vector.
+const argHeadroom = 2048
+
+// AutoChunk splits the Args after the first fixed ones into multiple sequential
+// invocations, like xargs, when the command line exceeds the platform limit
+// [ArgMax]. Each invocation runs with the first fixed Args, followed by as many
+// of the rest as fit, fixed should be at least 1 for the program name.
+//
+// The invocations share Stdin, Stdout and Stderr, and stop at the first failure,
+// which is returned by Wait. OnStart is only called for the first invocation, and
+// the pipes of [Command.StdoutReader], [Command.StderrReader] and
+// [Command.StdinWriter] are closed after it, use Stdout and Stderr writers instead.
+//
+// The wrappers like [Command.UseSudo] are kept for each invocation, but an error is
+// returned on start if the wrappers don't keep the Args as is, like [Command.ElevateGUI].
+func (c *Command) AutoChunk(fixed int) *Command {
+	if fixed < 1 {
+		c.LastError = fmt.Errorf("AutoChunk: fixed args should be at least 1, got %d", fixed)
+		return c
+	}
+	c.mu.Lock()
+	c.autoChunk = fixed
+	c.mu.Unlock()
+	return c
+}
+
+// chunkArgs splits the rest of Args if they're too long, with orig the Args before
+// the wrappers, sets Args to the first invocation and returns the args of the
+// following invocations.
+func (c *Command) chunkArgs(orig []string) ([][]string, error) {
+	c.mu.RLock()
+	fixed := c.autoChunk
+	c.mu.RUnlock()
+	if fixed == 0 {
+		return nil, nil
+	}
+	args := c.Cmd.Args
+	env := c.Cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	limit := ArgMax() - argHeadroom
+	for _, v := range env {
+		limit -= envLen(v)
+	}
+	size := 0
+	for _, v := range args {
+		size += argLen(v)
+	}
+	if size <= limit {
+		return nil, nil
+	}
+	if fixed > len(orig) {
+		return nil, fmt.Errorf("AutoChunk: %d fixed args but only %d args", fixed, len(orig))
+	}
+	// the wrappers should only prepend args
+	offset := len(args) - len(orig)
+	if offset < 0 || !equalStrings(args[offset:], orig) {
+		return nil, fmt.Errorf("AutoChunk: the args are changed by the wrappers")
+	}
+	prefix := offset + fixed
+	limit -= size
+	for _, v := range args[prefix:] {
+		limit += argLen(v)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("AutoChunk: the fixed args are too long")
+	}
+
+	var chunks [][]string
+	var chunk []string
+	chunkSize := 0
+	for i, v := range args[prefix:] {
+		l := argLen(v)
+		if l > limit || maxArgLen > 0 && len(v)+1 > maxArgLen {
+			return nil, fmt.Errorf("AutoChunk: arg %d is too long", prefix+i)
+		}
+		if chunkSize+l > limit {
+			chunks = append(chunks, chunk)
+			chunk, chunkSize = nil, 0
+		}
+		chunk = append(chunk, v)
+		chunkSize += l
+	}
+	chunks = append(chunks, chunk)
+
+	c.Cmd.Args = append(args[:prefix:prefix], chunks[0]...)
+	rest := make([][]string, 0, len(chunks)-1)
+	for _, v := range chunks[1:] {
+		rest = append(rest, append(args[:prefix:prefix], v...))
+	}
+	return rest, nil
+}
+
+// chunkWait returns the wait function running the following invocations of
+// [Command.AutoChunk] after the first one returned by wait.
+func (c *Command) chunkWait(wait func() error, chunks [][]string) func() error {
+	return func() error {
+		for _, args := range chunks {
+			if err := wait(); err != nil {
+				return err
+			}
+			if err := c.Ctx.Err(); err != nil {
+				return err
+			}
+			var err error
+			if wait, err = c.restart(args); err != nil {
+				return err
+			}
+		}
+		return wait()
+	}
+}
+
+// restart starts the command again with args after the previous one exited, the
+// Cmd is replaced by a new one with the same settings, it returns the wait function.
+func (c *Command) restart(args []string) (func() error, error) {
+	prev := c.Cmd
+	cmd := exec.CommandContext(c.procCtx, prev.Path)
+	cmd.Args = args
+	cmd.Env = prev.Env
+	cmd.Dir = prev.Dir
+	cmd.Stdin = prev.Stdin
+	cmd.Stdout = prev.Stdout
+	cmd.Stderr = prev.Stderr
+	cmd.ExtraFiles = prev.ExtraFiles
+	cmd.SysProcAttr = prev.SysProcAttr
+	c.mu.Lock()
+	c.Cmd = cmd
+	c.mu.Unlock()
+
+	var wait func() error
+	var err error
+	if e := currentExecutor(); e != nil {
+		wait, err = e.Start(c)
+	} else {
+		err = cmd.Start()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if wait == nil {
+		wait = cmd.Wait
+	}
+	c.mu.Lock()
+	if cmd.Process != nil {
+		c.Pid = cmd.Process.Pid
+	}
+	c.mu.Unlock()
+	c.emit(Event{Type: EventStarted})
+	return wait, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import "strconv"
+
+// argLen is the size of arg counted in the limit of the command line, the
+// string with the NUL terminator and the pointer to it.
+func argLen(arg string) int {
+	return len(arg) + 1 + strconv.IntSize/8
+}
+
+// envLen is the size of env "KEY=VALUE" counted in the limit of the command line
+func envLen(env string) int {
+	return argLen(env)
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAutoChunk(t *testing.T) {
+	args := []string{"sh", "-c", `echo $#`, "sh"}
+	arg := strings.Repeat("x", 100)
+	n := ArgMax() / 50
+	for i := 0; i < n; i++ {
+		args = append(args, arg)
+	}
+	if err := New(append([]string(nil), args...)).Run(); err == nil {
+		t.Fatal("want argument list too long")
+	}
+
+	b, err := New(args).AutoChunk(4).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(string(b))
+	if len(lines) < 2 {
+		t.Fatalf("want multiple invocations, got %q", b)
+	}
+	total := 0
+	for _, v := range lines {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += i
+	}
+	if total != n {
+		t.Fatalf("got %d args, want %d", total, n)
+	}
+}
+
+func TestAutoChunkShort(t *testing.T) {
+	b, err := New([]string{"echo", "a", "b"}).AutoChunk(1).Output()
+	if err != nil || string(b) != "a b\n" {
+		t.Fatal(string(b), err)
+	}
+	if err := New([]string{"echo"}).AutoChunk(0).Run(); err == nil {
+		t.Fatal("want error for fixed 0")
+	}
+}
+
+func TestAutoChunkStop(t *testing.T) {
+	args := []string{"sh", "-c", `echo x; exit 3`, "sh"}
+	arg := strings.Repeat("x", 100)
+	for i := 0; i < ArgMax()/50; i++ {
+		args = append(args, arg)
+	}
+	b, err := New(args).AutoChunk(4).Output()
+	if exitCode(err) != 3 || string(b) != "x\n" {
+		t.Fatal(string(b), err)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package command
+
+// maxArgLen is the limit of a single arg, 0 if only the total is limited
+const maxArgLen = 0
+
+// ArgMax returns the limit of the command line, the 32767 characters of the
+// command line built by CommandLineToArgvW rules on Windows.
+func ArgMax() int {
+	return 32767
+}
+
+// argLen is the size of arg counted in the limit of the command line, the
+// escaped arg with the separator.
+func argLen(arg string) int {
+	return len(escapeWindowsArg(arg)) + 1
+}
+
+// envLen is 0, the environment block is not counted in the command line
+func envLen(env string) int {
+	return 0
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"time"
+)
+
+// Timer is returned by [Clock.AfterFunc] to cancel the call
+type Timer interface {
+	// Stop prevents the call, returns false if it's already called or stopped
+	Stop() bool
+}
+
+// Clock is the source of time of the command, the tests can inject a fake
+// clock by [Command.WithClock] to advance the time synthetically.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f after duration d, f should not block
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// realClock is the default Clock by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// WithClock set the clock used by the command, like the timer of [Command.Timeout],
+// and the time in [Stats], [OutputChunk] and [Command.Record], it should be
+// called before the other methods using time.
+func (c *Command) WithClock(clock Clock) *Command {
+	c.mu.Lock()
+	c.clock = clock
+	c.mu.Unlock()
+	return c
+}
+
+// getClock returns the clock of command, the real clock if not set
+func (c *Command) getClock() Clock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.clock == nil {
+		return realClock{}
+	}
+	return c.clock
+}
+
+// now returns the current time of command clock
+func (c *Command) now() time.Time {
+	return c.getClock().Now()
+}
+
+// withClockTimeout is context.WithTimeout by clock
+func withClockTimeout(parent context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	timer := clock.AfterFunc(timeout, cancel)
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
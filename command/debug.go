@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// debugEnv is the env enabling the debug output to stderr, see [SetDebug]
+const debugEnv = "BETTER_COMMAND_DEBUG"
+
+var (
+	debugMu     sync.Mutex
+	debugWriter = debugFromEnv()
+)
+
+func debugFromEnv() io.Writer {
+	if v := os.Getenv(debugEnv); v != "" && v != "0" {
+		return os.Stderr
+	}
+	return nil
+}
+
+// SetDebug sets the process wide writer of the debug output, nil disables it.
+// For every command, the final escaped args are written when it starts, then
+// the duration and exit status when it exits, with the secrets of
+// [Command.Redact] masked. The debug output is written to stderr if the env
+// BETTER_COMMAND_DEBUG=1 is set, thus the quoting can be diagnosed without
+// changing the code.
+func SetDebug(w io.Writer) {
+	debugMu.Lock()
+	debugWriter = w
+	debugMu.Unlock()
+}
+
+// debugf writes a line of the debug output if enabled
+func (c *Command) debugf(format string, args ...interface{}) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugWriter == nil {
+		return
+	}
+	fmt.Fprintln(debugWriter, "better-command: "+c.Redacted(fmt.Sprintf(format, args...)))
+}
+
+// Echo writes the final escaped args of command to w just before it starts, like
+// `make` and `set -x`, with the secrets of [Command.Redact] masked, thus the CLIs
+// can offer a `--verbose` flag simply. The errors writing to w are ignored.
+//
+// It's called by [Command.BeforeStart], thus only the first process of
+// [Command.Retry] and [Command.AutoChunk] is echoed.
+func (c *Command) Echo(w io.Writer) *Command {
+	return c.BeforeStart(func(cmd *exec.Cmd) error {
+		fmt.Fprintln(w, c.Redacted(QuoteArgs(cmd.Args)))
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"regexp"
+	"testing"
+)
+
+func TestSetDebug(t *testing.T) {
+	var b bytes.Buffer
+	SetDebug(&b)
+	NewSh(`echo %s; exit 3`, "a b").Redact("b").Run()
+	New([]string{"/nonexistent"}).Run()
+	// the writes are done under debugMu
+	SetDebug(nil)
+	for _, re := range []string{
+		`better-command: start pid \d+: sh -c 'echo a\\ \*\*\*\*\*\*; exit 3'`,
+		`better-command: exit pid \d+ after \S+: exit status 3`,
+		`better-command: start /nonexistent: .*no such file`,
+	} {
+		if !regexp.MustCompile(re).MatchString(b.String()) {
+			t.Errorf("want %s in:\n%s", re, b.String())
+		}
+	}
+	n := b.Len()
+	if NewSh(`true`).Run(); b.Len() != n {
+		t.Fatal("debug output should be disabled")
+	}
+}
+
+func TestEcho(t *testing.T) {
+	var b bytes.Buffer
+	err := NewSh(`echo %s >/dev/null`, "a b").Redact("b").Echo(&b).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "sh -c 'echo a\\ ****** >/dev/null'\n"; b.String() != want {
+		t.Fatalf("got %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	NewSh(`true`).Echo(&b).BeforeStart(func(*exec.Cmd) error { return errors.New("abort") }).Run()
+	if b.String() != "sh -c true\n" {
+		t.Fatalf("got %q", b.String())
+	}
+}
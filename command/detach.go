@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// StartDetached starts the command fully detached from the current process and
+// returns immediately, in a new session on POSIX or without the console on
+// Windows, thus it outlives the current process like a daemon. Unlike Spawn, the
+// command is never killed when its context is canceled, and Wait must not be called.
+//
+// The stdin, stdout and stderr should be files or nil for [os.DevNull], since
+// the pipes would be closed when the current process exits. The OnStart functions
+// are not called, and the OnExit functions are called after the command exits if
+// the current process is still running.
+func (c *Command) StartDetached() (pid int, err error) {
+	if c.LastError != nil {
+		c.cleanup()
+		return 0, c.LastError
+	}
+	if _, err = c.setup(); err != nil {
+		c.cleanup()
+		return 0, err
+	}
+	for _, v := range []struct {
+		name string
+		file interface{}
+	}{{"Stdin", c.Cmd.Stdin}, {"Stdout", c.Cmd.Stdout}, {"Stderr", c.Cmd.Stderr}} {
+		if _, ok := v.file.(*os.File); !ok && v.file != nil {
+			c.cleanup()
+			return 0, fmt.Errorf("StartDetached: %s should be a file or nil", v.name)
+		}
+	}
+	if err = c.beforeStartHooks(); err != nil {
+		c.cleanup()
+		return 0, err
+	}
+	if err = c.Ctx.Err(); err != nil {
+		c.cleanup()
+		return 0, c.causeOf(err)
+	}
+
+	// the Cmd without the context, which kills the process when canceled
+	prev := c.Cmd
+	cmd := exec.Command(prev.Path)
+	cmd.Args = prev.Args
+	cmd.Env = prev.Env
+	cmd.Dir = prev.Dir
+	cmd.Stdin = prev.Stdin
+	cmd.Stdout = prev.Stdout
+	cmd.Stderr = prev.Stderr
+	cmd.ExtraFiles = prev.ExtraFiles
+	cmd.SysProcAttr = prev.SysProcAttr
+	detach(cmd)
+	c.mu.Lock()
+	c.Cmd = cmd
+	c.detached = true
+	c.mu.Unlock()
+	if err = cmd.Start(); err != nil {
+		c.debugf("start %s: %v", QuoteArgs(cmd.Args), err)
+		c.cleanup()
+		c.markDone()
+		return 0, err
+	}
+	startTime := c.now()
+	c.mu.Lock()
+	c.startTime = startTime
+	c.Pid = cmd.Process.Pid
+	pid = c.Pid
+	c.mu.Unlock()
+	c.debugf("start detached pid %d: %s", pid, QuoteArgs(cmd.Args))
+	go func() {
+		// reap the process if it exits before the current process
+		cmd.Wait()
+		exitTime := c.now()
+		c.mu.Lock()
+		c.exitTime = exitTime
+		c.mu.Unlock()
+		c.cleanup()
+		c.markDone()
+	}()
+	return pid, nil
+}
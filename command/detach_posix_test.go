@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStartDetached(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out")
+	c := NewSh(`sleep 0.2; echo done > %s`, file).Timeout(20 * time.Millisecond)
+	pid, err := c.StartDetached()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgid, err := syscall.Getpgid(pid); err != nil || pgid != pid {
+		t.Fatalf("got pgid %d of %d, %v", pgid, pid, err)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("not reaped")
+	}
+	// not killed by the timeout
+	b, err := os.ReadFile(file)
+	if err != nil || string(b) != "done\n" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	if code, ok := c.ExitCode(); !ok || code != 0 {
+		t.Fatalf("got exit code %d %v", code, ok)
+	}
+
+	c = NewSh(`true`)
+	c.Cmd.Stdout = new(bytes.Buffer)
+	if _, err := c.StartDetached(); err == nil {
+		t.Fatal("want error")
+	}
+}
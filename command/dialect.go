@@ -0,0 +1,98 @@
+package command
+
+import "unicode/utf8"
+
+// dialect is the escaping rules of a shell
+type dialect int
+
+const (
+	// dialectPOSIX is for sh, bash, dash and other POSIX shells
+	dialectPOSIX dialect = iota
+	// dialectZsh is for zsh, `=cmd` is expanded to the path of cmd
+	dialectZsh
+	// dialectFish is for fish, which has no ${VAR}, and treat % as process expansion
+	dialectFish
+	// dialectPOSIXCompat is for strict POSIX sh, see [Command.POSIXCompat]
+	dialectPOSIXCompat
+)
+
+// escapeRune append the escaped rune of s at i into b by the special rules of d,
+// and returns the index of the next rune, or false if it should be escaped by
+// the common rules.
+func (d dialect) escapeRune(b []byte, s string, i int) ([]byte, int, bool) {
+	v := s[i]
+	if v == '\n' && d != dialectFish {
+		// backslash-newline is a line continuation, so quote it
+		return append(b, '\'', v, '\''), i + 1, true
+	}
+	switch d {
+	case dialectPOSIXCompat:
+		// the backslash escapes of the control and non-ASCII characters differ
+		// between the ash builds, like the ones without Unicode support, while
+		// the single-quoted strings are literal in every POSIX sh
+		if v < ' ' || v >= 0x7f {
+			size := 1
+			if v >= utf8.RuneSelf {
+				_, size = utf8.DecodeRuneInString(s[i:])
+			}
+			b = append(b, '\'')
+			b = append(b, s[i:i+size]...)
+			return append(b, '\''), i + size, true
+		}
+	case dialectZsh:
+		if v == '=' {
+			return append(b, '\\', v), i + 1, true
+		}
+	case dialectFish:
+		switch v {
+		case '\n':
+			return append(b, '\\', 'n'), i + 1, true
+		case '\t':
+			return append(b, '\\', 't'), i + 1, true
+		case '\r':
+			return append(b, '\\', 'r'), i + 1, true
+		case '%':
+			return append(b, '\\', v), i + 1, true
+		}
+	}
+	return b, i, false
+}
+
+// NewZsh just like [NewSh], but run []string{"zsh", "-c", cmdString} by default
+func NewZsh(cmdString string, parts ...string) *Command {
+	return newCommand(dialectZsh, []string{"zsh", "-c", cmdString}, parts)
+}
+
+// NewFish just like [NewSh], but run []string{"fish", "-c", cmdString} by default,
+// the arguments are escaped by the rules of fish, thus only $VAR is allowed
+// for %s and "%s", ${VAR} is escaped since fish doesn't support it.
+func NewFish(cmdString string, parts ...string) *Command {
+	return newCommand(dialectFish, []string{"fish", "-c", cmdString}, parts)
+}
+
+// rerender substitute the templates with parts again by the current dialect,
+// the Args that is not from templates, or changed after rendered are kept.
+func (c *Command) rerender() {
+	offset := len(c.Cmd.Args) - len(c.templates)
+	if offset < 0 {
+		return
+	}
+	r := render{nonce: c.render.nonce, home: c.render.home, params: c.render.params, verbs: c.render.verbs}
+	args := make([]string, len(c.templates))
+	for i, v := range c.templates {
+		s, err := substitute(v, c.parts, c.dialect, &r)
+		if err != nil {
+			c.LastError = err
+			return
+		}
+		args[i] = s
+	}
+	for i, s := range args {
+		// keep the args changed after rendered, like by [Command.Shell]
+		if c.Cmd.Args[offset+i] == c.rendered[i] {
+			c.Cmd.Args[offset+i] = s
+		}
+	}
+	c.render = r
+	c.rendered = args
+}
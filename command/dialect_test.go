@@ -0,0 +1,32 @@
+package command
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewZsh(t *testing.T) {
+	cmd := NewZsh(`echo %s '%s'`, "=ls $HOME", "=ls")
+	if diff := cmp.Diff(cmd.Args, []string{"zsh", "-c", `echo \=ls\ $HOME '=ls'`}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestNewFish(t *testing.T) {
+	cmd := NewFish(`echo %s '%s'`, "${HOME} $HOME %self\nx", `a\'b`)
+	if diff := cmp.Diff(cmd.Args, []string{"fish", "-c", `echo \$\{HOME\}\ $HOME\ \%self\nx 'a\\\'b'`}); diff != "" {
+		t.Fatal(diff)
+	}
+	if _, err := exec.LookPath("fish"); err != nil {
+		t.Skip("fish not installed")
+	}
+	b, err := NewFish(`echo %s '%s'`, "a;b$(ls)", `c\'d`).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a;b$(ls) c\\'d\n" {
+		t.Fatal("fish output should be escaped", string(b))
+	}
+}
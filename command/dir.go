@@ -0,0 +1,90 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DirCreate run command with PWD set to dir like [Command.Dir], the dir and its
+// parents are created with perm by [os.MkdirAll] if missing, the %s in dir are
+// substituted by parts as Dir. LastError will be set if dir can't be created or isn't
+// a directory.
+func (c *Command) DirCreate(dir string, perm os.FileMode, parts ...string) *Command {
+	dir, err := dirPath(dir, parts)
+	if err == nil && dir == "" {
+		err = fmt.Errorf("empty dir")
+	}
+	if err == nil {
+		err = os.MkdirAll(dir, perm)
+	}
+	if err == nil {
+		err = checkDir(dir)
+	}
+	if err != nil {
+		c.LastError = fmt.Errorf("DirCreate: %w", err)
+		return c
+	}
+	c.Cmd.Dir = dir
+	return c
+}
+
+// dirPath substitutes the %s in dir by parts, %% is a literal %
+func dirPath(dir string, parts []string) (string, error) {
+	if len(parts) == 0 {
+		return dir, nil
+	}
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(dir); i++ {
+		if dir[i] != '%' {
+			b.WriteByte(dir[i])
+			continue
+		}
+		if i++; i == len(dir) {
+			return "", fmt.Errorf("%q: trailing %%", dir)
+		}
+		switch dir[i] {
+		case '%':
+			b.WriteByte('%')
+		case 's':
+			if n == len(parts) {
+				return "", fmt.Errorf("%q: missing part of %%s", dir)
+			}
+			if err := checkDirPart(parts[n]); err != nil {
+				return "", err
+			}
+			b.WriteString(parts[n])
+			n++
+		default:
+			return "", fmt.Errorf("%q: unknown verb %%%c", dir, dir[i])
+		}
+	}
+	if n != len(parts) {
+		return "", fmt.Errorf("%q: %d parts for %d %%s", dir, len(parts), n)
+	}
+	return b.String(), nil
+}
+
+// checkDirPart checks part is a single name of path
+func checkDirPart(part string) error {
+	if part == "" || part == "." || part == ".." || strings.ContainsAny(part, "/\\\x00") {
+		return fmt.Errorf("invalid part %q of dir", part)
+	}
+	return nil
+}
+
+// checkDir checks dir is an existing directory, empty dir is the current dir
+func checkDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dir   string
+		parts []string
+		want  string
+		err   string
+	}{
+		{dir: "", want: ""},
+		{dir: tmp, want: tmp},
+		{dir: tmp + "/100%", err: "no such file"},
+		{dir: filepath.Join(tmp, "%s"), parts: []string{"file"}, err: "not a directory"},
+		{dir: filepath.Join(tmp, "missing"), err: "no such file"},
+		{dir: file, err: "not a directory"},
+		{dir: filepath.Join(tmp, "%s"), parts: []string{".."}, err: "invalid part"},
+		{dir: filepath.Join(tmp, "%s"), parts: []string{"a/../.."}, err: "invalid part"},
+		{dir: filepath.Join(tmp, "%s"), parts: []string{"a", "b"}, err: "2 parts for 1"},
+		{dir: "%s" + string(filepath.Separator) + "%s", parts: []string{"a"}, err: "missing part"},
+	}
+	for _, v := range tests {
+		c := New([]string{"true"}).Dir(v.dir, v.parts...)
+		if v.err != "" {
+			if c.LastError == nil || !strings.Contains(c.LastError.Error(), v.err) {
+				t.Errorf("Dir(%q, %q) got %v, want %q", v.dir, v.parts, c.LastError, v.err)
+			}
+			continue
+		}
+		if c.LastError != nil || c.WorkDir() != v.want {
+			t.Errorf("Dir(%q, %q) got %q, %v", v.dir, v.parts, c.WorkDir(), c.LastError)
+		}
+	}
+}
+
+func TestDirCreate(t *testing.T) {
+	tmp := t.TempDir()
+	c := New([]string{"true"}).DirCreate(filepath.Join(tmp, "builds", "%s"), 0700, "100%")
+	want := filepath.Join(tmp, "builds", "100%")
+	if c.LastError != nil || c.WorkDir() != want {
+		t.Fatalf("got %q, %v", c.WorkDir(), c.LastError)
+	}
+	if fi, err := os.Stat(want); err != nil || !fi.IsDir() {
+		t.Fatalf("got %v, %v", fi, err)
+	}
+	// existing dir is fine
+	if c := New([]string{"true"}).DirCreate(want, 0700); c.LastError != nil {
+		t.Fatal(c.LastError)
+	}
+	if c := New([]string{"true"}).DirCreate(filepath.Join(tmp, "%s"), 0700, ".."); c.LastError == nil {
+		t.Fatal("want invalid part error")
+	}
+	if c := New([]string{"true"}).DirCreate("", 0700); c.LastError == nil {
+		t.Fatal("want empty dir error")
+	}
+}
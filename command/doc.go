@@ -21,6 +21,21 @@
 //   - %s or "%s": will escape everything, except for shell variables like $ABC, or ${ABC}, any other variables form not accepted.
 //   - '%s': will escape everything, shell variables also be escaped.
 //
+// The %s inside a heredoc body follows the heredoc rules: it's kept as is for <<'EOF', and `\`, `$`, "`" are escaped for <<EOF,
+// the argument cannot contain the delimiter line, otherwise LastError will be set.
+//
+// The argument for %F will be written to a temp file, and the %F is replaced by the path of the file, which is removed on exit,
+// for the arguments too large or too sensitive to appear on the command line. The %F is only a placeholder at the start of a word or after `=`,
+// and only with [Command.ExtendedVerbs], otherwise it's kept as is, like `stat -c %F`.
+//
+// The argument for %g is a glob pattern, the `*`, `?` and bracket expressions like `[a-z]` are kept to be expanded by the shell,
+// everything else is escaped, and the invalid patterns are rejected. The %g is only a placeholder in the unquoted words,
+// and only with [Command.ExtendedVerbs], otherwise it's kept as is, like `printf %g`.
+//
+// The argument for %p is a path, the leading `~` or `~user` is expanded to the home dir, of the target user of [Command.AsUser] if set,
+// then escaped like '%s'. The %p is only a placeholder at the start of an unquoted word or after `=`,
+// and only with [Command.ExtendedVerbs], otherwise it's kept as is, like `find -printf %p`.
+//
 // The [New]([]string, args...) and [NewSh](string, args...) method argments just like [fmt.Printf], the first arg is formatString, rest is format arguments, but with one exception: they can only accept %s as format placeholder. If you want use like %v, you can manually invoke [String()] method of the argument to pass as string.
 //
 // # Handy
@@ -45,11 +60,103 @@
 //   - [command.Stderr]
 //   - [command.Shell]
 //   - [command.OnExit]
+//   - [command.NormalizeNewlines]
+//   - [command.TrimTrailingNewline]
+//   - [command.CaptureTail]
+//   - [command.SpillToDisk]
+//   - [command.WrapStdout]
+//   - [command.CompressOutput]
+//   - [command.StdinString]
+//   - [command.StdinBytes]
+//   - [command.StdinFile]
+//   - [command.NoStdin]
+//   - [command.InteractiveStdin]
+//   - [command.Monitor]
+//   - [command.KillIfRSSAbove]
+//   - [command.StrictShell]
+//   - [command.Trace]
+//   - [command.Interactive]
+//   - [command.AutoShell]
+//   - [command.POSIXCompat]
+//   - [command.Redact]
+//   - [command.Elevate]
+//   - [command.AsGroup]
+//   - [command.AsUID]
+//   - [command.AsUserLogin]
+//   - [command.Elevated]
+//   - [command.ElevateGUI]
+//   - [command.TransientUnit]
+//   - [command.Record]
+//   - [command.WithClock]
+//   - [command.AutoChunk]
+//   - [command.SecretFD]
+//   - [command.SanitizeEnv]
+//   - [command.RejectUnsafeEnv]
+//   - [command.Umask]
+//   - [command.NewSession]
+//   - [command.Foreground]
+//   - [command.ForwardSignals]
+//   - [command.IgnoreParentInterrupt]
+//   - [command.KillPolicy]
+//   - [command.KillScope]
+//   - [command.CancelSignal]
+//   - [command.KillDelay]
+//   - [command.StartTimeout]
+//   - [command.Limit]
+//   - [command.Breaker]
+//   - [command.WithSemaphore]
+//   - [command.Retry]
+//   - [command.StdinTemplate]
+//   - [command.StdinEscape]
+//   - [command.WithParams]
+//   - [command.OnEvent]
+//   - [command.OnRetry]
+//   - [command.OnFailure]
+//   - [command.BeforeStart]
+//   - [command.OnSignal]
+//   - [command.Progress]
+//   - [command.ProgressInterval]
+//   - [command.OnWriteError]
+//   - [command.Quiet]
+//   - [command.Echo]
+//   - [command.Subreaper]
+//   - [command.DieWithParent]
+//   - [command.ProcAttr]
+//   - [command.PassFile]
+//   - [command.TempDir]
+//   - [command.KeepTempDirOnFailure]
+//   - [command.DirCreate]
+//   - [command.LookPathIn]
+//   - [command.NoLookPath]
+//   - [command.ExtendedVerbs]
 //
 // But below methods cannot be chained(finalize):
 //   - [command.Run]
 //   - [command.Output]
 //   - [command.CombinedOutput]
+//   - [command.OutputSpill]
+//   - [command.CombinedOutputSpill]
+//   - [command.OutputToFile]
+//   - [command.Spawn]
+//   - [command.StreamOutput]
+//   - [command.ExportSystemdUnit]
+//   - [command.ExportLaunchdPlist]
+//   - [command.OutputString]
+//   - [command.OutputTrimmed]
+//   - [command.OutputJSON]
+//   - [command.OutputYAML]
+//   - [command.OutputYAMLAll]
+//   - [command.OutputTable]
+//   - [command.OutputRecords]
+//   - [command.OutputSplit]
+//   - [command.OutputMatch]
+//   - [command.OutputSubmatches]
+//   - [command.OutputScan]
+//   - [command.MergedOutput]
+//   - [command.Transcript]
+//   - [command.CombinedOutputTail]
+//   - [command.Exec]
+//   - [command.StartDetached]
 //
 // For more information please checkout the godoc.
 package command
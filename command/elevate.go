@@ -0,0 +1,51 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ElevateEnv is the environment variable to choose the backend of [Command.Elevate],
+// like BETTER_COMMAND_ELEVATE=doas.
+const ElevateEnv = "BETTER_COMMAND_ELEVATE"
+
+// elevateBackends is the detection order of [DetectElevate]
+var elevateBackends = []string{"sudo", "doas", "pkexec", "run0"}
+
+// DetectElevate returns the first available privilege escalation backend of sudo,
+// doas, pkexec and run0, or the backend from [ElevateEnv] if set.
+func DetectElevate() (string, error) {
+	if v := os.Getenv(ElevateEnv); v != "" {
+		return v, nil
+	}
+	for _, v := range elevateBackends {
+		if _, err := exec.LookPath(v); err == nil {
+			return v, nil
+		}
+	}
+	return "", errors.New("no privilege escalation backend found")
+}
+
+// ElevateBackend use backend for [Command.Elevate] instead of detecting,
+// it's one of sudo, doas, pkexec and run0.
+func ElevateBackend(backend string) SudoOption {
+	return func(o *sudoOptions) { o.backend = backend }
+}
+
+// Elevate is like [Command.UseSudo], but the backend is chosen by [DetectElevate],
+// the opts are translated to the flags of the backend, LastError is set if the
+// option is not supported by the backend, like group for doas.
+func (c *Command) Elevate(opts ...SudoOption) *Command {
+	o := newSudoOptions("", opts)
+	if o.backend == "" {
+		backend, err := DetectElevate()
+		if err != nil {
+			c.LastError = fmt.Errorf("Elevate: %w", err)
+			return c
+		}
+		o.backend = backend
+	}
+	return c.elevate(o)
+}
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestElevateBackend(t *testing.T) {
+	opts := []SudoOption{SudoUser("nobody"), SudoNonInteractive(), SudoPreserveEnv("PATH")}
+	tests := map[string][]string{
+		"doas":   {"doas", "-n", "-u", "nobody"},
+		"pkexec": {"pkexec", "--user", "nobody"},
+		"run0":   {"run0", "--no-ask-password", "--user=nobody", "--setenv=PATH"},
+	}
+	for backend, want := range tests {
+		args, err := newSudoOptions(backend, opts).args()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(args, want); diff != "" {
+			t.Fatal(backend, diff)
+		}
+	}
+	if _, err := newSudoOptions("doas", []SudoOption{SudoGroup("wheel")}).args(); err == nil {
+		t.Fatal("doas should not support group")
+	}
+}
+
+func TestElevate(t *testing.T) {
+	os.Setenv(ElevateEnv, "doas")
+	defer os.Unsetenv(ElevateEnv)
+	if backend, _ := DetectElevate(); backend != "doas" {
+		t.Fatal("backend should be from env", backend)
+	}
+	cmd := NewSh(`whoami`).Elevate(SudoUser("nobody"), SudoGroup("wheel"))
+	if cmd.LastError == nil {
+		t.Fatal("should error for unsupported option")
+	}
+}
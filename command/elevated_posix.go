@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+// Elevated run command as administrator, on POSIX it's same as [Command.Elevate]
+// without options, on windows it shows the UAC prompt.
+func (c *Command) Elevated() *Command {
+	return c.Elevate()
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package command
+
+// Elevated run command as administrator with the "runas" verb of ShellExecuteEx,
+// which shows the UAC prompt, waits for completion and exits with the exit code
+// of the command. It's done through `powershell Start-Process -Verb RunAs`.
+//
+// The elevated process runs in a new console, so the stdin, stdout and
+// stderr of command are not connected.
+func (c *Command) Elevated() *Command {
+	return c.wrapArgs(func(c *Command) error {
+		script := elevatedScript(c.Cmd.Path, c.Cmd.Args[1:])
+		c.Cmd.Args = []string{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script}
+		c.setPath("powershell.exe")
+		return c.LastError
+	})
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+// +build darwin
+
+package command
+
+// ElevateGUI run command with administrator privileges by `osascript`, which shows
+// the native password dialog of macOS with prompt, instead of requiring a terminal sudo.
+//
+// The command runs by `do shell script`, so the stdin is not connected, the env is
+// not passed, and the stdout is returned after the command exit.
+func (c *Command) ElevateGUI(prompt string) *Command {
+	return c.wrapArgs(func(c *Command) error {
+		script := adminScript(c.Cmd.Args, c.Cmd.Dir, prompt)
+		c.Cmd.Args = []string{"/usr/bin/osascript", "-e", script}
+		c.setPath("/usr/bin/osascript")
+		return c.LastError
+	})
+}
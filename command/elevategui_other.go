@@ -0,0 +1,13 @@
+//go:build !darwin
+// +build !darwin
+
+package command
+
+import "errors"
+
+// ElevateGUI run command with administrator privileges by the native password
+// dialog, it's only supported on macOS.
+func (c *Command) ElevateGUI(prompt string) *Command {
+	c.LastError = errors.New("ElevateGUI: only support darwin")
+	return c
+}
@@ -0,0 +1,165 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// EventType is the type of [Event]
+type EventType int
+
+const (
+	// EventConstructed is emitted when [Command.OnEvent] is called, at the time
+	// the command was constructed
+	EventConstructed EventType = iota
+	// EventStarted is emitted after the process started, with Pid, it's emitted
+	// again for the processes of [Command.Retry] and [Command.AutoChunk]
+	EventStarted
+	// EventOutput is emitted for each chunk written to the Stdout or Stderr of
+	// command, with Data and Stderr, it's not emitted if they are not set
+	EventOutput
+	// EventSignaled is emitted before Signal is sent by the [KillPolicy]
+	EventSignaled
+	// EventKilled is emitted before the command is killed since its context is
+	// canceled, Err is the error of context, or the reason like [ErrStartTimeout]
+	EventKilled
+	// EventExited is emitted after the command exited, with ExitCode and the Err of Wait
+	EventExited
+	// EventRetryScheduled is emitted before the delay of [Command.Retry],
+	// with Attempt, Delay and the Err of the failed attempt
+	EventRetryScheduled
+)
+
+var eventTypeNames = [...]string{
+	EventConstructed:    "constructed",
+	EventStarted:        "started",
+	EventOutput:         "output",
+	EventSignaled:       "signaled",
+	EventKilled:         "killed",
+	EventExited:         "exited",
+	EventRetryScheduled: "retry scheduled",
+}
+
+func (t EventType) String() string {
+	if t < 0 || int(t) >= len(eventTypeNames) {
+		return "unknown"
+	}
+	return eventTypeNames[t]
+}
+
+// Event is an event in the lifecycle of a command, see [Command.OnEvent]
+type Event struct {
+	Type EventType
+	// Time is the time of event by the clock of [Command.WithClock]
+	Time time.Time
+	// Pid is the pid of the process, 0 before started
+	Pid int
+	// Data is the output chunk of EventOutput, from the stderr if Stderr is true
+	Data   []byte
+	Stderr bool
+	// Signal is the signal of EventSignaled
+	Signal os.Signal
+	// ExitCode is the exit code of EventExited, -1 if it's terminated by signal
+	ExitCode int
+	// Attempt is the attempt to run next, and Delay is the delay before it,
+	// of EventRetryScheduled
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+// OnEvent set f to receive the events of the lifecycle of command, thus the
+// supervisors and UIs can build the full timeline. The calls of f are serialized,
+// but may be from different goroutines, f should return quickly and must not
+// block, since it's called in the paths of command, like writing the output.
+//
+// The Stdout and Stderr of command are passed through pipes to emit the output,
+// even if they're files, and the output of CombinedOutput is emitted as stdout.
+func (c *Command) OnEvent(f func(Event)) *Command {
+	c.mu.Lock()
+	first := len(c.events) == 0
+	c.events = append(c.events, f)
+	created := c.created
+	c.mu.Unlock()
+	c.eventMu.Lock()
+	f(Event{Type: EventConstructed, Time: created})
+	c.eventMu.Unlock()
+	if !first {
+		return c
+	}
+	return c.prepare(func(c *Command) error {
+		if sameWriter(c.Cmd.Stdout, c.Cmd.Stderr) {
+			// the combined output is emitted as stdout
+			w := &eventWriter{c, c.Cmd.Stdout, false}
+			c.Cmd.Stdout, c.Cmd.Stderr = w, w
+			return nil
+		}
+		if c.Cmd.Stdout != nil {
+			c.Cmd.Stdout = &eventWriter{c, c.Cmd.Stdout, false}
+		}
+		if c.Cmd.Stderr != nil {
+			c.Cmd.Stderr = &eventWriter{c, c.Cmd.Stderr, true}
+		}
+		return nil
+	})
+}
+
+// emit sends e to the functions of OnEvent
+func (c *Command) emit(e Event) {
+	c.mu.RLock()
+	events := c.events
+	e.Pid = c.Pid
+	c.mu.RUnlock()
+	if len(events) == 0 {
+		return
+	}
+	e.Time = c.now()
+	c.eventMu.Lock()
+	defer c.eventMu.Unlock()
+	for _, f := range events {
+		f(e)
+	}
+}
+
+// emitSignal sends e of EventSignaled or EventKilled, unless the command already
+// exited, since the cleanup of Wait also cancels the context to kill the rest of
+// the process group.
+func (c *Command) emitSignal(e Event) {
+	c.mu.RLock()
+	exited := !c.exitTime.IsZero()
+	c.mu.RUnlock()
+	if !exited {
+		c.emit(e)
+	}
+}
+
+// emitExited sends the EventExited of the result err of Wait
+func (c *Command) emitExited(err error) {
+	code := -1
+	var status *ExitStatusError
+	if c.ProcessState != nil {
+		code = c.ProcessState.ExitCode()
+	} else if errors.As(err, &status) {
+		code = status.Code
+	} else if err == nil {
+		code = 0
+	}
+	c.emit(Event{Type: EventExited, ExitCode: code, Err: err})
+}
+
+// eventWriter emits the EventOutput of the chunks written to w
+type eventWriter struct {
+	c      *Command
+	w      io.Writer
+	stderr bool
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.c.emit(Event{Type: EventOutput, Data: append([]byte(nil), p[:n]...), Stderr: w.stderr})
+	}
+	return n, err
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOnEventKilled(t *testing.T) {
+	var l eventLog
+	err := NewSh(`sleep 10`).Timeout(50 * time.Millisecond).
+		KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: time.Second}}).OnEvent(l.add).Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if got := l.types(); got != "constructed,started,signaled,exited" {
+		t.Fatalf("got %s", got)
+	}
+	if l.events[2].Signal != syscall.SIGTERM || l.events[3].ExitCode != -1 {
+		t.Fatalf("got %v and exit code %d", l.events[2].Signal, l.events[3].ExitCode)
+	}
+
+	var l2 eventLog
+	err = NewSh(`trap '' TERM; sleep 10`).StartTimeout(50 * time.Millisecond).
+		KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: 20 * time.Millisecond}}).OnEvent(l2.add).Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if got := l2.types(); got != "constructed,started,signaled,killed,exited" {
+		t.Fatalf("got %s", got)
+	}
+	if !errors.Is(l2.events[3].Err, ErrStartTimeout) {
+		t.Fatalf("got %v", l2.events[3].Err)
+	}
+}
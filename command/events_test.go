@@ -0,0 +1,102 @@
+package command
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) add(e Event) {
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+}
+
+func (l *eventLog) types() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var s []string
+	for _, e := range l.events {
+		if e.Type != EventOutput {
+			s = append(s, e.Type.String())
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *eventLog) output(stderr bool) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var s string
+	for _, e := range l.events {
+		if e.Type == EventOutput && e.Stderr == stderr {
+			s += string(e.Data)
+		}
+	}
+	return s
+}
+
+func TestOnEvent(t *testing.T) {
+	var l eventLog
+	c := NewSh(`echo out; echo err >&2; exit 3`).OnEvent(l.add)
+	var stderr strings.Builder
+	c.Cmd.Stderr = &stderr
+	out, err := c.Output()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if got := l.types(); got != "constructed,started,exited" {
+		t.Fatalf("got %s", got)
+	}
+	if string(out) != "out\n" || stderr.String() != "err\n" {
+		t.Fatalf("got %q and %q", out, stderr.String())
+	}
+	if l.output(false) != "out\n" || l.output(true) != "err\n" {
+		t.Fatalf("got %q and %q", l.output(false), l.output(true))
+	}
+	started, exited := l.events[1], l.events[len(l.events)-1]
+	if started.Pid == 0 || exited.Pid != started.Pid {
+		t.Fatalf("got pids %d and %d", started.Pid, exited.Pid)
+	}
+	if exited.ExitCode != 3 || exited.Err != err {
+		t.Fatalf("got exit code %d and %v", exited.ExitCode, exited.Err)
+	}
+	if exited.Time.Before(started.Time) || started.Time.Before(l.events[0].Time) {
+		t.Fatal("events should be in time order")
+	}
+}
+
+func TestOnEventCombinedOutput(t *testing.T) {
+	var l eventLog
+	out, err := NewSh(`echo a; echo b >&2`).OnEvent(l.add).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "a\nb\n" || l.output(false) != "a\nb\n" {
+		t.Fatalf("got %q and %q", out, l.output(false))
+	}
+}
+
+func TestOnEventRetry(t *testing.T) {
+	var l eventLog
+	err := NewSh(`exit 1`).Retry(2, time.Millisecond).OnEvent(l.add).Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	want := "constructed,started,retry scheduled,started,retry scheduled,started,exited"
+	if got := l.types(); got != want {
+		t.Fatalf("got %s", got)
+	}
+	if e := l.events[2]; e.Attempt != 2 || e.Delay != time.Millisecond || e.Err == nil {
+		t.Fatalf("got %+v", e)
+	}
+	if e := l.events[4]; e.Attempt != 3 || e.Delay != 2*time.Millisecond {
+		t.Fatalf("got %+v", e)
+	}
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Executor starts the final command instead of the os process, used to record
+// and replay the commands in tests, see [SetExecutor].
+type Executor interface {
+	// Start starts the command after all the prepares and wrappers, and returns
+	// the function waiting for it to exit, the stdio of [exec.Cmd] should be
+	// served by the Executor.
+	Start(c *Command) (wait func() error, err error)
+}
+
+var (
+	executorMu sync.RWMutex
+	executor   Executor
+)
+
+// SetExecutor sets the process wide Executor starting every command of this
+// package, nil restores starting the os processes.
+func SetExecutor(e Executor) {
+	executorMu.Lock()
+	executor = e
+	executorMu.Unlock()
+}
+
+func currentExecutor() Executor {
+	executorMu.RLock()
+	defer executorMu.RUnlock()
+	return executor
+}
+
+// ExitStatusError is returned by the commands of [Executor] which not
+// started os process, for the non-zero exit code, like [exec.ExitError].
+type ExitStatusError struct {
+	Code int
+}
+
+func (e *ExitStatusError) Error() string {
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+// ExitCode returns the exit code
+func (e *ExitStatusError) ExitCode() int {
+	return e.Code
+}
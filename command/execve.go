@@ -0,0 +1,94 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Exec replaces the current process with the command by execve, after the
+// settings of command are applied like Spawn, thus the launchers and wrappers
+// don't leave a parent Go process holding the files and signals. It only returns
+// if the command can't be executed, and it's not supported on Windows.
+//
+// The current process is changed in place, the dir of [Command.Dir] and the user
+// of [Command.AsUser] are applied to the current process before execve, so they
+// are kept if execve fails after them. The stdin, stdout and stderr of current
+// process are inherited if they're not set, other than them, only [os.Stdin],
+// [os.Stdout] and [os.Stderr] can be set, and ExtraFiles are not supported. The
+// OnStart and OnExit functions are not called, and the temp files of %F are left
+// for the command.
+func (c *Command) Exec() error {
+	if c.LastError != nil {
+		c.cleanup()
+		c.markDone()
+		return c.LastError
+	}
+	if _, err := c.setup(); err != nil {
+		c.cleanup()
+		c.markDone()
+		return err
+	}
+	c.mu.RLock()
+	wrapped := len(c.stdoutWrappers) > 0
+	c.mu.RUnlock()
+	if err := checkExecFiles(c.Cmd, wrapped); err != nil {
+		c.cleanup()
+		c.markDone()
+		return fmt.Errorf("Exec: %w", err)
+	}
+	if err := c.beforeStartHooks(); err != nil {
+		c.cleanup()
+		c.markDone()
+		return err
+	}
+	if err := c.Ctx.Err(); err != nil {
+		c.cleanup()
+		c.markDone()
+		return c.causeOf(err)
+	}
+	path := c.Cmd.Path
+	if filepath.Base(path) == path {
+		var err error
+		if path, err = exec.LookPath(path); err != nil {
+			c.cleanup()
+			c.markDone()
+			return err
+		}
+	}
+	env := c.Cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	c.debugf("exec %s", QuoteArgs(c.Cmd.Args))
+	err := execve(c.Cmd, path, env)
+	c.cleanup()
+	c.markDone()
+	return fmt.Errorf("Exec: %w", err)
+}
+
+// checkExecFiles returns an error if the files of cmd can't be kept by execve
+func checkExecFiles(cmd *exec.Cmd, wrapped bool) error {
+	if wrapped {
+		return errors.New("the stdout wrappers are not supported")
+	}
+	if len(cmd.ExtraFiles) > 0 {
+		return errors.New("ExtraFiles are not supported")
+	}
+	for _, v := range []struct {
+		name string
+		file interface{}
+		std  *os.File
+	}{
+		{"Stdin", cmd.Stdin, os.Stdin},
+		{"Stdout", cmd.Stdout, os.Stdout},
+		{"Stderr", cmd.Stderr, os.Stderr},
+	} {
+		if v.file != nil && v.file != interface{}(v.std) {
+			return fmt.Errorf("%s should be nil or os.%s", v.name, v.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// execve applies the dir and credential of cmd to current process, then
+// replaces it with path
+func execve(cmd *exec.Cmd, path string, env []string) error {
+	if cmd.Dir != "" {
+		if err := os.Chdir(cmd.Dir); err != nil {
+			return err
+		}
+	}
+	if attr := cmd.SysProcAttr; attr != nil && attr.Credential != nil {
+		cred := attr.Credential
+		if !cred.NoSetGroups {
+			groups := make([]int, len(cred.Groups))
+			for i, v := range cred.Groups {
+				groups[i] = int(v)
+			}
+			if err := syscall.Setgroups(groups); err != nil {
+				return err
+			}
+		}
+		if err := syscall.Setgid(int(cred.Gid)); err != nil {
+			return err
+		}
+		if err := syscall.Setuid(int(cred.Uid)); err != nil {
+			return err
+		}
+	}
+	return syscall.Exec(path, cmd.Args, env)
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExec(t *testing.T) {
+	if os.Getenv("BETTER_COMMAND_EXEC_HELPER") != "" {
+		err := NewSh(`echo "$(pwd) $X"`).Dir(os.Getenv("BETTER_COMMAND_EXEC_HELPER")).
+			Env(append(os.Environ(), "X=x")).Exec()
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=TestExec$")
+	cmd.Env = append(os.Environ(), "BETTER_COMMAND_EXEC_HELPER="+dir)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	// the output of test is replaced by the command
+	if got := strings.TrimSpace(string(out)); got != dir+" x" {
+		t.Fatalf("got %q", got)
+	}
+
+	c := NewSh(`true`)
+	c.Cmd.Stdout = new(bytes.Buffer)
+	if err := c.Exec(); err == nil || !strings.Contains(err.Error(), "Stdout") {
+		t.Fatalf("got %v", err)
+	}
+	// the failed Exec is done, thus the slot is released
+	sem := NewSemaphore(1)
+	c = New([]string{"/nonexistent"}).WithSemaphore(sem)
+	if err := c.Exec(); err == nil {
+		t.Fatal("want error")
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("not done after Exec failed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sem.Acquire(ctx); err != nil {
+		t.Fatal("slot not released", err)
+	}
+}
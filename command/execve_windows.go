@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func execve(cmd *exec.Cmd, path string, env []string) error {
+	return errors.New("not supported on Windows")
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// killScopeNames are the names of KillScope for Explain
+var killScopeNames = [...]string{GroupOnly: "group", ChildOnly: "child", ChildThenGroup: "child then group"}
+
+// Explain returns the human readable description of what will happen when the
+// command runs: the resolved path, each arg with the template it's substituted
+// from, the env diff from current process, the dir, user, timeout and how it's
+// killed when canceled. The secrets of [Command.Redact] are masked.
+//
+// The steps applied when the command starts, like [Command.UseSudo], are not
+// included but counted.
+func (c *Command) Explain() string {
+	var b strings.Builder
+	c.mu.RLock()
+	prepares := len(c.prepares) + len(c.argsWrappers)
+	timeout := c.timeout
+	scope := c.killScope
+	c.mu.RUnlock()
+
+	fmt.Fprintf(&b, "path: %s\n", c.Cmd.Path)
+	b.WriteString("args:\n")
+	offset := len(c.Cmd.Args) - len(c.templates)
+	for i, v := range c.Cmd.Args {
+		fmt.Fprintf(&b, "  [%d] %q", i, v)
+		if j := i - offset; offset >= 0 && j >= 0 && j < len(c.rendered) && v == c.rendered[j] && v != c.templates[j] {
+			fmt.Fprintf(&b, " substituted from %q", c.templates[j])
+		}
+		b.WriteByte('\n')
+	}
+	if c.Cmd.Env == nil {
+		b.WriteString("env: inherited\n")
+	} else if diff := envDiff(os.Environ(), c.Cmd.Env); len(diff) == 0 {
+		b.WriteString("env: same as current process\n")
+	} else {
+		b.WriteString("env:\n")
+		for _, v := range diff {
+			fmt.Fprintf(&b, "  %s\n", v)
+		}
+	}
+	if dir := c.Cmd.Dir; dir != "" {
+		fmt.Fprintf(&b, "dir: %s\n", dir)
+	} else {
+		b.WriteString("dir: current\n")
+	}
+	if u, g := c.credentialNames(); u != "" {
+		fmt.Fprintf(&b, "user: %s:%s\n", u, g)
+	}
+	if timeout > 0 {
+		fmt.Fprintf(&b, "timeout: %v\n", timeout)
+	}
+	b.WriteString("kill:")
+	for _, v := range c.getKillPolicy() {
+		fmt.Fprintf(&b, " %v then wait %v,", v.Signal, v.Wait)
+	}
+	fmt.Fprintf(&b, " SIGKILL the %s\n", killScopeNames[scope])
+	if prepares > 0 {
+		fmt.Fprintf(&b, "steps at start: %d\n", prepares)
+	}
+	return c.Redacted(b.String())
+}
+
+// envDiff returns the sorted changes of env from base, like `+KEY=value` for
+// the added or changed, and `-KEY` for the removed.
+func envDiff(base, env []string) []string {
+	toMap := func(env []string) map[string]string {
+		m := make(map[string]string, len(env))
+		for _, kv := range env {
+			if n := strings.IndexByte(kv, '='); n >= 0 {
+				m[kv[:n]] = kv[n+1:]
+			}
+		}
+		return m
+	}
+	old, cur := toMap(base), toMap(env)
+	var diff []string
+	for k, v := range cur {
+		if ov, ok := old[k]; !ok || ov != v {
+			diff = append(diff, "+"+k+"="+v)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			diff = append(diff, "-"+k)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool { return diff[i][1:] < diff[j][1:] })
+	return diff
+}
+
+// GoString implements [fmt.GoStringer] for %#v, with the secrets of [Command.Redact] masked.
+func (c *Command) GoString() string {
+	return c.Redacted(fmt.Sprintf("&command.Command{Path:%q, Args:%#v, Dir:%q, Pid:%d, LastError:%#v}",
+		c.Cmd.Path, c.Cmd.Args, c.Cmd.Dir, c.Pid, c.LastError))
+}
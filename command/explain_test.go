@@ -0,0 +1,44 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+	env := append(os.Environ(), "BC_EXPLAIN=1")
+	c := NewSh(`echo %s`, "a;s3cret").Env(env).Dir("/").Timeout(time.Minute).
+		CancelSignal(syscall.SIGTERM).Redact("s3cret")
+	s := c.Explain()
+	for _, want := range []string{
+		"path: /",
+		`[0] "sh"`,
+		`[2] "echo a\\;******" substituted from "echo %s"`,
+		"+BC_EXPLAIN=1",
+		"dir: /",
+		"timeout: 1m0s",
+		"kill: terminated then wait 5s, SIGKILL the group",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("want %q in:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "s3cret") {
+		t.Errorf("the secret should be masked:\n%s", s)
+	}
+	if s := New([]string{"true"}).Explain(); !strings.Contains(s, "env: inherited") || strings.Contains(s, "substituted") {
+		t.Errorf("got:\n%s", s)
+	}
+}
+
+func TestGoString(t *testing.T) {
+	s := fmt.Sprintf("%#v", NewSh(`echo %s`, "s3cret").Redact("s3cret"))
+	want := `Args:[]string{"sh", "-c", "echo ******"}`
+	if !strings.Contains(s, want) || !strings.HasPrefix(s, "&command.Command{") {
+		t.Fatalf("got %s", s)
+	}
+}
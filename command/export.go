@@ -0,0 +1,203 @@
+package command
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SystemdUnitOptions is the options of [Command.ExportSystemdUnit]
+type SystemdUnitOptions struct {
+	// Description of the unit
+	Description string
+	// After are the units to start after, like "network-online.target"
+	After []string
+	// Type is the service type, like "simple" or "oneshot"
+	Type string
+	// Restart is the restart policy, like "on-failure"
+	Restart string
+	// WantedBy is the install target, default to "multi-user.target"
+	WantedBy string
+	// Service are extra lines of the [Service] section, like "LimitNOFILE=65536"
+	Service []string
+}
+
+// LaunchdOptions is the options of [Command.ExportLaunchdPlist]
+type LaunchdOptions struct {
+	// Label is the unique job name, like "com.example.app", required
+	Label string
+	// RunAtLoad starts the job when it's loaded
+	RunAtLoad bool
+	// KeepAlive restarts the job whenever it exits
+	KeepAlive bool
+	// StandardOutPath is the file to write stdout
+	StandardOutPath string
+	// StandardErrorPath is the file to write stderr
+	StandardErrorPath string
+}
+
+// exportArgs returns the args with the absolute path of executable as first
+func (c *Command) exportArgs() ([]string, error) {
+	if c.LastError != nil {
+		return nil, c.LastError
+	}
+	path, err := filepath.Abs(c.Cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{path}, c.Cmd.Args[1:]...), nil
+}
+
+// exportEnv returns the env set for the command, the entries same as the
+// current process are omitted, since the service not inherits them.
+func (c *Command) exportEnv() []string {
+	inherited := map[string]bool{}
+	for _, v := range os.Environ() {
+		inherited[v] = true
+	}
+	var env []string
+	for _, v := range c.Cmd.Env {
+		if !inherited[v] {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
+// ExportSystemdUnit returns a systemd service unit running the command as configured,
+// with ExecStart, Environment, User, Group and WorkingDirectory, so the command
+// can be installed as a managed service.
+//
+// The env entries same as the current process are omitted, the steps run when the
+// command starts, like [Command.StrictShell], are not included.
+func (c *Command) ExportSystemdUnit(opts SystemdUnitOptions) (string, error) {
+	args, err := c.exportArgs()
+	if err != nil {
+		return "", fmt.Errorf("ExportSystemdUnit: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	if opts.Description != "" {
+		b.WriteString("Description=" + opts.Description + "\n")
+	}
+	if len(opts.After) > 0 {
+		b.WriteString("After=" + strings.Join(opts.After, " ") + "\n")
+	}
+	b.WriteString("\n[Service]\n")
+	if opts.Type != "" {
+		b.WriteString("Type=" + opts.Type + "\n")
+	}
+	quoted := make([]string, len(args))
+	for i, v := range args {
+		quoted[i] = quoteSystemd(v, true)
+	}
+	b.WriteString("ExecStart=" + strings.Join(quoted, " ") + "\n")
+	for _, v := range c.exportEnv() {
+		b.WriteString("Environment=" + quoteSystemd(v, false) + "\n")
+	}
+	if user, group := c.credentialNames(); user != "" {
+		b.WriteString("User=" + user + "\n")
+		b.WriteString("Group=" + group + "\n")
+	}
+	if c.Cmd.Dir != "" {
+		b.WriteString("WorkingDirectory=" + quoteSystemd(c.Cmd.Dir, false) + "\n")
+	}
+	if opts.Restart != "" {
+		b.WriteString("Restart=" + opts.Restart + "\n")
+	}
+	for _, v := range opts.Service {
+		b.WriteString(v + "\n")
+	}
+	wantedBy := opts.WantedBy
+	if wantedBy == "" {
+		wantedBy = "multi-user.target"
+	}
+	b.WriteString("\n[Install]\nWantedBy=" + wantedBy + "\n")
+	return b.String(), nil
+}
+
+// quoteSystemd quotes s as one word of systemd unit file, `%` specifiers are
+// escaped, and `$` variables too in command lines.
+func quoteSystemd(s string, cmdline bool) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if cmdline {
+		s = strings.ReplaceAll(s, "$", "$$")
+	}
+	if s != "" && !strings.ContainsAny(s, " \t\n\r\"';\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// ExportLaunchdPlist returns a launchd property list running the command as configured,
+// with ProgramArguments, EnvironmentVariables, UserName, GroupName and WorkingDirectory,
+// so the command can be installed as a launchd job.
+//
+// The env entries same as the current process are omitted, the steps run when the
+// command starts, like [Command.StrictShell], are not included.
+func (c *Command) ExportLaunchdPlist(opts LaunchdOptions) (string, error) {
+	args, err := c.exportArgs()
+	if err == nil && opts.Label == "" {
+		err = fmt.Errorf("empty Label")
+	}
+	if err != nil {
+		return "", fmt.Errorf("ExportLaunchdPlist: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	plistString(&b, "Label", opts.Label)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, v := range args {
+		b.WriteString("\t\t<string>" + escapeXML(v) + "</string>\n")
+	}
+	b.WriteString("\t</array>\n")
+	if env := c.exportEnv(); len(env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, v := range env {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			b.WriteString("\t\t<key>" + escapeXML(kv[0]) + "</key>\n\t\t<string>" + escapeXML(kv[1]) + "</string>\n")
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	if user, group := c.credentialNames(); user != "" {
+		plistString(&b, "UserName", user)
+		plistString(&b, "GroupName", group)
+	}
+	if c.Cmd.Dir != "" {
+		plistString(&b, "WorkingDirectory", c.Cmd.Dir)
+	}
+	if opts.StandardOutPath != "" {
+		plistString(&b, "StandardOutPath", opts.StandardOutPath)
+	}
+	if opts.StandardErrorPath != "" {
+		plistString(&b, "StandardErrorPath", opts.StandardErrorPath)
+	}
+	if opts.RunAtLoad {
+		b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	}
+	if opts.KeepAlive {
+		b.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String(), nil
+}
+
+func plistString(b *strings.Builder, key, value string) {
+	b.WriteString("\t<key>" + key + "</key>\n\t<string>" + escapeXML(value) + "</string>\n")
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExportSystemdUnit(t *testing.T) {
+	cmd := NewSh("echo %s $HOME 100%", "a b").Dir("/tmp")
+	cmd.Cmd.Env = append(os.Environ(), "A=x y")
+	unit, err := cmd.ExportSystemdUnit(SystemdUnitOptions{Description: "test", Restart: "on-failure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Description=test\n",
+		` -c "echo a\\ b $$HOME 100%%"` + "\n",
+		`Environment="A=x y"` + "\n",
+		"WorkingDirectory=/tmp\n",
+		"Restart=on-failure\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Fatalf("%q not found in %s", want, unit)
+		}
+	}
+	if strings.Contains(unit, "Environment=PATH=") {
+		t.Fatal("inherited env should be omitted")
+	}
+}
+
+func TestExportLaunchdPlist(t *testing.T) {
+	cmd := NewSh("echo %s", "<a&b>")
+	cmd.Cmd.Env = []string{"A=1"}
+	if _, err := cmd.ExportLaunchdPlist(LaunchdOptions{}); err == nil {
+		t.Fatal("should require Label")
+	}
+	plist, err := cmd.ExportLaunchdPlist(LaunchdOptions{Label: "com.example.test", KeepAlive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>com.example.test</string>\n",
+		`<string>-c</string>` + "\n\t\t" + `<string>echo \&lt;a\&amp;b\&gt;</string>` + "\n",
+		"<key>A</key>\n\t\t<string>1</string>\n",
+		"<key>KeepAlive</key>\n\t<true/>\n",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Fatalf("%q not found in %s", want, plist)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// fifoReader reads the FIFO of [Command.WithFIFO], which is opened when the
+// command is spawned.
+type fifoReader struct {
+	once  sync.Once
+	ready chan struct{}
+	mu    sync.Mutex
+	f     *os.File
+	err   error
+}
+
+func newFIFOReader() *fifoReader {
+	return &fifoReader{ready: make(chan struct{})}
+}
+
+// open sets the opened FIFO or the error, only the first call takes effect
+func (r *fifoReader) open(f *os.File, err error) {
+	r.once.Do(func() {
+		r.mu.Lock()
+		r.f, r.err = f, err
+		r.mu.Unlock()
+		close(r.ready)
+	})
+	if f != nil && r.f != f {
+		f.Close()
+	}
+}
+
+// Read blocks until the command is spawned, then reads the FIFO until all the
+// writers closed it.
+func (r *fifoReader) Read(p []byte) (int, error) {
+	<-r.ready
+	r.mu.Lock()
+	f, err := r.f, r.err
+	r.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return f.Read(p)
+}
+
+// Close closes the FIFO, the command writing to it may get SIGPIPE.
+func (r *fifoReader) Close() error {
+	r.open(nil, errors.New("WithFIFO: read after close"))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
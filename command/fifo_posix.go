@@ -0,0 +1,101 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// WithFIFO substitutes the path of a temporary FIFO for the parts of template
+// named placeholder, like [Command.WithParams], and returns the reader of what
+// the command writes to it, thus the patterns like process substitution work
+// without the syntax of bash:
+//
+//	c := NewSh(`mysqldump %s > %s 2>/dev/null`, db, "dump")
+//	dump := c.WithFIFO("dump")
+//	c.Spawn()
+//	io.Copy(w, dump)
+//	dump.Close()
+//	c.Wait()
+//
+// The FIFO is created when the command is spawned, and removed on exit. The
+// reader must be read concurrently with the command, like the [exec.Cmd.StdoutPipe],
+// since the command blocks once the FIFO is full. It gets EOF once the command
+// closed the FIFO, thus the command should open it only once, or when Wait
+// returns if the command never opened it, and the error if the command failed to
+// start. LastError will be set if placeholder is not a part of template.
+func (c *Command) WithFIFO(placeholder string) io.ReadCloser {
+	r := newFIFOReader()
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		c.LastError = fmt.Errorf("WithFIFO: %w", err)
+		r.open(nil, c.LastError)
+		return r
+	}
+	path := filepath.Join(os.TempDir(), "better-command-fifo-"+hex.EncodeToString(b))
+	found := false
+	for i, v := range c.parts {
+		if v == placeholder {
+			c.parts[i] = path
+			found = true
+		}
+	}
+	if !found {
+		c.LastError = fmt.Errorf("WithFIFO: missing placeholder %q", placeholder)
+		r.open(nil, c.LastError)
+		return r
+	}
+	if c.rerender(); c.LastError != nil {
+		c.LastError = fmt.Errorf("WithFIFO: %w", c.LastError)
+		r.open(nil, c.LastError)
+		return r
+	}
+	// unblocks the reader if the command failed to start
+	c.OnExit(func(c *Command) {
+		if c.Process == nil {
+			r.open(nil, fmt.Errorf("WithFIFO: command not started"))
+		}
+	})
+	c.prepare(func(c *Command) error {
+		// created exclusively, it fails if the path is taken
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			return fmt.Errorf("WithFIFO: %w", &os.PathError{Op: "mkfifo", Path: path, Err: err})
+		}
+		c.OnStart(func(*Command) {
+			// the open blocks until the command opens the FIFO to write
+			go func() {
+				f, err := os.OpenFile(path, os.O_RDONLY, 0)
+				if err != nil {
+					err = fmt.Errorf("WithFIFO: %w", err)
+				}
+				r.open(f, err)
+			}()
+		})
+		unblock := func(*Command) {
+			// the command never opened the FIFO, open it to unblock the reader
+			for {
+				select {
+				case <-r.ready:
+					return
+				default:
+				}
+				if w, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+					w.Close()
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		c.onWait(unblock)
+		c.OnExit(unblock, func(*Command) { os.Remove(path) })
+		return nil
+	})
+	return r
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithFIFO(t *testing.T) {
+	c := NewSh(`sleep 0.1; seq 1 20000 > %s; echo done`, "out")
+	r := c.WithFIFO("out")
+	path := c.Args[len(c.Args)-1]
+	if err := c.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("1\n2\n")) || !bytes.HasSuffix(b, []byte("\n20000\n")) {
+		t.Fatalf("got %d bytes", len(b))
+	}
+	path = path[strings.Index(path, os.TempDir()):]
+	path = path[:strings.IndexAny(path, ` '"`)]
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("fifo %s not removed: %v", path, err)
+	}
+}
+
+func TestWithFIFOUnused(t *testing.T) {
+	// EOF if the command never opens the FIFO
+	c := NewSh(`echo %s`, "out")
+	r := c.WithFIFO("out")
+	b, err := c.Output()
+	if err != nil || !strings.Contains(string(b), "better-command-fifo-") {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	if b, err := io.ReadAll(r); err != nil || len(b) != 0 {
+		t.Fatalf("got %q, %v", b, err)
+	}
+
+	c = NewSh(`echo %s`, "out")
+	if r := c.WithFIFO("in"); c.LastError == nil {
+		t.Fatal("want missing placeholder error")
+	} else if _, err := r.Read(nil); err == nil {
+		t.Fatal("want error reading")
+	}
+}
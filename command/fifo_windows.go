@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithFIFO substitutes the path of a temporary FIFO for the part placeholder,
+// and returns the reader of it, it's not supported on windows.
+func (c *Command) WithFIFO(placeholder string) io.ReadCloser {
+	c.LastError = fmt.Errorf("WithFIFO: not support windows yet")
+	r := newFIFOReader()
+	r.open(nil, c.LastError)
+	return r
+}
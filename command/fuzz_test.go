@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzValidateSafe checks the escaping of parts by [ValidateSafe], run it by
+// `go test -fuzz=FuzzValidateSafe ./command`.
+func FuzzValidateSafe(f *testing.F) {
+	for _, v := range validateParts {
+		f.Add(v)
+	}
+	f.Fuzz(func(t *testing.T, part string) {
+		for _, tpl := range validateTemplates {
+			n := strings.Count(tpl, "%s")
+			parts := make([]string, n)
+			for i := range parts {
+				parts[i] = part
+			}
+			err := ValidateSafe(tpl, parts...)
+			if err != nil && !strings.Contains(err.Error(), "delimiter") {
+				t.Fatalf("ValidateSafe(%q, %q): %v", tpl, part, err)
+			}
+		}
+	})
+}
+
+// FuzzLexShell checks lexShell never panics
+func FuzzLexShell(f *testing.F) {
+	for _, v := range validateTemplates {
+		f.Add(v)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		lexShell(s)
+	})
+}
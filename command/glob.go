@@ -0,0 +1,112 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// escapeGlob validates the glob pattern p of a %g placeholder and escapes it for
+// dialect d, the `*`, `?` and `[...]` bracket expressions are kept unescaped,
+// everything else is escaped like '%s'.
+func escapeGlob(p string, d dialect) (string, error) {
+	if err := checkGlob(p, d); err != nil {
+		return "", fmt.Errorf("%%g: invalid pattern %q: %w", p, err)
+	}
+	b := make([]byte, 0, len(p)*2)
+	inClass := false
+	for i := 0; i < len(p); {
+		v := p[i]
+		switch {
+		case v == '*' || v == '?':
+			if !inClass {
+				b = append(b, v)
+				i++
+				continue
+			}
+		case v == '[' && !inClass:
+			inClass = true
+			b = append(b, v)
+			i++
+			// the negation and the leading ] are part of the expression
+			if i < len(p) && (p[i] == '!' || p[i] == '^') {
+				b = append(b, '!')
+				i++
+			}
+			if i < len(p) && p[i] == ']' {
+				b = append(b, ']')
+				i++
+			}
+			continue
+		case v == ']' && inClass:
+			inClass = false
+			b = append(b, v)
+			i++
+			continue
+		case v == '-' && inClass:
+			b = append(b, v)
+			i++
+			continue
+		}
+		b, i = appendEscaped(b, p, i, d)
+	}
+	return string(b), nil
+}
+
+// appendEscaped appends the rune of s at i escaped for the unquoted words of
+// dialect d to b, the same as '%s', and returns the index of the next rune.
+func appendEscaped(b []byte, s string, i int, d dialect) ([]byte, int) {
+	if escaped, next, ok := d.escapeRune(b, s, i); ok {
+		return escaped, next
+	}
+	v := s[i]
+	size := 1
+	if v >= utf8.RuneSelf {
+		_, size = utf8.DecodeRuneInString(s[i:])
+	}
+	if !shellNormal.contains(v) || v == '=' || v == '%' {
+		b = append(b, '\\')
+	}
+	return append(b, s[i:i+size]...), i + size
+}
+
+// checkGlob checks p is a glob pattern of `*`, `?` and closed bracket expressions,
+// which are the common subset of the shells.
+func checkGlob(p string, d dialect) error {
+	if p == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	if strings.IndexByte(p, 0) >= 0 {
+		return fmt.Errorf("NUL in pattern")
+	}
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '?':
+			if d == dialectFish {
+				return fmt.Errorf("? is not supported by fish")
+			}
+		case '[':
+			if d == dialectFish {
+				return fmt.Errorf("[ is not supported by fish")
+			}
+			j := i + 1
+			if j < len(p) && (p[j] == '!' || p[j] == '^') {
+				j++
+			}
+			if j < len(p) && p[j] == ']' {
+				j++
+			}
+			end := strings.IndexByte(p[j:], ']')
+			if end < 0 {
+				return fmt.Errorf("unclosed [ at %d", i)
+			}
+			if strings.ContainsAny(p[j:j+end], "/[") {
+				return fmt.Errorf("/ or [ in the bracket expression at %d", i)
+			}
+			i = j + end
+		case ']':
+			return fmt.Errorf("unmatched ] at %d", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.log", "d e.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]struct {
+		template string
+		pattern  string
+		want     string
+	}{
+		"star":      {`cd %s && echo %g`, "*.txt", "a.txt b.txt d e.txt\n"},
+		"class":     {`cd %s && echo %g`, "[!ab]*", "c.log d e.txt\n"},
+		"question":  {`cd %s && echo %g`, "?.log", "c.log\n"},
+		"space":     {`cd %s && echo %g`, "d e*", "d e.txt\n"},
+		"injection": {`cd %s && echo %g`, "*; echo $(id) `id`", "*; echo $(id) `id`\n"},
+		"dir":       {`echo %s/%g | sed 's|.*/||'`, "c*", "c.log\n"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := NewSh(tc.template, dir, tc.pattern).ExtendedVerbs().Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.want {
+				t.Fatalf("got %q, want %q", b, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewGlobLiteral(t *testing.T) {
+	b, err := NewSh(`printf '%g ' 1.5; printf "%g" 2; echo`).Output()
+	if err != nil || string(b) != "1.5 2\n" {
+		t.Fatal(string(b), err)
+	}
+	// %g is kept as is without ExtendedVerbs, like the baseline
+	b, err = NewSh(`printf %g 1.5`).Output()
+	if err != nil || string(b) != "1.5" {
+		t.Fatal(string(b), err)
+	}
+	if c := NewSh(`printf %g %s`, "a b"); c.LastError != nil || c.Args[2] != `printf %g a\ b` {
+		t.Fatalf("got %q, %v", c.Args, c.LastError)
+	}
+	// not at the start of a word
+	if c := NewSh(`date +%g`); c.LastError != nil || c.Args[2] != "date +%g" {
+		t.Fatalf("got %q, %v", c.Args, c.LastError)
+	}
+	if c := NewSh(`date +%g-%s`, "a b").ExtendedVerbs(); c.LastError != nil || c.Args[2] != `date +%g-a\ b` {
+		t.Fatalf("got %q, %v", c.Args, c.LastError)
+	}
+}
+
+func TestNewGlobInvalid(t *testing.T) {
+	for _, p := range []string{"", "[abc", "a]", "[a/b]", "[[:alpha:]]"} {
+		if err := NewSh(`echo %g`, p).ExtendedVerbs().Run(); err == nil {
+			t.Errorf("want error for pattern %q", p)
+		}
+	}
+}
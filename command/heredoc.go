@@ -0,0 +1,206 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/futurist/better-command/shlex"
+)
+
+// heredocRe match the heredoc operator, like <<EOF, <<-'EOF', << "EOF"
+var heredocRe = regexp.MustCompile(`<<(-?)[ \t]*(?:'([^'\n]+)'|"([^"\n]+)"|(\\?)([A-Za-z_][A-Za-z0-9_]*))`)
+
+// substitute replace each %s in format with the escaped parts in order,
+// the %s inside heredoc body will be escaped by the heredoc rules, see [substituteHeredoc].
+// The %F outside heredoc body are replaced by temp file paths, see [substituteTokens].
+func substitute(format string, parts []string, d dialect, r *render) (string, error) {
+	s, _, err := substituteParts(format, parts, d, r)
+	return s, err
+}
+
+// substituteParts is [substitute] returning the number of parts used
+func substituteParts(format string, parts []string, d dialect, r *render) (string, int, error) {
+	var b strings.Builder
+	i := 0
+	// offset is the position of format in the template
+	offset := 0
+	for {
+		m := findHeredoc(format)
+		if m == nil {
+			break
+		}
+		nl := strings.IndexByte(format[m[1]:], '\n')
+		if nl < 0 {
+			// no heredoc body
+			break
+		}
+		bodyStart := m[1] + nl + 1
+		s, err := substituteTokens(format[:m[0]], offset, parts, &i, d, r)
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(s)
+		// keep the operator as is, the tokenizer will drop the quotes
+		b.WriteString(format[m[0]:m[1]])
+		// the tokenizer will drop trailing spaces, so write the newline back
+		s, err = substituteTokens(format[m[1]:bodyStart-1], offset+m[1], parts, &i, d, r)
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(s)
+		b.WriteByte('\n')
+
+		stripTabs := m[3] > m[2]
+		quoted := m[4] >= 0 || m[6] >= 0 || m[9] > m[8]
+		var delim string
+		switch {
+		case m[4] >= 0:
+			delim = format[m[4]:m[5]]
+		case m[6] >= 0:
+			delim = format[m[6]:m[7]]
+		default:
+			delim = format[m[10]:m[11]]
+		}
+
+		// find the terminator line
+		bodyEnd, rest := len(format), len(format)
+		for pos := bodyStart; pos < len(format); {
+			end := strings.IndexByte(format[pos:], '\n')
+			if end < 0 {
+				end = len(format)
+			} else {
+				end += pos
+			}
+			if isHeredocDelim(format[pos:end], delim, stripTabs) {
+				bodyEnd = pos
+				rest = end
+				break
+			}
+			pos = end + 1
+		}
+		body, err := substituteHeredoc(format[bodyStart:bodyEnd], parts, &i, delim, quoted, stripTabs, r)
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(body)
+		b.WriteString(format[bodyEnd:rest])
+		format = format[rest:]
+		offset += rest
+	}
+	s, err := substituteTokens(format, offset, parts, &i, d, r)
+	if err != nil {
+		return "", 0, err
+	}
+	b.WriteString(s)
+	return b.String(), i, nil
+}
+
+// findHeredoc returns the submatch indexes of heredocRe for the first heredoc
+// operator of format, which is in the words of the tokenizer outside the quotes
+// and comments, neither a here-string <<< nor a shift in the arithmetic like
+// $((1<<x)). It returns nil if there is none, or the template is invalid before
+// the operator, which is reported by [substituteTokens].
+func findHeredoc(format string) []int {
+	l := shlex.NewStringTokenizer(format)
+	// arith is the depth of the (( )) of arithmetic
+	arith := 0
+	for {
+		token, err := l.Next()
+		if err != nil {
+			return nil
+		}
+		if token.TokenType != shlex.WordToken {
+			continue
+		}
+		quote := byte(0)
+		for i := token.Offset; i < token.End; i++ {
+			c := format[i]
+			switch {
+			case quote == '\'':
+				if c == '\'' {
+					quote = 0
+				}
+			case c == '\\':
+				i++
+			case quote == '"':
+				if c == '"' {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case strings.HasPrefix(format[i:], "(("):
+				arith++
+				i++
+			case strings.HasPrefix(format[i:], "))") && arith > 0:
+				arith--
+				i++
+			case arith > 0:
+			case strings.HasPrefix(format[i:], "<<<"):
+				i += 2
+			case strings.HasPrefix(format[i:], "<<"):
+				if m := heredocRe.FindStringSubmatchIndex(format[i:]); m != nil && m[0] == 0 {
+					for k, v := range m {
+						if v >= 0 {
+							m[k] = v + i
+						}
+					}
+					return m
+				}
+				i++
+			}
+		}
+	}
+}
+
+// substituteHeredoc replace each %s in heredoc body with parts, starting from parts[*i].
+//
+// When the delimiter is quoted, like <<'EOF', the body is literal so parts are kept as is,
+// otherwise the `\`, `$` and "`" in parts are escaped.
+// An error is returned if any parts will terminate the heredoc early.
+// The %s are replaced by the env references if r.params is set, see [Command.WithParams].
+func substituteHeredoc(body string, parts []string, i *int, delim string, quoted, stripTabs bool, r *render) (string, error) {
+	if !strings.Contains(body, "%s") {
+		return body, nil
+	}
+	var b strings.Builder
+	for {
+		n := strings.Index(body, "%s")
+		if n < 0 {
+			break
+		}
+		if *i >= len(parts) {
+			return "", fmt.Errorf("heredoc: missing part %d for %%s", *i+1)
+		}
+		v := parts[*i]
+		switch {
+		case r != nil && r.params && quoted:
+			return "", fmt.Errorf("heredoc: params can not be referenced in quoted heredoc %q", delim)
+		case r != nil && r.params:
+			v = "${" + paramEnv(*i) + "}"
+		case !quoted:
+			v = heredocEscaper.Replace(v)
+		}
+		b.WriteString(body[:n])
+		b.WriteString(v)
+		body = body[n+2:]
+		*i++
+	}
+	b.WriteString(body)
+	body = b.String()
+	for _, line := range strings.Split(body, "\n") {
+		if isHeredocDelim(line, delim, stripTabs) {
+			return "", fmt.Errorf("heredoc: argument contains the delimiter %q", delim)
+		}
+	}
+	return body, nil
+}
+
+var heredocEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, "`", "\\`")
+
+func isHeredocDelim(line, delim string, stripTabs bool) bool {
+	if stripTabs {
+		line = strings.TrimLeft(line, "\t")
+	}
+	return line == delim
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeredoc(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		parts  []string
+		want   string
+	}{
+		"quoted":   {"cat <<'EOF'\n%s\nEOF\n", []string{"$HOME `ls` a;b"}, "$HOME `ls` a;b\n"},
+		"unquoted": {"cat <<EOF\n%s\nEOF\n", []string{"$HOME `ls` \\n"}, "$HOME `ls` \\n\n"},
+		"strip":    {"cat <<-EOF\n\t%s\n\tEOF\necho %s", []string{"a b", "c;d"}, "a b\nc;d\n"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := NewSh(tc.format, tc.parts...).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(b), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestHeredocDelimiter(t *testing.T) {
+	cmd := NewSh("cat <<'EOF'\n%s\nEOF\n", "a\nEOF\nrm -rf /")
+	if cmd.LastError == nil {
+		t.Fatal("should error when argument contains delimiter")
+	}
+}
+
+func TestHeredocNotOperator(t *testing.T) {
+	tests := map[string]struct {
+		cmd  *Command
+		want string
+	}{
+		"herestring": {NewBash("cat <<< hello\necho %s", "a; echo INJECTED"), "hello\na; echo INJECTED\n"},
+		"arithmetic": {NewSh("echo $((1<<2)) $(( 1 << 3 ))\necho %s", "a; echo INJECTED"), "4 8\na; echo INJECTED\n"},
+		"quoted":     {NewSh("echo '<<EOF'\necho %s", "a; echo INJECTED"), "<<EOF\na; echo INJECTED\n"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := tc.cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(b), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
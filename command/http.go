@@ -0,0 +1,126 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTPHandler returns a handler to run the commands of registry by POST /{name},
+// the form values are the params of [Registry.Command], they are always escaped.
+//
+// authFn is called before anything else, the request is rejected with 403 if it
+// returns error, nil authFn allows every request.
+//
+// The stdout and stderr are streamed back as they are written, as chunked
+// text/plain, with the exit code in trailer X-Exit-Code, or as Server-Sent Events
+// "stdout", "stderr" and the last "exit" when the request accepts text/event-stream.
+// The command is killed if the client goes away.
+func HTTPHandler(registry *Registry, authFn func(*http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authFn != nil {
+			if err := authFn(r); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.Trim(r.URL.Path, "/")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		if _, ok := registry.Params(name); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params := map[string]string{}
+		for k, v := range r.Form {
+			if len(v) != 1 {
+				http.Error(w, fmt.Sprintf("param %q should have one value", k), http.StatusBadRequest)
+				return
+			}
+			params[k] = v[0]
+		}
+		c, err := registry.Command(name, params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Trailer", "X-Exit-Code")
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		flusher, _ := w.(http.Flusher)
+
+		ch, stop := c.StreamOutput()
+		defer stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case chunk, ok := <-ch:
+				if !ok {
+					return
+				}
+				if chunk.Done {
+					code := strconv.Itoa(exitCode(chunk.Err))
+					if sse {
+						writeEvent(w, "exit", code)
+					} else {
+						w.Header().Set("X-Exit-Code", code)
+					}
+				} else if sse {
+					writeEvent(w, chunk.Stream.String(), string(chunk.Data))
+				} else {
+					w.Write(chunk.Data)
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		}
+	})
+}
+
+// writeEvent writes data as a Server-Sent Event, each line a data field, the
+// lines end by "\r\n", "\r" or "\n" like the fields of the event stream.
+func writeEvent(w http.ResponseWriter, event, data string) {
+	var b strings.Builder
+	b.WriteString("event: " + event + "\n")
+	data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	w.Write([]byte(b.String()))
+}
+
+// exitCode returns the exit code of err returned by [Command.Run], 0 if nil,
+// -1 if the command not exited normally.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e interface{ ExitCode() int }
+	if errors.As(err, &e) {
+		return e.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register("greet", []string{"sh", "-c", "echo hello '%s'; exit 3"}, "name")
+	srv := httptest.NewServer(HTTPHandler(r, func(req *http.Request) error {
+		if req.Header.Get("Authorization") != "token" {
+			return errors.New("denied")
+		}
+		return nil
+	}))
+	defer srv.Close()
+
+	post := func(path, accept string, auth bool) *http.Response {
+		req, _ := http.NewRequest("POST", srv.URL+path, strings.NewReader(url.Values{"name": {"$USER;x"}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", accept)
+		if auth {
+			req.Header.Set("Authorization", "token")
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := post("/greet", "", false)
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("got status %d", res.StatusCode)
+	}
+	res = post("/other", "", true)
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d", res.StatusCode)
+	}
+
+	res = post("/greet", "", true)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "hello $USER;x\n" {
+		t.Fatalf("got %q", body)
+	}
+	if code := res.Trailer.Get("X-Exit-Code"); code != "3" {
+		t.Fatalf("got exit code %q", code)
+	}
+
+	res = post("/greet", "text/event-stream", true)
+	body, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	want := "event: stdout\ndata: hello $USER;x\n\nevent: exit\ndata: 3\n\n"
+	if string(body) != want {
+		t.Fatalf("got %q, want %q", body, want)
+	}
+}
+
+func TestWriteEventCR(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeEvent(w, "stdout", "a\r\nb\rc\nevent: x\r")
+	want := "event: stdout\ndata: a\ndata: b\ndata: c\ndata: event: x\n\n"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
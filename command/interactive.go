@@ -0,0 +1,20 @@
+package command
+
+import (
+	"os"
+	"syscall"
+)
+
+// Interactive connects the command with the stdin, stdout and stderr of
+// current process, puts it in the foreground process group of the terminal,
+// and forwards SIGINT and SIGWINCH to it, thus editors, pagers or
+// `kubectl exec -it` behave like running directly.
+//
+// On windows it only connects the stdio.
+func (c *Command) Interactive() *Command {
+	c.Cmd.Stdin = os.Stdin
+	c.Cmd.Stdout = os.Stdout
+	c.Cmd.Stderr = os.Stderr
+	c.Foreground()
+	return c.ForwardSignals(syscall.SIGINT, sigWINCH)
+}
@@ -0,0 +1,114 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+const sigWINCH = syscall.SIGWINCH
+
+// tcgetpgrp returns the foreground process group of terminal fd,
+// it fails if fd is not a terminal.
+func tcgetpgrp(fd uintptr) (int, error) {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// tcsetpgrp set the foreground process group of terminal fd to pgrp
+func tcsetpgrp(fd uintptr, pgrp int) error {
+	p := int32(pgrp)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&p)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Foreground gives the process group of the command the control of the terminal
+// of stdin by tcsetpgrp(3) when start, and gives it back to current process when
+// exit, so the interactive commands like pagers, editors and REPLs get Ctrl-C and
+// Ctrl-Z from the terminal, despite running in their own process group.
+//
+// It's a no-op if stdin is not a terminal, the command runs in a new session,
+// or [Command.IgnoreParentInterrupt] is set.
+func (c *Command) Foreground() *Command {
+	return c.prepare(func(c *Command) error {
+		c.mu.RLock()
+		ignoreInterrupt := c.ignoreInterrupt
+		c.mu.RUnlock()
+		if c.Cmd.SysProcAttr.Setsid || ignoreInterrupt {
+			return nil
+		}
+		fd := os.Stdin.Fd()
+		pgrp, err := tcgetpgrp(fd)
+		if err != nil {
+			// not a terminal
+			return nil
+		}
+		c.Cmd.SysProcAttr.Foreground = true
+		c.Cmd.SysProcAttr.Ctty = int(fd)
+		c.OnExit(func(*Command) {
+			// we are in background now, tcsetpgrp will raise SIGTTOU
+			signal.Ignore(syscall.SIGTTOU)
+			defer signal.Reset(syscall.SIGTTOU)
+			tcsetpgrp(fd, pgrp)
+		})
+		return nil
+	})
+}
+
+// ForwardSignals relays sigs received by current process to the process group
+// of the command while it's running, and removes the handlers when exit. It's
+// SIGINT, SIGTERM, SIGHUP and SIGWINCH if sigs is empty.
+//
+// Since the command runs in its own process group, the Ctrl-C of terminal is
+// not sent to it, thus CLIs wrapping the command should forward the signals.
+// The SIGINT is not forwarded if [Command.IgnoreParentInterrupt] is set.
+func (c *Command) ForwardSignals(sigs ...os.Signal) *Command {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGWINCH}
+	}
+	ch := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	c.OnExit(func(*Command) {
+		signal.Stop(ch)
+		close(stop)
+	})
+	return c.OnStart(func(c *Command) {
+		c.mu.RLock()
+		pid := c.Pid
+		ignoreInterrupt := c.ignoreInterrupt
+		c.mu.RUnlock()
+		notify := make([]os.Signal, 0, len(sigs))
+		for _, sig := range sigs {
+			if !ignoreInterrupt || sig != syscall.SIGINT {
+				notify = append(notify, sig)
+			}
+		}
+		if len(notify) == 0 {
+			return
+		}
+		signal.Notify(ch, notify...)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case sig := <-ch:
+					if s, ok := sig.(syscall.Signal); ok {
+						syscall.Kill(-pid, s)
+					}
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInteractiveForwardSignal(t *testing.T) {
+	start := time.Now()
+	err := NewSh(`trap 'exit 0' INT; sleep 5`).Interactive().OnStart(func(*Command) {
+		go func() {
+			time.Sleep(time.Millisecond * 100)
+			syscall.Kill(os.Getpid(), syscall.SIGINT)
+		}()
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("SIGINT should be forwarded")
+	}
+}
+
+func TestForegroundNoTerminal(t *testing.T) {
+	b, err := NewSh(`echo ok`).Stdin(nil).Foreground().Output()
+	if err != nil || string(b) != "ok\n" {
+		t.Fatal(string(b), err)
+	}
+}
+
+func TestForwardSignals(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGUSR1, syscall.SIGTERM} {
+		sig := sig
+		var sigs []os.Signal
+		if sig == syscall.SIGUSR1 {
+			sigs = append(sigs, sig)
+		}
+		start := time.Now()
+		err := NewSh(`trap 'exit 0' USR1 TERM; sleep 5`).ForwardSignals(sigs...).OnStart(func(*Command) {
+			go func() {
+				time.Sleep(time.Millisecond * 100)
+				syscall.Kill(os.Getpid(), sig)
+			}()
+		}).Run()
+		if err != nil {
+			t.Fatal(sig, err)
+		}
+		if time.Since(start) > time.Second*2 {
+			t.Fatal(sig, "should be forwarded")
+		}
+	}
+}
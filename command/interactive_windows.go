@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"os"
+	"syscall"
+)
+
+// sigWINCH is not defined in syscall on windows
+const sigWINCH = syscall.Signal(0x1c)
+
+// Foreground gives the process group of the command the control of the terminal,
+// it's a no-op on windows.
+func (c *Command) Foreground() *Command {
+	return c
+}
+
+// ForwardSignals relays sigs received by current process to the process group
+// of the command while it's running, it's a no-op on windows.
+func (c *Command) ForwardSignals(sigs ...os.Signal) *Command {
+	return c
+}
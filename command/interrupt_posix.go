@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"os/signal"
+)
+
+// IgnoreParentInterrupt guarantees the command keeps running when current process
+// receives SIGINT, like the Ctrl-C of terminal, for the jobs that should never be
+// interrupted like backups. The command runs in its own process group thus never
+// gets the SIGINT of terminal, and this disables [Command.Foreground] and the
+// forwarding of SIGINT by [Command.ForwardSignals] and [Command.Interactive].
+//
+// If await is true, current process also ignores SIGINT until the command exits,
+// so the caller can keep waiting for it in Wait. Otherwise current process is
+// killed by SIGINT as usual, and the command keeps running as an orphan, the
+// Stdout and Stderr should be files in this case, since the pipes are broken.
+func (c *Command) IgnoreParentInterrupt(await bool) *Command {
+	c.mu.Lock()
+	c.ignoreInterrupt = true
+	c.mu.Unlock()
+	if !await {
+		return c
+	}
+	ch := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	c.OnExit(func(*Command) {
+		signal.Stop(ch)
+		close(stop)
+	})
+	return c.prepare(func(c *Command) error {
+		signal.Notify(ch, os.Interrupt)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ch:
+				}
+			}
+		}()
+		return nil
+	})
+}
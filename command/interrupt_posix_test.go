@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIgnoreParentInterrupt(t *testing.T) {
+	b, err := NewSh(`sleep 0.3; echo done`).Interactive().Stdout(nil).IgnoreParentInterrupt(true).OnStart(func(*Command) {
+		go func() {
+			time.Sleep(time.Millisecond * 100)
+			syscall.Kill(os.Getpid(), syscall.SIGINT)
+		}()
+	}).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "done\n" {
+		t.Fatalf("%q", b)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package command
+
+import "fmt"
+
+// IgnoreParentInterrupt guarantees the command keeps running when current process
+// receives SIGINT, it's not supported on windows.
+func (c *Command) IgnoreParentInterrupt(await bool) *Command {
+	c.LastError = fmt.Errorf("IgnoreParentInterrupt: not support windows yet")
+	return c
+}
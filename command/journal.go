@@ -0,0 +1,97 @@
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// journalSocket is the native protocol socket of systemd-journald
+var journalSocket = "/run/systemd/journal/socket"
+
+// journalWriter sends every line as a journal entry
+type journalWriter struct {
+	lineWriter
+	conn net.Conn
+}
+
+func (w *journalWriter) Close() error {
+	err := w.flush()
+	if e := w.conn.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// JournalWriter returns a writer sending every written line to systemd-journald
+// as an entry with the syslog priority (0 emerg to 7 debug) and the extra fields,
+// like {"SYSLOG_IDENTIFIER": "app"}, the field names should be uppercase.
+//
+// It can be set to Stdout/Stderr of the command, close it after the command
+// exit to send the last line without line ending.
+func JournalWriter(priority int, fields map[string]string) (io.WriteCloser, error) {
+	if priority < 0 || priority > 7 {
+		return nil, fmt.Errorf("JournalWriter: invalid priority %d", priority)
+	}
+	for k := range fields {
+		if !validJournalField(k) {
+			return nil, fmt.Errorf("JournalWriter: invalid field name %q", k)
+		}
+	}
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, fmt.Errorf("JournalWriter: %w", err)
+	}
+	w := &journalWriter{conn: conn}
+	w.emit = func(line string) error {
+		_, err := conn.Write(journalEntry(priority, fields, line))
+		return err
+	}
+	return w, nil
+}
+
+// validJournalField reports whether name contains only uppercase letters,
+// digits and underscores, and not starts with an underscore.
+func validJournalField(name string) bool {
+	if name == "" || name[0] == '_' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// journalEntry encodes an entry in the journal native protocol
+func journalEntry(priority int, fields map[string]string, message string) []byte {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(priority))
+	writeJournalField(&b, "MESSAGE", message)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeJournalField(&b, k, fields[k])
+	}
+	return b.Bytes()
+}
+
+// writeJournalField writes KEY=value, or the binary form when value contains newlines
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(key + "=" + value + "\n")
+		return
+	}
+	b.WriteString(key + "\n")
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalWriter(t *testing.T) {
+	old := journalSocket
+	defer func() { journalSocket = old }()
+	journalSocket = filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenPacket("unixgram", journalSocket)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	w, err := JournalWriter(3, map[string]string{"SYSLOG_IDENTIFIER": "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := New([]string{"sh", "-c", "echo hello; printf world >&2"})
+	cmd.Cmd.Stdout = w
+	cmd.Cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	buf := make([]byte, 1024)
+	for _, want := range []string{"hello", "world"} {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := "PRIORITY=3\nMESSAGE=" + want + "\nSYSLOG_IDENTIFIER=app\n"
+		if string(buf[:n]) != entry {
+			t.Fatalf("got %q, want %q", buf[:n], entry)
+		}
+	}
+
+	if _, err := JournalWriter(3, map[string]string{"lower": "x"}); err == nil {
+		t.Fatal("should reject invalid field name")
+	}
+}
+
+func TestJournalEntryMultiline(t *testing.T) {
+	got := string(journalEntry(6, map[string]string{"DATA": "a\nb"}, "m"))
+	want := "PRIORITY=6\nMESSAGE=m\nDATA\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errNotStarted is returned when controlling a command not started
+var errNotStarted = errors.New("command not started")
+
+// KillStep is a step of [KillPolicy], Signal is sent to the command, then
+// waits for the command to exit before the next step.
+type KillStep struct {
+	Signal os.Signal
+	Wait   time.Duration
+}
+
+// KillPolicy is the steps to terminate the command when its context is canceled,
+// like by [Command.Timeout] or [Command.Stop]. If the command is still running
+// after all steps, it's killed by SIGKILL, the signals are sent to the processes
+// of [Command.KillScope].
+//
+// For example, the policy sending SIGINT, then SIGTERM after 2s, then SIGKILL
+// after another 5s:
+//
+//	KillPolicy{{Signal: syscall.SIGINT, Wait: 2 * time.Second}, {Signal: syscall.SIGTERM, Wait: 5 * time.Second}}
+type KillPolicy []KillStep
+
+// KillPolicy set the steps to terminate the command when its context is canceled,
+// instead of killing the process group immediately by SIGKILL.
+//
+// The error of Wait is the exit status of the command, thus it may be nil if the
+// command exits with 0 by the signals, check Ctx.Err() to see if it's canceled.
+func (c *Command) KillPolicy(policy KillPolicy) *Command {
+	for i, v := range policy {
+		if v.Wait < 0 {
+			c.LastError = fmt.Errorf("KillPolicy: negative wait of step %d", i)
+			return c
+		}
+		if err := checkKillSignal(v.Signal); err != nil {
+			c.LastError = fmt.Errorf("KillPolicy: step %d: %w", i, err)
+			return c
+		}
+	}
+	c.mu.Lock()
+	c.killPolicy = append(KillPolicy(nil), policy...)
+	c.mu.Unlock()
+	return c
+}
+
+// defaultKillDelay is the wait after the signal of [Command.CancelSignal]
+const defaultKillDelay = 5 * time.Second
+
+// CancelSignal set the signal sent to the command when its context is canceled,
+// instead of SIGKILL, like SIGINT or SIGTERM which many tools handle to finalize
+// their output. The command is killed if it's still running after the delay of
+// [Command.KillDelay], 5s by default. It's ignored if [Command.KillPolicy] set.
+func (c *Command) CancelSignal(sig syscall.Signal) *Command {
+	if err := checkKillSignal(sig); err != nil {
+		c.LastError = fmt.Errorf("CancelSignal: %w", err)
+		return c
+	}
+	c.mu.Lock()
+	c.cancelSignal = sig
+	c.mu.Unlock()
+	return c
+}
+
+// KillDelay set the wait after the signal of [Command.CancelSignal] before the
+// command is killed.
+func (c *Command) KillDelay(d time.Duration) *Command {
+	if d < 0 {
+		c.LastError = fmt.Errorf("KillDelay: negative delay %v", d)
+		return c
+	}
+	c.mu.Lock()
+	c.killDelay = d
+	c.mu.Unlock()
+	return c
+}
+
+// getKillPolicy returns the KillPolicy of command, or the one of CancelSignal
+func (c *Command) getKillPolicy() KillPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.killPolicy) > 0 || c.cancelSignal == 0 {
+		return c.killPolicy
+	}
+	delay := defaultKillDelay
+	if c.killDelay > 0 {
+		delay = c.killDelay
+	}
+	return KillPolicy{{Signal: c.cancelSignal, Wait: delay}}
+}
+
+// Stop cancels the context of the command, thus it's terminated by the
+// [KillPolicy], or killed if not set, it doesn't wait for the command to exit.
+func (c *Command) Stop() {
+	c.stop()
+}
+
+// KillScope is the processes to signal when the command is terminated
+type KillScope int
+
+const (
+	// GroupOnly signals the process group of the command, it's the default, the
+	// group is killed after the child exits by the [KillPolicy]
+	GroupOnly KillScope = iota
+	// ChildOnly signals the direct child only, thus the daemons spawned by it
+	// are kept running
+	ChildOnly
+	// ChildThenGroup signals the direct child by the [KillPolicy], then kills the
+	// process group after the child exits
+	ChildThenGroup
+)
+
+// KillScope set the processes to signal when the command is terminated by the
+// [KillPolicy] or killed, the process group of the command by default.
+func (c *Command) KillScope(scope KillScope) *Command {
+	if scope < GroupOnly || scope > ChildThenGroup {
+		c.LastError = fmt.Errorf("KillScope: unknown scope %d", scope)
+		return c
+	}
+	if err := checkKillScope(scope); err != nil {
+		c.LastError = fmt.Errorf("KillScope: %w", err)
+		return c
+	}
+	c.mu.Lock()
+	c.killScope = scope
+	c.mu.Unlock()
+	return c
+}
+
+// cancelWithCause cancels the context of the command, then err is returned by
+// Spawn or Wait instead of the exit status.
+func (c *Command) cancelWithCause(err error) {
+	c.mu.Lock()
+	if c.cause == nil {
+		c.cause = err
+	}
+	c.mu.Unlock()
+	c.stop()
+}
+
+// causeOf returns the cause of cancelWithCause if set, otherwise err
+func (c *Command) causeOf(err error) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err != nil && c.cause != nil {
+		return c.cause
+	}
+	return err
+}
+
+// watchCancel terminates the started command when Ctx is canceled.
+func (c *Command) watchCancel() {
+	select {
+	case <-c.Ctx.Done():
+	case <-c.done:
+		return
+	}
+	policy := c.getKillPolicy()
+	c.mu.RLock()
+	scope := c.killScope
+	c.mu.RUnlock()
+	if c.terminate(policy, scope == GroupOnly) {
+		// the processes of the group may survive the child, like the ones
+		// ignoring the signals of policy
+		if scope != ChildOnly {
+			c.signalProcess(os.Kill, true)
+		}
+		return
+	}
+	c.emitSignal(Event{Type: EventKilled, Err: c.causeOf(c.Ctx.Err())})
+	c.signalProcess(os.Kill, scope != ChildOnly)
+	c.kill()
+}
+
+// terminate runs the steps of policy, signals the process group if group is
+// true, and returns true if the command is done before all steps.
+func (c *Command) terminate(policy KillPolicy, group bool) bool {
+	clock := c.getClock()
+	for _, step := range policy {
+		select {
+		case <-c.done:
+			return true
+		default:
+		}
+		c.emitSignal(Event{Type: EventSignaled, Signal: step.Signal})
+		if err := c.signalProcess(step.Signal, group); err != nil {
+			break
+		}
+		timeout := make(chan struct{})
+		t := clock.AfterFunc(step.Wait, func() { close(timeout) })
+		select {
+		case <-c.done:
+			t.Stop()
+			return true
+		case <-timeout:
+		}
+	}
+	return false
+}
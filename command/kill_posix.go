@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkKillSignal returns error if sig can't be sent by [KillPolicy]
+func checkKillSignal(sig os.Signal) error {
+	if _, ok := sig.(syscall.Signal); !ok {
+		return fmt.Errorf("unknown signal %v", sig)
+	}
+	return nil
+}
+
+// checkKillScope returns error if scope is not supported
+func checkKillScope(scope KillScope) error {
+	return nil
+}
+
+// signalProcess sends sig to the process group of the command if group is
+// true, otherwise to the process only
+func (c *Command) signalProcess(sig os.Signal, group bool) error {
+	c.mu.RLock()
+	pid := c.Pid
+	c.mu.RUnlock()
+	if pid == 0 {
+		return errNotStarted
+	}
+	if group {
+		pid = -pid
+	}
+	return syscall.Kill(pid, sig.(syscall.Signal))
+}
@@ -0,0 +1,217 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestKillPolicy(t *testing.T) {
+	c := NewSh(`trap '' INT; trap 'echo term; exit 0' TERM; echo ready; while :; do sleep 0.05; done`).
+		KillPolicy(KillPolicy{
+			{Signal: syscall.SIGINT, Wait: 100 * time.Millisecond},
+			{Signal: syscall.SIGTERM, Wait: 5 * time.Second},
+		}).
+		Timeout(200 * time.Millisecond)
+	start := time.Now()
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(out)); len(got) != 2 || got[1] != "term" {
+		t.Fatalf("got %q", out)
+	}
+	if c.Ctx.Err() == nil {
+		t.Fatal("context not canceled")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("took %v", d)
+	}
+}
+
+func TestKillPolicyEscalate(t *testing.T) {
+	c := NewSh(`trap '' INT TERM; while :; do sleep 0.05; done`).
+		KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: 100 * time.Millisecond}})
+	if err := c.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	c.Stop()
+	err := c.Wait()
+	if code, ok := c.ExitCode(); err == nil || !ok || code != -1 {
+		t.Fatalf("got %v, exit code %d", err, code)
+	}
+}
+
+func TestKillPolicyInvalid(t *testing.T) {
+	c := NewSh("true").KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: -1}})
+	if c.LastError == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestKillScope(t *testing.T) {
+	for _, scope := range []KillScope{GroupOnly, ChildOnly, ChildThenGroup} {
+		var out syncBuffer
+		c := NewSh(`sleep 5 >/dev/null 2>&1 & echo $!; while :; do sleep 0.05; done`).
+			Stdout(&out).KillScope(scope)
+		if err := c.Spawn(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; out.Len() == 0; i++ {
+			if i > 100 {
+				t.Fatal("no output")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Stop()
+		if err := c.Wait(); err == nil {
+			t.Fatal("want error")
+		}
+		out.mu.Lock()
+		pid, err := strconv.Atoi(strings.TrimSpace(out.b.String()))
+		out.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the killed daemon may not exit yet
+		alive := processAlive(pid)
+		for i := 0; alive && scope != ChildOnly && i < 100; i++ {
+			time.Sleep(10 * time.Millisecond)
+			alive = processAlive(pid)
+		}
+		if alive != (scope == ChildOnly) {
+			t.Fatalf("scope %d: daemon alive %v", scope, alive)
+		}
+		syscall.Kill(pid, syscall.SIGKILL)
+	}
+}
+
+func TestKillScopeChildThenGroup(t *testing.T) {
+	c := NewSh(`trap 'exit 0' TERM; sleep 5 >/dev/null 2>&1 & echo $!; while :; do sleep 0.05; done`).
+		KillScope(ChildThenGroup).
+		KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: 5 * time.Second}}).
+		Timeout(200 * time.Millisecond)
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; processAlive(pid); i++ {
+		if i > 100 {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("daemon not killed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKillPolicyGroupAfterExit(t *testing.T) {
+	c := NewSh(`trap 'exit 0' TERM; (trap '' TERM; exec sleep 5) >/dev/null 2>&1 & echo $!; while :; do sleep 0.05; done`).
+		KillPolicy(KillPolicy{{Signal: syscall.SIGTERM, Wait: 5 * time.Second}}).
+		Timeout(200 * time.Millisecond)
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the daemon ignoring SIGTERM is killed after the child exits
+	for i := 0; processAlive(pid); i++ {
+		if i > 100 {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("daemon not killed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// processAlive reports whether the process pid is running, the zombies not
+// reaped by init are not alive
+func processAlive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	i := strings.LastIndexByte(string(b), ')')
+	return i < 0 || i+2 >= len(b) || b[i+2] != 'Z'
+}
+
+func TestCancelSignal(t *testing.T) {
+	c := NewSh(`trap 'echo int; exit 0' INT; echo ready; while :; do sleep 0.05; done`).
+		CancelSignal(syscall.SIGINT).
+		Timeout(200 * time.Millisecond)
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(out)); len(got) != 2 || got[1] != "int" {
+		t.Fatalf("got %q", out)
+	}
+
+	c = NewSh(`trap '' TERM; while :; do sleep 0.05; done`).
+		CancelSignal(syscall.SIGTERM).
+		KillDelay(100 * time.Millisecond).
+		Timeout(100 * time.Millisecond)
+	start := time.Now()
+	if err := c.Run(); err == nil {
+		t.Fatal("want killed")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("took %v", d)
+	}
+}
+
+func TestCancelSignalCleanupAfterExit(t *testing.T) {
+	// the %F file and OnExit outlive the grace period after the timeout
+	exited := false
+	c := NewSh(`trap 'sleep 0.3; cat %F; exit 0' INT; while :; do sleep 0.05; done`, "kept").ExtendedVerbs().
+		CancelSignal(syscall.SIGINT).
+		KillDelay(5 * time.Second).
+		Timeout(100 * time.Millisecond).
+		OnExit(func(c *Command) { exited = c.ProcessState != nil })
+	out, err := c.Output()
+	if err != nil || string(out) != "kept" {
+		t.Fatalf("got %q, %v", out, err)
+	}
+	if !exited {
+		t.Fatal("OnExit called before exit")
+	}
+}
+
+func TestTermSignal(t *testing.T) {
+	var sigs []syscall.Signal
+	c := NewSh(`kill -SEGV $$`).OnSignal(func(sig syscall.Signal) { sigs = append(sigs, sig) })
+	if _, ok := c.TermSignal(); ok {
+		t.Fatal("should not be ok before done")
+	}
+	if err := c.Run(); err == nil {
+		t.Fatal("want error")
+	}
+	if sig, ok := c.TermSignal(); !ok || sig != syscall.SIGSEGV {
+		t.Fatalf("got %v %v", sig, ok)
+	}
+	if len(sigs) != 1 || sigs[0] != syscall.SIGSEGV {
+		t.Fatalf("got %v", sigs)
+	}
+
+	c = NewSh(`exit 1`).OnSignal(func(sig syscall.Signal) { t.Errorf("got signal %v", sig) })
+	c.Run()
+	if sig, ok := c.TermSignal(); ok {
+		t.Fatalf("got %v", sig)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkKillSignal returns error if sig can't be sent by [KillPolicy], only
+// os.Kill is supported on windows.
+func checkKillSignal(sig os.Signal) error {
+	if sig != os.Kill {
+		return fmt.Errorf("signal %v not support windows yet", sig)
+	}
+	return nil
+}
+
+// checkKillScope returns error if scope is not supported, only the process
+// is killed on windows.
+func checkKillScope(scope KillScope) error {
+	if scope != GroupOnly {
+		return fmt.Errorf("not support windows yet")
+	}
+	return nil
+}
+
+// signalProcess sends sig to the process of the command, group is ignored
+func (c *Command) signalProcess(sig os.Signal, group bool) error {
+	c.mu.RLock()
+	p := c.Process
+	c.mu.RUnlock()
+	if p == nil {
+		return errNotStarted
+	}
+	return p.Signal(sig)
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of starting commands by a token bucket, it can be
+// shared by multiple commands with [Command.Limit].
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing rate starts per second on average, with
+// bursts of at most burst starts, the bucket is full initially. The rate should
+// be positive, otherwise Wait always fails.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, burst: burst, tokens: float64(burst)}
+}
+
+// Wait blocks until a token is available or ctx is done, the token is not taken if
+// ctx is done first.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 || math.IsInf(l.rate, 0) || math.IsNaN(l.rate) {
+		return fmt.Errorf("invalid rate %v", l.rate)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.advance(now)
+	// reserve the token, the tokens may be negative for the waiters
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.advance(time.Now())
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// advance refills the tokens to now
+func (l *Limiter) advance(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+}
+
+// Limit makes the command wait for a token of l before start, the wait is
+// aborted if the context of command is done, like by [Command.Timeout].
+func (c *Command) Limit(l *Limiter) *Command {
+	return c.prepare(func(c *Command) error {
+		if err := l.Wait(c.Ctx); err != nil {
+			return fmt.Errorf("Limit: %w", err)
+		}
+		return nil
+	})
+}
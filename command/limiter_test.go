@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(20, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := NewSh("true").Limit(l).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2 by burst, then 2 at 20 per second
+	if d := time.Since(start); d < 90*time.Millisecond || d > 2*time.Second {
+		t.Fatalf("took %v", d)
+	}
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	l := NewLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := NewSh("true").Limit(l).Timeout(50 * time.Millisecond).Run()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	// the token of canceled wait is returned
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.5 {
+		t.Fatalf("token not returned, got %v", tokens)
+	}
+}
+
+func TestLimiterInvalid(t *testing.T) {
+	if err := NewSh("true").Limit(NewLimiter(0, 1)).Run(); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,44 @@
+package command
+
+import (
+	"bytes"
+	"sync"
+)
+
+// lineWriter splits the written bytes into lines and emits them without the
+// line ending, the incomplete last line is emitted on Close.
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	emit func(line string) error
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(w.buf[:i], []byte{'\r'})
+		w.buf = w.buf[i+1:]
+		if err := w.emit(string(line)); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// flush emits the incomplete last line
+func (w *lineWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := string(w.buf)
+	w.buf = nil
+	return w.emit(line)
+}
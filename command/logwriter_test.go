@@ -0,0 +1,23 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{emit: func(line string) error {
+		lines = append(lines, line)
+		return nil
+	}}
+	w.Write([]byte("a\r\nb"))
+	w.Write([]byte("c\n\nd"))
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(lines, []string{"a", "bc", "", "d"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
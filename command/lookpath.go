@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// LookPathIn resolves the name of command against the dirs of path, a list like
+// the env PATH, instead of the PATH of current process, thus a hermetic toolchain
+// can be used without changing the env of the daemon. The name with a separator
+// is used as is. The command's own env PATH is not changed, use [Command.Env]
+// for the commands it runs.
+//
+// The resolved absolute path is returned by [Command.ResolvedPath] for logging.
+// LastError will be set if the name is not found in path.
+func (c *Command) LookPathIn(path string) *Command {
+	name := c.Cmd.Args[0]
+	if filepath.Base(name) != name {
+		return c
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		if p, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			if p, err = filepath.Abs(p); err != nil {
+				c.LastError = fmt.Errorf("LookPathIn: %w", err)
+				return c
+			}
+			c.Cmd.Path = p
+			clearLookPathErr(c.Cmd)
+			return c
+		}
+	}
+	c.LastError = fmt.Errorf("LookPathIn: %q not found in %q", name, path)
+	return c
+}
+
+// NoLookPath requires the name of command to be an absolute path, thus it never
+// depends on the PATH, LastError will be set otherwise.
+func (c *Command) NoLookPath() *Command {
+	name := c.Cmd.Args[0]
+	if !filepath.IsAbs(name) {
+		c.LastError = fmt.Errorf("NoLookPath: %q is not an absolute path", name)
+		return c
+	}
+	c.Cmd.Path = name
+	clearLookPathErr(c.Cmd)
+	return c
+}
+
+// ResolvedPath returns the path of the binary the command runs, which is
+// resolved when the command is created, or by [Command.LookPathIn].
+func (c *Command) ResolvedPath() string {
+	return c.Cmd.Path
+}
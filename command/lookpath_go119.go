@@ -0,0 +1,11 @@
+//go:build go1.19
+// +build go1.19
+
+package command
+
+import "os/exec"
+
+// clearLookPathErr clear the error of resolving the Path recorded by exec.Command
+func clearLookPathErr(cmd *exec.Cmd) {
+	cmd.Err = nil
+}
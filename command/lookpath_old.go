@@ -0,0 +1,10 @@
+//go:build !go1.19
+// +build !go1.19
+
+package command
+
+import "os/exec"
+
+// clearLookPathErr cannot clear the unexported lookPathErr of exec.Cmd before go1.19
+func clearLookPathErr(cmd *exec.Cmd) {
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookPathIn(t *testing.T) {
+	tmp := t.TempDir()
+	tool := filepath.Join(tmp, "bc-hermetic-tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\necho hermetic\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	c := New([]string{"bc-hermetic-tool"}).LookPathIn(filepath.Join(tmp, "missing") + string(filepath.ListSeparator) + tmp)
+	if c.ResolvedPath() != tool {
+		t.Fatalf("got path %q", c.ResolvedPath())
+	}
+	b, err := c.Output()
+	if err != nil || string(b) != "hermetic\n" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	// not found in the PATH even if exists in the inherited PATH
+	if c := New([]string{"sh"}).LookPathIn(tmp); c.LastError == nil {
+		t.Fatal("want not found error")
+	}
+}
+
+func TestNoLookPath(t *testing.T) {
+	if c := New([]string{"sh", "-c", "true"}).NoLookPath(); c.LastError == nil {
+		t.Fatal("want not absolute error")
+	}
+	c := New([]string{"/bin/sh", "-c", "echo ok"}).NoLookPath()
+	b, err := c.Output()
+	if err != nil || string(b) != "ok\n" || c.ResolvedPath() != "/bin/sh" {
+		t.Fatalf("got %q, %v, %q", b, err, c.ResolvedPath())
+	}
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrNoMatch is returned by [Command.OutputMatch] and [Command.OutputSubmatches]
+// when the output has no match of the regexp.
+var ErrNoMatch = errors.New("no match")
+
+// OutputMatch runs the command and returns the capture groups of the first
+// match of re in its standard output, or the whole match if re has no groups,
+// like the version of `go version`:
+//
+//	v, err := New([]string{"go", "version"}).OutputMatch(regexp.MustCompile(`go(\d+\.\d+)`))
+//
+// An error wrapping [ErrNoMatch] is returned if nothing matched.
+func (c *Command) OutputMatch(re *regexp.Regexp) ([]string, error) {
+	b, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	m := re.FindStringSubmatch(string(b))
+	if m == nil {
+		return nil, fmt.Errorf("OutputMatch: %w of %s", ErrNoMatch, re)
+	}
+	return matchGroups(m), nil
+}
+
+// OutputSubmatches runs the command and returns the capture groups of all the
+// matches of re in its standard output, see [Command.OutputMatch].
+func (c *Command) OutputSubmatches(re *regexp.Regexp) ([][]string, error) {
+	b, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	all := re.FindAllStringSubmatch(string(b), -1)
+	if all == nil {
+		return nil, fmt.Errorf("OutputSubmatches: %w of %s", ErrNoMatch, re)
+	}
+	for i, m := range all {
+		all[i] = matchGroups(m)
+	}
+	return all, nil
+}
+
+// matchGroups returns the groups of submatch m, or the whole match without groups
+func matchGroups(m []string) []string {
+	if len(m) == 1 {
+		return m
+	}
+	return m[1:]
+}
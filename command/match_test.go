@@ -0,0 +1,39 @@
+package command
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOutputMatch(t *testing.T) {
+	m, err := NewSh(`echo 'tool version 1.2.3 (abc)'`).OutputMatch(regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"1", "2", "3"}, m); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+	m, err = NewSh(`echo id=42`).OutputMatch(regexp.MustCompile(`\d+`))
+	if err != nil || len(m) != 1 || m[0] != "42" {
+		t.Fatalf("got %q, %v", m, err)
+	}
+	if _, err = NewSh(`echo none`).OutputMatch(regexp.MustCompile(`\d`)); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestOutputSubmatches(t *testing.T) {
+	all, err := NewSh(`printf 'a=1\nb=2\n'`).OutputSubmatches(regexp.MustCompile(`(?m)^(\w+)=(\d+)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"a", "1"}, {"b", "2"}}, all); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+	if _, err = NewSh(`true`).OutputSubmatches(regexp.MustCompile(`x`)); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Chunk is a piece of the merged output of [Command.MergedOutput]
+type Chunk struct {
+	// Seq is the order of chunk from 0, which is the order written by the command
+	// only in the same stream, see [Command.MergedOutput]
+	Seq int
+	// Stream is where the Data come from
+	Stream Stream
+	// Time is when the Data be written, by the clock of [Command.WithClock]
+	Time time.Time
+	Data []byte
+}
+
+// mergeBuffer keeps the chunks written to its streams in order
+type mergeBuffer struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	chunks []Chunk
+}
+
+func (b *mergeBuffer) add(stream Stream, p []byte) {
+	b.mu.Lock()
+	b.chunks = append(b.chunks, Chunk{Seq: len(b.chunks), Stream: stream, Time: b.now(), Data: append([]byte(nil), p...)})
+	b.mu.Unlock()
+}
+
+// mergeWriter writes to the stream of mergeBuffer
+type mergeWriter struct {
+	b      *mergeBuffer
+	stream Stream
+}
+
+func (w *mergeWriter) Write(p []byte) (int, error) {
+	w.b.add(w.stream, p)
+	return len(p), nil
+}
+
+// MergedOutput runs the command and returns the chunks of its stdout and stderr
+// in the order they are read from the pipes, thus the diagnostics can tell where
+// each line come from, while CombinedOutput loses the streams, and the separate
+// buffers lose the order.
+//
+// Only the chunks of the same stream are guaranteed in the order written by the
+// command. The pipes are read by separate goroutines, so the order between the
+// streams is the best-effort arrival order, the chunks written at nearly the same
+// time may be swapped. Use CombinedOutput, or `2>&1` of the shell, if the exact
+// order matters more than the streams.
+func (c *Command) MergedOutput() ([]Chunk, error) {
+	b := &mergeBuffer{now: c.now}
+	err := c.mergedOutput(b)
+	return b.chunks, err
+}
+
+// mergedOutput runs the command with stdout and stderr written into b
+func (c *Command) mergedOutput(b *mergeBuffer) error {
+	defer c.cleanup()
+	if c.LastError != nil {
+		return c.LastError
+	}
+
+	if c.Cmd.Stdout != nil {
+		return errors.New("exec: Stdout already set")
+	}
+	if c.Cmd.Stderr != nil {
+		return errors.New("exec: Stderr already set")
+	}
+	c.Cmd.Stdout = &mergeWriter{b, StreamStdout}
+	c.Cmd.Stderr = &mergeWriter{b, StreamStderr}
+	return c.Run()
+}
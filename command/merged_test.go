@@ -0,0 +1,29 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergedOutput(t *testing.T) {
+	chunks, err := NewSh(`echo a; sleep 0.05; echo b >&2; sleep 0.05; echo c`).MergedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i, v := range chunks {
+		if v.Seq != i || v.Time.IsZero() {
+			t.Fatalf("got %+v", v)
+		}
+		got = append(got, v.Stream.String()+":"+string(v.Data))
+	}
+	if s := strings.Join(got, ","); s != "stdout:a\n,stderr:b\n,stdout:c\n" {
+		t.Fatalf("got %q", s)
+	}
+
+	c := NewSh(`true`)
+	c.Cmd.Stderr = new(strings.Builder)
+	if _, err := c.MergedOutput(); err == nil {
+		t.Fatal("want error")
+	}
+}
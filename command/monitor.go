@@ -0,0 +1,69 @@
+package command
+
+import (
+	"errors"
+	"time"
+)
+
+// errProcNotSupported is returned when process statistics are not supported on the platform
+var errProcNotSupported = errors.New("process statistics not supported on this platform")
+
+// Sample is the resource usage of a running command, including all processes
+// in its process group.
+type Sample struct {
+	// Time is when the sample be taken
+	Time time.Time
+	// RSS is the resident set size in bytes
+	RSS int64
+	// CPUTime is the user and system CPU time consumed so far
+	CPUTime time.Duration
+	// Processes is the count of processes in the group
+	Processes int
+}
+
+// Monitor samples the resource usage of the command every interval while it's
+// running, and calls onSample with each sample. It's only supported on Linux
+// by reading /proc, onSample will never be called on other platforms.
+func (c *Command) Monitor(interval time.Duration, onSample func(Sample)) *Command {
+	stop := make(chan struct{})
+	c.OnExit(func(*Command) { close(stop) })
+	return c.OnStart(func(c *Command) {
+		c.mu.RLock()
+		pid := c.Pid
+		c.mu.RUnlock()
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-c.Ctx.Done():
+					return
+				case <-ticker.C:
+					s, err := sampleGroup(pid)
+					if err != nil {
+						// the process exited or not supported
+						return
+					}
+					onSample(s)
+				}
+			}
+		}()
+	})
+}
+
+// KillIfRSSAbove kills the command when the RSS of its process group
+// exceeds n bytes, sampled every second, see [Command.Monitor].
+func (c *Command) KillIfRSSAbove(n int64) *Command {
+	return c.Monitor(time.Second, func(s Sample) {
+		if s.RSS > n {
+			c.mu.RLock()
+			cancel := c.Cancel
+			c.mu.RUnlock()
+			if cancel != nil {
+				cancel()
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// capture is the buffer that Output and CombinedOutput write into
+type capture interface {
+	io.Writer
+	Bytes() []byte
+}
+
+// CaptureTail make [Command.Output] and [Command.CombinedOutput] retain only
+// the first n bytes and the last n bytes of the output, the bytes in the middle
+// are omitted, see [LimitedBuffer].
+func (c *Command) CaptureTail(n int) *Command {
+	c.captureTail = n
+	return c
+}
+
+// newCapture return the buffer to capture output
+func (c *Command) newCapture() capture {
+	if c.captureTail > 0 {
+		return &LimitedBuffer{N: c.captureTail}
+	}
+	if c.spillThreshold > 0 {
+		return &SpillBuffer{Threshold: c.spillThreshold, Dir: c.spillDir}
+	}
+	return new(bytes.Buffer)
+}
+
+// closeCapture release the resources hold by w
+func closeCapture(w capture) {
+	if closer, ok := w.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// NormalizeNewlines convert CRLF to LF in the output captured by
+// [Command.Output] and [Command.CombinedOutput], thus the output can be compared
+// without caring about the platform.
+func (c *Command) NormalizeNewlines() *Command {
+	c.normalizeNewlines = true
+	return c
+}
+
+// TrimTrailingNewline remove a single trailing newline (LF or CRLF) from the
+// output captured by [Command.Output] and [Command.CombinedOutput].
+func (c *Command) TrimTrailingNewline() *Command {
+	c.trimTrailingNewline = true
+	return c
+}
+
+// normalizeOutput apply the newline options to captured output b
+func (c *Command) normalizeOutput(b []byte) []byte {
+	if c.normalizeNewlines {
+		b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	}
+	if c.trimTrailingNewline {
+		b = trimTrailingNewline(b)
+	}
+	return b
+}
+
+// trimTrailingNewline remove a single trailing LF or CRLF from b
+func trimTrailingNewline(b []byte) []byte {
+	if bytes.HasSuffix(b, []byte("\r\n")) {
+		return b[:len(b)-2]
+	}
+	return bytes.TrimSuffix(b, []byte("\n"))
+}
+
+// OutputString runs the command and returns its standard output as a string,
+// with a single trailing newline (LF or CRLF) removed, see [Command.Output].
+func (c *Command) OutputString() (string, error) {
+	b, err := c.Output()
+	return string(trimTrailingNewline(b)), err
+}
+
+// OutputTrimmed runs the command and returns its standard output as a string,
+// with the leading and trailing white spaces removed, see [Command.Output].
+func (c *Command) OutputTrimmed() (string, error) {
+	b, err := c.Output()
+	return string(bytes.TrimSpace(b)), err
+}
+
+// OutputSplit runs the command and returns its standard output split by sep,
+// a trailing sep is dropped, and nil is returned for empty output. With sep 0,
+// it splits the NUL-separated output like `find -print0` or `git ls-files -z`,
+// thus the names with newlines are kept.
+func (c *Command) OutputSplit(sep byte) ([][]byte, error) {
+	b, err := c.Output()
+	if len(b) == 0 {
+		return nil, err
+	}
+	return bytes.Split(bytes.TrimSuffix(b, []byte{sep}), []byte{sep}), err
+}
+
+// CombinedOutputTail runs the command and returns the last n bytes of its combined
+// standard output and standard error, the rest is discarded as it's written, thus
+// the memory is bounded for the health checks and cron jobs.
+func (c *Command) CombinedOutputTail(n int) ([]byte, error) {
+	if n <= 0 {
+		defer c.cleanup()
+		return nil, fmt.Errorf("CombinedOutputTail: invalid size %d", n)
+	}
+	b := &tailBuffer{n: n}
+	err := c.combinedOutput(b)
+	return c.normalizeOutput(b.Bytes()), err
+}
+
+// OutputJSON runs the command and decodes its standard output as JSON into v
+// by [json.Unmarshal].
+func (c *Command) OutputJSON(v interface{}) error {
+	b, err := c.Output()
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("OutputJSON: %w", err)
+	}
+	return nil
+}
+
+// WrapStdout wrap the stdout writer of the command with wrap when run, the
+// command output will be written into the returned io.WriteCloser, which will
+// be closed after the command exit. Multiple wrappers are applied in order,
+// the last one receives the output first.
+func (c *Command) WrapStdout(wrap func(w io.Writer) (io.WriteCloser, error)) *Command {
+	c.mu.Lock()
+	c.stdoutWrappers = append(c.stdoutWrappers, wrap)
+	c.mu.Unlock()
+	return c
+}
+
+// CompressOutput compress the stdout with gzip of level as it streams,
+// the level is same as [gzip.NewWriterLevel].
+func (c *Command) CompressOutput(level int) *Command {
+	return c.WrapStdout(func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// wrapStdout apply the stdout wrappers, return the func to close them
+func (c *Command) wrapStdout() (func() error, error) {
+	c.mu.RLock()
+	wrappers := c.stdoutWrappers
+	c.mu.RUnlock()
+	closers := make([]io.Closer, 0, len(wrappers))
+	closeAll := func() error {
+		var err error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if e := closers[i].Close(); err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+	if len(wrappers) == 0 {
+		return closeAll, nil
+	}
+	w := c.Cmd.Stdout
+	if w == nil {
+		w = io.Discard
+	}
+	for _, wrap := range wrappers {
+		wc, err := wrap(w)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		closers = append(closers, wc)
+		w = wc
+	}
+	c.Cmd.Stdout = w
+	return closeAll, nil
+}
+
+// OutputToFile runs the command and writes its standard output into a temp
+// file in the same directory of path, then atomically renames it to path when
+// the command succeeded, otherwise the temp file is removed, thus path is never
+// left half-written.
+func (c *Command) OutputToFile(path string, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		c.cleanup()
+		return err
+	}
+	err = c.output(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
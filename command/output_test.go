@@ -0,0 +1,148 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeNewlines(t *testing.T) {
+	b, err := NewSh(`printf 'a\r\nb\r\n'`).NormalizeNewlines().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a\nb\n" {
+		t.Fatalf("output should be normalized: %q", b)
+	}
+	b, err = NewSh(`printf 'a\r\nb\r\n'`).NormalizeNewlines().TrimTrailingNewline().CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a\nb" {
+		t.Fatalf("trailing newline should be trimmed: %q", b)
+	}
+}
+
+func TestOutputString(t *testing.T) {
+	s, err := NewSh(`printf ' a\n\n'`).OutputString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != " a\n" {
+		t.Fatalf("a single trailing newline should be trimmed: %q", s)
+	}
+	s, err = NewSh(`printf ' a\r\n\n'`).OutputTrimmed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "a" {
+		t.Fatalf("spaces should be trimmed: %q", s)
+	}
+	if _, err = NewSh(`exit 1`).OutputString(); err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestOutputSplit(t *testing.T) {
+	parts, err := NewSh(`printf 'a\nb\000\000c\000'`).OutputSplit(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 3 || string(parts[0]) != "a\nb" || len(parts[1]) != 0 || string(parts[2]) != "c" {
+		t.Fatalf("got %q", parts)
+	}
+	if parts, err = NewSh(`true`).OutputSplit(0); err != nil || parts != nil {
+		t.Fatalf("got %q, %v", parts, err)
+	}
+}
+
+func TestCaptureTail(t *testing.T) {
+	b, err := NewSh(`printf 0123456789`).CaptureTail(3).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "012\n... omitting 4 bytes ...\n789" {
+		t.Fatalf("output should be limited: %q", b)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	w := &LimitedBuffer{N: 2}
+	w.Write([]byte("ab"))
+	w.Write([]byte("cd"))
+	if string(w.Bytes()) != "abcd" {
+		t.Fatalf("nothing should be omitted: %q", w.Bytes())
+	}
+	w.Write([]byte("ef"))
+	if string(w.Bytes()) != "ab\n... omitting 2 bytes ...\nef" {
+		t.Fatalf("middle should be omitted: %q", w.Bytes())
+	}
+}
+
+func TestCompressOutput(t *testing.T) {
+	b, err := NewSh(`printf abc`).CompressOutput(gzip.BestSpeed).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := io.ReadAll(r)
+	if string(out) != "abc" {
+		t.Fatal("output should be gzipped abc", string(out))
+	}
+	if _, err := NewSh(`printf abc`).CompressOutput(100).Output(); err == nil {
+		t.Fatal("invalid level should error")
+	}
+}
+
+func TestOutputToFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "out.txt")
+	if err := NewSh(`printf abc`).OutputToFile(file, 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(file)
+	if string(b) != "abc" {
+		t.Fatal("file content should be abc", string(b))
+	}
+	if err := NewSh(`printf def; exit 1`).OutputToFile(file, 0600); err == nil {
+		t.Fatal("should error when exit 1")
+	}
+	b, _ = os.ReadFile(file)
+	if string(b) != "abc" {
+		t.Fatal("file should be untouched when failed", string(b))
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Fatal("temp file should be removed")
+	}
+}
+
+func TestCombinedOutputTail(t *testing.T) {
+	b, err := NewSh(`echo abc; echo def >&2; echo ghi`).CombinedOutputTail(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "f\nghi\n" {
+		t.Fatalf("got %q", b)
+	}
+	b, err = NewSh(`echo abc`).CombinedOutputTail(100)
+	if err != nil || string(b) != "abc\n" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+	if _, err = NewSh(`true`).CombinedOutputTail(0); err == nil {
+		t.Fatal("want error")
+	}
+
+	w := &tailBuffer{n: 3}
+	for _, v := range []string{"ab", "cde", "f", "ghijk", "l"} {
+		w.Write([]byte(v))
+	}
+	if string(w.Bytes()) != "jkl" {
+		t.Fatalf("got %q", w.Bytes())
+	}
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/futurist/better-command/shlex"
+)
+
+// paramEnvPrefix is the prefix of the env names of [Command.WithParams]
+const paramEnvPrefix = "__BC_PARAM_"
+
+// WithParams pass the parts of the template through env instead of the command line,
+// the parts are the names of params, each %s is rewritten to the reference of a
+// uniquely named env, like "$__BC_PARAM_0", which is set to the value of the param,
+// thus the values never appear in the command line, and are invisible to `ps`:
+//
+//	NewSh(`mysql -p%s -e %s`, "password", "query").WithParams(map[string]string{
+//		"password": password,
+//		"query":    hugeQuery,
+//	})
+//
+// The %F keeps the value in the temp file as before, the %g and %p still substitute
+// the value into the command line, and %s in a quoted heredoc like <<'EOF' is an error.
+// LastError will be set if any names are not in params.
+func (c *Command) WithParams(params map[string]string) *Command {
+	values := make([]string, len(c.parts))
+	for i, name := range c.parts {
+		v, ok := params[name]
+		if !ok {
+			c.LastError = fmt.Errorf("WithParams: missing param %q", name)
+			return c
+		}
+		values[i] = v
+	}
+	c.parts = values
+	c.render.params = true
+	if c.rerender(); c.LastError != nil {
+		c.LastError = fmt.Errorf("WithParams: %w", c.LastError)
+		return c
+	}
+	return c.prepare(func(c *Command) error {
+		for i, v := range values {
+			c.setEnv(paramEnv(i), v)
+		}
+		return nil
+	})
+}
+
+// paramEnv returns the env name of the i-th param
+func paramEnv(i int) string {
+	return paramEnvPrefix + strconv.Itoa(i)
+}
+
+// paramRef returns the reference of the env of i-th param for the %s in token,
+// which is always expanded as a single word.
+func paramRef(i int, token *shlex.Token, d dialect) string {
+	ref := "${" + paramEnv(i) + "}"
+	if d == dialectFish {
+		// fish has no ${VAR}, and the quotes end the name
+		ref = "$" + paramEnv(i)
+	}
+	switch {
+	case token.IsNonEscape():
+		// close the single quotes, and reopen after the reference
+		return `'"` + ref + `"'`
+	case token.TokenType == shlex.CommentToken:
+		return ref
+	default:
+		return `"` + ref + `"`
+	}
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithParams(t *testing.T) {
+	secret := `a b'"$HOME;*`
+	c := NewSh(`for v in %s "x%sy" 'x%sy'; do echo "[$v]"; done`, "p", "p", "p").WithParams(map[string]string{"p": secret})
+	if c.LastError != nil {
+		t.Fatal(c.LastError)
+	}
+	if strings.Contains(strings.Join(c.Args, " "), "HOME") {
+		t.Fatalf("the value should not be in args: %q", c.Args)
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[" + secret + "]\n[x" + secret + "y]\n[x" + secret + "y]\n"; string(out) != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestWithParamsHeredoc(t *testing.T) {
+	out, err := NewSh("cat <<EOF\n%s\nEOF", "v").WithParams(map[string]string{"v": "$HOME`x`\nEOF"}).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "$HOME`x`\nEOF\n" {
+		t.Fatalf("got %q", out)
+	}
+	c := NewSh("cat <<'EOF'\n%s\nEOF", "v").WithParams(map[string]string{"v": "a"})
+	if c.LastError == nil {
+		t.Fatal("want error of quoted heredoc")
+	}
+}
+
+func TestWithParamsMissing(t *testing.T) {
+	c := NewSh(`echo %s %s`, "a", "b").WithParams(map[string]string{"a": "1"})
+	if c.LastError == nil || !strings.Contains(c.LastError.Error(), `"b"`) {
+		t.Fatalf("got %v", c.LastError)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Pause suspends the process group of the spawned command by SIGSTOP, until
+// [Command.Resume] is called, thus heavy jobs can give way to others without
+// being killed and restarted. The Timeout still counts when paused.
+func (c *Command) Pause() error {
+	return c.signalGroup("Pause", syscall.SIGSTOP)
+}
+
+// Resume continues the process group of the command paused by [Command.Pause]
+// by SIGCONT.
+func (c *Command) Resume() error {
+	return c.signalGroup("Resume", syscall.SIGCONT)
+}
+
+// signalGroup sends sig to the process group of the command
+func (c *Command) signalGroup(method string, sig syscall.Signal) error {
+	c.mu.RLock()
+	pid := c.Pid
+	c.mu.RUnlock()
+	if pid == 0 {
+		return fmt.Errorf("%s: %w", method, errNotStarted)
+	}
+	if err := syscall.Kill(-pid, sig); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
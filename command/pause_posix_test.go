@@ -0,0 +1,60 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (w *syncBuffer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func (w *syncBuffer) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Len()
+}
+
+func TestPauseResume(t *testing.T) {
+	c := NewSh(`while true; do echo x; sleep 0.01; done`)
+	if err := c.Pause(); !errors.Is(err, errNotStarted) {
+		t.Fatal(err)
+	}
+	var out syncBuffer
+	if err := c.Stdout(&out).Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Cancel()
+	time.Sleep(100 * time.Millisecond)
+	if err := c.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	n := out.Len()
+	time.Sleep(200 * time.Millisecond)
+	if out.Len() != n {
+		t.Fatal("output while paused")
+	}
+	if err := c.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if out.Len() == n {
+		t.Fatal("no output after resumed")
+	}
+	c.Cancel()
+	c.Wait()
+}
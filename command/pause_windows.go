@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package command
+
+import "fmt"
+
+// Pause suspends the process group of the spawned command, it's not supported
+// on windows.
+func (c *Command) Pause() error {
+	return fmt.Errorf("Pause: not support windows yet")
+}
+
+// Resume continues the process group of the command paused by [Command.Pause],
+// it's not supported on windows.
+func (c *Command) Resume() error {
+	return fmt.Errorf("Resume: not support windows yet")
+}
@@ -0,0 +1,19 @@
+//go:build linux
+// +build linux
+
+package command
+
+import "syscall"
+
+// DieWithParent set sig to be sent to the command when the current process dies,
+// by the Pdeathsig of SysProcAttr, like SIGTERM or SIGKILL, thus the command is
+// never left running when the current process crashes or is killed by SIGKILL,
+// in which case the kill on cancel can't run. It's only supported on Linux.
+//
+// The signal is sent when the thread starting the command exits, which is rare
+// in Go unless the thread is locked by [runtime.LockOSThread], and it's sent to
+// the direct child only, like `sudo` of [Command.UseSudo].
+func (c *Command) DieWithParent(sig syscall.Signal) *Command {
+	c.Cmd.SysProcAttr.Pdeathsig = sig
+	return c
+}
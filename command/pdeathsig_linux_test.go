@@ -0,0 +1,49 @@
+package command
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDieWithParent(t *testing.T) {
+	if os.Getenv("BETTER_COMMAND_PDEATHSIG_HELPER") != "" {
+		c := NewSh(`exec sleep 10`).DieWithParent(syscall.SIGKILL)
+		if err := c.Spawn(); err != nil {
+			t.Fatal(err)
+		}
+		os.Stdout.WriteString(strconv.Itoa(c.Pid) + "\n")
+		c.Wait()
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=TestDieWithParent$")
+	cmd.Env = append(os.Environ(), "BETTER_COMMAND_PDEATHSIG_HELPER=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(line))
+	// the kill on cancel can't run
+	cmd.Process.Kill()
+	cmd.Wait()
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(pid, 0) == nil && !isZombie(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("not killed with the parent")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package command
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// DieWithParent set sig to be sent to the command when the current process dies,
+// it's only supported on Linux.
+func (c *Command) DieWithParent(sig syscall.Signal) *Command {
+	c.LastError = fmt.Errorf("DieWithParent: only supported on Linux")
+	return c
+}
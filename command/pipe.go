@@ -0,0 +1,81 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// pipeReader is the read end of the pipe returned by StdoutReader and StderrReader,
+// it's closed automatically when reading reaches EOF.
+type pipeReader struct {
+	f    *os.File
+	once sync.Once
+}
+
+func (r *pipeReader) Read(p []byte) (int, error) {
+	n, err := r.f.Read(p)
+	if err == io.EOF {
+		r.Close()
+	}
+	return n, err
+}
+
+func (r *pipeReader) Close() error {
+	var err error
+	r.once.Do(func() { err = r.f.Close() })
+	return err
+}
+
+// StdoutReader returns a reader that will be connected to the command stdout
+// when the command starts, it can be requested before [Command.Spawn] or [Command.Run].
+//
+// Unlike [exec.Cmd.StdoutPipe], Wait never closes the reader, so the output
+// can be fully drained even after Wait returned, the reader is closed after
+// reading EOF, or when the command be canceled or failed to start.
+func (c *Command) StdoutReader() (io.ReadCloser, error) {
+	if c.Cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	r, w, err := c.newPipeReader()
+	if err != nil {
+		return nil, err
+	}
+	c.Cmd.Stdout = w
+	return r, nil
+}
+
+// StderrReader is like [Command.StdoutReader] but for stderr.
+func (c *Command) StderrReader() (io.ReadCloser, error) {
+	if c.Cmd.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	r, w, err := c.newPipeReader()
+	if err != nil {
+		return nil, err
+	}
+	c.Cmd.Stderr = w
+	return r, nil
+}
+
+// newPipeReader create a pipe, the write end belongs to the child after start
+func (c *Command) newPipeReader() (*pipeReader, *os.File, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &pipeReader{f: pr}
+	c.OnStart(func(*Command) { pw.Close() })
+	c.OnExit(func(c *Command) {
+		pw.Close()
+		c.mu.RLock()
+		started := c.Pid != 0
+		c.mu.RUnlock()
+		// unblock the readers when nothing will be written anymore
+		if !started || c.Ctx.Err() != nil {
+			r.Close()
+		}
+	})
+	return r, pw, nil
+}
@@ -0,0 +1,50 @@
+package command
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestStdoutReader(t *testing.T) {
+	cmd := NewSh(`printf abc; printf def 1>&2`)
+	stdout, err := cmd.StdoutReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := cmd.StderrReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(stdout)
+	if string(b) != "abc" {
+		t.Fatal("stdout should be abc", string(b))
+	}
+	b, _ = io.ReadAll(stderr)
+	if string(b) != "def" {
+		t.Fatal("stderr should be def", string(b))
+	}
+}
+
+func TestStdoutReaderCancel(t *testing.T) {
+	cmd := NewSh(`printf abc; sleep 10`).Timeout(time.Millisecond * 100)
+	stdout, err := cmd.StdoutReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	go cmd.Run()
+	b, _ := io.ReadAll(stdout)
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("reader should be closed when canceled")
+	}
+	if string(b) != "abc" {
+		t.Fatal("stdout should be abc", string(b))
+	}
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrPolicyDenied is wrapped by the error returned when the command is vetoed by the [Policy]
+var ErrPolicyDenied = errors.New("denied by policy")
+
+// PolicyRequest is the final command to start, inspected by the [Policy]
+type PolicyRequest struct {
+	// Path is the executable to run
+	Path string
+	// Args is the final argv, after every wrapping like sudo
+	Args []string
+	// User is the user name to run the command as
+	User string
+	// Env is the env of the command, the current process env if not set
+	Env []string
+	// Dir is the working directory, current directory if empty
+	Dir string
+}
+
+// Policy inspects every command before it starts, and vetoes the command by
+// returning error, see [SetPolicy].
+type Policy func(PolicyRequest) error
+
+var (
+	policyMu sync.RWMutex
+	policy   Policy
+)
+
+// SetPolicy sets the process wide policy evaluated before starting any command
+// of this package, the command fails to start with the error wrapping
+// [ErrPolicyDenied] if the policy vetoes it, nil removes the policy.
+func SetPolicy(p Policy) {
+	policyMu.Lock()
+	policy = p
+	policyMu.Unlock()
+}
+
+// AllPolicies returns a Policy which vetoes the command if any of policies vetoes it
+func AllPolicies(policies ...Policy) Policy {
+	return func(r PolicyRequest) error {
+		for _, p := range policies {
+			if err := p(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DenyPattern returns a Policy which vetoes the command if the space joined
+// argv matches any of patterns, like `rm\s+-rf\s+/(\s|$)`.
+func DenyPattern(patterns ...*regexp.Regexp) Policy {
+	return func(r PolicyRequest) error {
+		s := strings.Join(r.Args, " ")
+		for _, re := range patterns {
+			if re.MatchString(s) {
+				return fmt.Errorf("matches %s", re)
+			}
+		}
+		return nil
+	}
+}
+
+// DenyPrograms returns a Policy which vetoes the command if the base name of any
+// word of argv equals any of names, like "curl" and "nc", the argv are split by
+// the shell separators, so the programs run by wrappers like sudo, or inside the
+// shell scripts are also denied.
+func DenyPrograms(names ...string) Policy {
+	isSep := func(r rune) bool {
+		return strings.ContainsRune(" \t\r\n;&|()`'\"$<>{}", r)
+	}
+	return func(r PolicyRequest) error {
+		for _, v := range append([]string{r.Path}, r.Args...) {
+			for _, word := range strings.FieldsFunc(v, isSep) {
+				base := filepath.Base(word)
+				for _, name := range names {
+					if base == name {
+						return fmt.Errorf("program %s", name)
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// checkPolicy evaluates the policy with the final command
+func (c *Command) checkPolicy() error {
+	policyMu.RLock()
+	p := policy
+	policyMu.RUnlock()
+	if p == nil {
+		return nil
+	}
+	r := PolicyRequest{
+		Path: c.Cmd.Path,
+		Args: append([]string(nil), c.Cmd.Args...),
+		Env:  c.Cmd.Env,
+		Dir:  c.Cmd.Dir,
+	}
+	if r.Env == nil {
+		r.Env = os.Environ()
+	}
+	if name, _ := c.credentialNames(); name != "" {
+		r.User = name
+	} else if u, err := user.Current(); err == nil {
+		r.User = u.Username
+	}
+	if err := p(r); err != nil {
+		return fmt.Errorf("Policy: %w: %s", ErrPolicyDenied, c.Redacted(err.Error()))
+	}
+	return nil
+}
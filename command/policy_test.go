@@ -0,0 +1,37 @@
+package command
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestSetPolicy(t *testing.T) {
+	var got PolicyRequest
+	SetPolicy(AllPolicies(
+		func(r PolicyRequest) error {
+			got = r
+			return nil
+		},
+		DenyPattern(regexp.MustCompile(`rm\s+-rf\s+/(\s|$)`)),
+		DenyPrograms("curl"),
+	))
+	defer SetPolicy(nil)
+
+	if err := NewSh("echo ok").Dir("/").Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got.Dir != "/" || got.Args[len(got.Args)-1] != "echo ok" || got.User == "" || len(got.Env) == 0 {
+		t.Fatalf("unexpected request %+v", got)
+	}
+
+	for _, v := range []string{"rm -rf /", "curl example.com"} {
+		err := NewSh(v).Run()
+		if !errors.Is(err, ErrPolicyDenied) {
+			t.Fatalf("%s should be denied, got %v", v, err)
+		}
+	}
+	if err := New([]string{"curl", "--version"}).Run(); !errors.Is(err, ErrPolicyDenied) {
+		t.Fatalf("curl should be denied, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// bashisms is the syntax not supported by strict POSIX sh, like dash and BusyBox ash
+var bashisms = []struct {
+	name string
+	re   *regexp.Regexp
+	// unquoted is true if it's only checked outside the double quotes and ${...}
+	unquoted bool
+}{
+	{"[[ ]] test", regexp.MustCompile(`(^|[\s;&|(])\[\[\s`), false},
+	{"process substitution", regexp.MustCompile(`[<>]\(`), false},
+	{"array", regexp.MustCompile(`(^|[\s;&|])[A-Za-z_][A-Za-z0-9_]*(\[[^\]]*\])?\+?=\(|\$\{[A-Za-z_][A-Za-z0-9_]*\[`), false},
+	{"function keyword", regexp.MustCompile(`(^|[\s;&|])function\s+[A-Za-z_]`), false},
+	{"$'...' quoting", regexp.MustCompile(`\$'`), false},
+	{"brace expansion", regexp.MustCompile(`\{[^\s{}]*(,|\.\.)[^\s{}]*\}`), true},
+	{"&> redirection", regexp.MustCompile(`&>`), false},
+	{"here-string", regexp.MustCompile(`<<<`), false},
+	{"source builtin", regexp.MustCompile(`(^|[\s;&|])source\s`), false},
+}
+
+// isPOSIXShell reports whether the shell is a plain POSIX sh
+func isPOSIXShell(shell string) bool {
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "sh", "dash", "ash", "busybox":
+		return true
+	}
+	return false
+}
+
+// stripSingleQuoted remove the '...' strings from script, since they are literal
+func stripSingleQuoted(script string) string {
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case inQuote:
+			if ch == '\'' {
+				inQuote = false
+			}
+		case ch == '\\' && i+1 < len(script):
+			i++
+		case ch == '$' && i+1 < len(script) && script[i+1] == '\'':
+			// keep $' for checking
+			b.WriteString("$'")
+			i++
+			inQuote = true
+		case ch == '\'':
+			inQuote = true
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// stripExpansions remove the "..." strings and the ${...} from script, where the
+// braces are not expanded, the single-quoted strings should be removed before.
+func stripExpansions(script string) string {
+	var b strings.Builder
+	// depth is the depth of braces in ${...}
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '\\' && i+1 < len(script):
+			i++
+		case depth > 0:
+			if ch == '{' {
+				depth++
+			} else if ch == '}' {
+				depth--
+			}
+		case inQuote:
+			inQuote = ch != '"'
+		case ch == '"':
+			inQuote = true
+		case ch == '$' && i+1 < len(script) && script[i+1] == '{':
+			depth = 1
+			i++
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// CheckPOSIX returns error if script uses the syntax not supported by strict POSIX sh,
+// like arrays, [[ ]], process substitution, the single-quoted strings are not checked.
+func CheckPOSIX(script string) error {
+	s := stripSingleQuoted(script)
+	unquoted := stripExpansions(s)
+	for _, v := range bashisms {
+		s := s
+		if v.unquoted {
+			s = unquoted
+		}
+		if loc := v.re.FindStringIndex(s); loc != nil {
+			return fmt.Errorf("bash-ism %s found: %q", v.name, strings.TrimSpace(s[loc[0]:loc[1]]))
+		}
+	}
+	return nil
+}
+
+// POSIXCompat enables the compatibility mode for strict POSIX sh like dash and
+// BusyBox ash: when start, if the shell is sh, dash, ash or busybox, the script
+// template is checked by [CheckPOSIX], and LastError is set if failed. The control
+// and non-ASCII characters of parts are single-quoted instead of backslash-escaped,
+// like 'é', which is the same in every POSIX sh.
+func (c *Command) POSIXCompat() *Command {
+	if c.dialect == dialectPOSIX {
+		c.dialect = dialectPOSIXCompat
+		c.rerender()
+	}
+	return c.prepare(func(c *Command) error {
+		i := c.scriptIndex()
+		if i < 2 || !isPOSIXShell(c.Cmd.Args[i-2]) {
+			return nil
+		}
+		for j, v := range c.templates {
+			if v == "-c" && j+1 < len(c.templates) {
+				if err := CheckPOSIX(c.templates[j+1]); err != nil {
+					c.LastError = fmt.Errorf("POSIXCompat: %w", err)
+					return c.LastError
+				}
+				break
+			}
+		}
+		return nil
+	})
+}
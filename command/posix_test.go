@@ -0,0 +1,52 @@
+package command
+
+import "testing"
+
+func TestCheckPOSIX(t *testing.T) {
+	tests := map[string]bool{
+		`[ -f a ] && echo ok`:       true,
+		`echo '[[ a ]] <(ls) $'`:    true,
+		`echo ${HOME}/a; x=1`:       true,
+		`if [[ -f a ]]; then :; fi`: false,
+		`diff <(ls a) <(ls b)`:      false,
+		`arr=(a b); echo ${arr[0]}`: false,
+		`function f { :; }`:         false,
+		`echo $'a\n'`:               false,
+		`echo {a,b} {1..3}`:         false,
+		`ls &> /dev/null`:           false,
+		`cat <<< abc`:               false,
+		`source ./env.sh`:           false,
+		`echo a\'[[ b`:              true,
+		`echo ${VAR:-a,b} "{a,b}"`:  true,
+		`echo "${x}" {a,b}`:         false,
+	}
+	for script, ok := range tests {
+		if err := CheckPOSIX(script); (err == nil) != ok {
+			t.Errorf("CheckPOSIX(%q) = %v, want ok: %v", script, err, ok)
+		}
+	}
+}
+
+func TestPOSIXCompat(t *testing.T) {
+	err := NewSh(`[[ -n %s ]]`, "a").POSIXCompat().Run()
+	if err == nil {
+		t.Fatal("bash-ism should be rejected for sh")
+	}
+	if err := NewBash(`[[ -n %s ]]`, "a").POSIXCompat().Run(); err != nil {
+		t.Fatal("bash-ism should be allowed for bash", err)
+	}
+	b, err := NewSh(`printf %s`, "a\nb").POSIXCompat().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a\nb" {
+		t.Fatal("newline should be kept", string(b))
+	}
+	c := NewSh(`printf %s`, "é\tb c").POSIXCompat()
+	if want := "printf 'é''\t'b\\ c"; c.Args[2] != want {
+		t.Fatalf("got %q, want %q", c.Args[2], want)
+	}
+	if b, err = c.Output(); err != nil || string(b) != "é\tb c" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+}
@@ -0,0 +1,58 @@
+package command
+
+import "strings"
+
+// quotePowerShell returns s quoted by single quotes for PowerShell,
+// which is always literal.
+func quotePowerShell(s string) string {
+	// PowerShell also treats the unicode single quotes as quote
+	r := strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b")
+	return "'" + r.Replace(s) + "'"
+}
+
+// escapeWindowsArg escape s for the command line of windows process, with the
+// rules of CommandLineToArgvW, same as syscall.EscapeArg on windows.
+func escapeWindowsArg(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			slashes++
+		case '"':
+			for ; slashes > 0; slashes-- {
+				b.WriteByte('\\')
+			}
+			b.WriteByte('\\')
+		default:
+			slashes = 0
+		}
+		b.WriteByte(s[i])
+	}
+	for ; slashes > 0; slashes-- {
+		b.WriteByte('\\')
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// elevatedScript returns the PowerShell script to run path with args elevated by
+// the "runas" verb of ShellExecuteEx, wait for it and exit with its exit code.
+func elevatedScript(path string, args []string) string {
+	script := "$p = Start-Process -FilePath " + quotePowerShell(path)
+	if len(args) > 0 {
+		escaped := make([]string, len(args))
+		for i, v := range args {
+			escaped[i] = escapeWindowsArg(v)
+		}
+		script += " -ArgumentList " + quotePowerShell(strings.Join(escaped, " "))
+	}
+	return script + " -Verb RunAs -Wait -PassThru; exit $p.ExitCode"
+}
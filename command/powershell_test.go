@@ -0,0 +1,34 @@
+package command
+
+import "testing"
+
+func TestQuotePowerShell(t *testing.T) {
+	if got := quotePowerShell(`it's $env:PATH`); got != `'it''s $env:PATH'` {
+		t.Fatal("should be quoted", got)
+	}
+}
+
+func TestEscapeWindowsArg(t *testing.T) {
+	tests := map[string]string{
+		"":       `""`,
+		"abc":    "abc",
+		"a b":    `"a b"`,
+		`a"b`:    `"a\"b"`,
+		`a\ b\`:  `"a\ b\\"`,
+		`a\"b c`: `"a\\\"b c"`,
+		`C:\a\b`: `C:\a\b`,
+	}
+	for s, want := range tests {
+		if got := escapeWindowsArg(s); got != want {
+			t.Errorf("escapeWindowsArg(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestElevatedScript(t *testing.T) {
+	got := elevatedScript(`C:\Program Files\app.exe`, []string{"-a", "b c"})
+	want := `$p = Start-Process -FilePath 'C:\Program Files\app.exe' -ArgumentList '-a "b c"' -Verb RunAs -Wait -PassThru; exit $p.ExitCode`
+	if got != want {
+		t.Fatal(got)
+	}
+}
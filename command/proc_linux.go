@@ -0,0 +1,140 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the USER_HZ used by /proc/<pid>/stat, it's 100 on almost
+// all Linux systems.
+const clockTicks = 100
+
+// procStat is the parsed /proc/<pid>/stat
+type procStat struct {
+	Pid        int
+	Comm       string
+	State      string
+	Ppid       int
+	Pgrp       int
+	Utime      uint64 // in clock ticks
+	Stime      uint64 // in clock ticks
+	NumThreads int
+	RSS        int64 // in pages
+}
+
+// cpuTime returns user and system CPU time of the process
+func (s *procStat) cpuTime() time.Duration {
+	return time.Duration(s.Utime+s.Stime) * time.Second / clockTicks
+}
+
+// readProcStat read and parse /proc/<pid>/stat
+func readProcStat(pid int) (*procStat, error) {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	// comm may contain spaces and parentheses, it's inside the first '(' and last ')'
+	l, r := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if l < 0 || r < l {
+		return nil, fmt.Errorf("invalid stat of pid %d", pid)
+	}
+	fields := strings.Fields(s[r+1:])
+	// fields[0] is the 3rd field (state) in proc(5)
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("invalid stat of pid %d", pid)
+	}
+	st := &procStat{Pid: pid, Comm: s[l+1 : r], State: fields[0]}
+	st.Ppid, _ = strconv.Atoi(fields[1])
+	st.Pgrp, _ = strconv.Atoi(fields[2])
+	st.Utime, _ = strconv.ParseUint(fields[11], 10, 64)
+	st.Stime, _ = strconv.ParseUint(fields[12], 10, 64)
+	st.NumThreads, _ = strconv.Atoi(fields[17])
+	st.RSS, _ = strconv.ParseInt(fields[21], 10, 64)
+	return st, nil
+}
+
+// listProcStats returns the stat of all processes
+func listProcStats() ([]*procStat, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*procStat, 0, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		// the process may exit during listing
+		if st, err := readProcStat(pid); err == nil {
+			stats = append(stats, st)
+		}
+	}
+	return stats, nil
+}
+
+// sampleGroup returns the resource usage of the process group pgid
+func sampleGroup(pgid int) (Sample, error) {
+	if _, err := readProcStat(pgid); err != nil {
+		return Sample{}, err
+	}
+	stats, err := listProcStats()
+	if err != nil {
+		return Sample{}, err
+	}
+	s := Sample{Time: time.Now()}
+	pageSize := int64(os.Getpagesize())
+	for _, st := range stats {
+		if st.Pgrp != pgid || st.State == "Z" {
+			continue
+		}
+		s.Processes++
+		s.RSS += st.RSS * pageSize
+		s.CPUTime += st.cpuTime()
+	}
+	return s, nil
+}
+
+// readProcUsage returns the resource usage of process pid, or of all processes in
+// the process group pid if group is true
+func readProcUsage(pid int, group bool) (ProcStat, error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return ProcStat{}, err
+	}
+	stats := []*procStat{st}
+	if group {
+		if stats, err = listProcStats(); err != nil {
+			return ProcStat{}, err
+		}
+	}
+	var s ProcStat
+	pageSize := int64(os.Getpagesize())
+	for _, st := range stats {
+		if st.Pgrp != pid && st.Pid != pid || st.State == "Z" {
+			continue
+		}
+		s.Processes++
+		s.RSS += st.RSS * pageSize
+		s.CPUTime += st.cpuTime()
+		s.Threads += st.NumThreads
+		// the fd dir is not readable for the processes of other users
+		if fds, err := os.ReadDir("/proc/" + strconv.Itoa(st.Pid) + "/fd"); err == nil {
+			s.FDs += len(fds)
+		}
+	}
+	return s, nil
+}
+
+// isZombie reports whether the process pid exited but not reaped yet
+func isZombie(pid int) bool {
+	st, err := readProcStat(pid)
+	return err == nil && st.State == "Z"
+}
@@ -0,0 +1,80 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadProcStat(t *testing.T) {
+	st, err := readProcStat(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Ppid != os.Getppid() || st.RSS <= 0 || st.NumThreads <= 0 {
+		t.Fatal("invalid stat", st)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	var mu sync.Mutex
+	samples := make([]Sample, 0)
+	err := NewSh(`sleep 0.3 & sleep 0.3; wait`).Monitor(time.Millisecond*50, func(s Sample) {
+		mu.Lock()
+		samples = append(samples, s)
+		mu.Unlock()
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(samples) == 0 {
+		t.Fatal("should have samples")
+	}
+	if samples[0].Processes < 2 || samples[0].RSS <= 0 {
+		t.Fatal("sample should include process group", samples[0])
+	}
+}
+
+func TestKillIfRSSAbove(t *testing.T) {
+	start := time.Now()
+	err := NewSh(`sleep 3`).KillIfRSSAbove(1).Run()
+	if err == nil {
+		t.Fatal("should be killed")
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("should be killed in time")
+	}
+}
+
+func TestProcStat(t *testing.T) {
+	c := NewSh(`sleep 1 & while :; do :; done`)
+	if _, err := c.ProcStat(false); err == nil {
+		t.Fatal("want error before spawn")
+	}
+	if err := c.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Wait()
+	defer c.Stop()
+	time.Sleep(200 * time.Millisecond)
+	s, err := c.ProcStat(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Processes != 1 || s.RSS <= 0 || s.Threads != 1 || s.FDs < 3 || s.CPUPercent <= 10 {
+		t.Fatalf("invalid stat %+v", s)
+	}
+	g, err := c.ProcStat(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Processes != 2 || g.RSS <= s.RSS || g.FDs <= s.FDs {
+		t.Fatalf("invalid group stat %+v", g)
+	}
+}
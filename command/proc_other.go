@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package command
+
+func sampleGroup(pgid int) (Sample, error) {
+	return Sample{}, errProcNotSupported
+}
+
+func readProcUsage(pid int, group bool) (ProcStat, error) {
+	return ProcStat{}, errProcNotSupported
+}
+
+func isZombie(pid int) bool {
+	return false
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProcStat is the live resource usage of a running command
+type ProcStat struct {
+	// RSS is the resident set size in bytes
+	RSS int64
+	// CPUPercent is the CPU usage since the previous call of [Command.ProcStat],
+	// or since the start for the first call, 100 for a full core
+	CPUPercent float64
+	// CPUTime is the user and system CPU time consumed so far
+	CPUTime time.Duration
+	// FDs is the count of open file descriptors
+	FDs int
+	// Threads is the count of threads
+	Threads int
+	// Processes is the count of processes, 1 if not for the group
+	Processes int
+}
+
+// ProcStat samples the resource usage of the running command, of all processes
+// in its process group if group is true. It's only supported on Linux by reading
+// /proc, see [Command.Monitor] to sample periodically.
+func (c *Command) ProcStat(group bool) (ProcStat, error) {
+	c.mu.RLock()
+	pid := c.Pid
+	c.mu.RUnlock()
+	if pid == 0 {
+		return ProcStat{}, fmt.Errorf("ProcStat: %w", errNotStarted)
+	}
+	s, err := readProcUsage(pid, group)
+	if err != nil {
+		return ProcStat{}, fmt.Errorf("ProcStat: %w", err)
+	}
+	now := c.now()
+	c.mu.Lock()
+	prevCPU, prevTime := c.lastCPU, c.lastCPUTime
+	if prevTime.IsZero() {
+		prevTime = c.startTime
+	}
+	c.lastCPU, c.lastCPUTime = s.CPUTime, now
+	c.mu.Unlock()
+	if wall := now.Sub(prevTime); wall > 0 && s.CPUTime > prevCPU {
+		s.CPUPercent = float64(s.CPUTime-prevCPU) / float64(wall) * 100
+	}
+	return s, nil
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultProgressInterval is the interval of [Command.Progress] if not set by
+// [Command.ProgressInterval]
+const defaultProgressInterval = time.Second
+
+// Progress is the sample of the output of a running command, see [Command.Progress]
+type Progress struct {
+	// StdoutBytes and StderrBytes are the bytes written to Stdout and Stderr
+	StdoutBytes int64
+	StderrBytes int64
+	// Lines is the number of lines written to both
+	Lines int64
+	// Elapsed is the time since the command started
+	Elapsed time.Duration
+	// Done is true for the last sample after the command exited
+	Done bool
+}
+
+// progress counts the output for Progress
+type progress struct {
+	mu      sync.Mutex
+	sample  Progress
+	stopped bool
+	timer   Timer
+}
+
+// Progress set f to receive the samples of the output of command on the interval
+// of [Command.ProgressInterval], 1s by default, and the last one after the command
+// exited before Wait returns, thus it can drive the progress bars and the logs of long operations,
+// like rsync or pg_restore, without parsing their output. The calls of f are
+// serialized, and f should not block.
+//
+// Only the Stdout and Stderr set are counted, the output of CombinedOutput is
+// counted as stdout.
+func (c *Command) Progress(f func(p Progress)) *Command {
+	p := &progress{}
+	c.prepare(func(c *Command) error {
+		if sameWriter(c.Cmd.Stdout, c.Cmd.Stderr) {
+			w := &progressWriter{p, c.Cmd.Stdout, false}
+			c.Cmd.Stdout, c.Cmd.Stderr = w, w
+			return nil
+		}
+		if c.Cmd.Stdout != nil {
+			c.Cmd.Stdout = &progressWriter{p, c.Cmd.Stdout, false}
+		}
+		if c.Cmd.Stderr != nil {
+			c.Cmd.Stderr = &progressWriter{p, c.Cmd.Stderr, true}
+		}
+		return nil
+	})
+
+	var clock Clock
+	var start time.Time
+	var tick func()
+	tick = func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.stopped {
+			return
+		}
+		s := p.sample
+		s.Elapsed = clock.Now().Sub(start)
+		f(s)
+		p.timer = clock.AfterFunc(c.getProgressInterval(), tick)
+	}
+	return c.OnStart(func(c *Command) {
+		c.mu.RLock()
+		start = c.startTime
+		c.mu.RUnlock()
+		clock = c.getClock()
+		p.mu.Lock()
+		p.timer = clock.AfterFunc(c.getProgressInterval(), tick)
+		p.mu.Unlock()
+	}).onWait(func(c *Command) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stopped = true
+		p.timer.Stop()
+		s := p.sample
+		c.mu.RLock()
+		s.Elapsed = c.exitTime.Sub(start)
+		c.mu.RUnlock()
+		s.Done = true
+		f(s)
+	})
+}
+
+// ProgressInterval set the interval of the samples of [Command.Progress]
+func (c *Command) ProgressInterval(d time.Duration) *Command {
+	if d <= 0 {
+		c.LastError = fmt.Errorf("ProgressInterval: invalid interval %v", d)
+		return c
+	}
+	c.mu.Lock()
+	c.progressInterval = d
+	c.mu.Unlock()
+	return c
+}
+
+func (c *Command) getProgressInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.progressInterval == 0 {
+		return defaultProgressInterval
+	}
+	return c.progressInterval
+}
+
+// progressWriter counts the output written to w
+type progressWriter struct {
+	p      *progress
+	w      io.Writer
+	stderr bool
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.p.mu.Lock()
+	if w.stderr {
+		w.p.sample.StderrBytes += int64(n)
+	} else {
+		w.p.sample.StdoutBytes += int64(n)
+	}
+	w.p.sample.Lines += int64(bytes.Count(b[:n], []byte{'\n'}))
+	w.p.mu.Unlock()
+	return n, err
+}
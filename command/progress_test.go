@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var samples []Progress
+	out, err := NewSh(`echo a; echo bc >&2; sleep 0.2; echo d`).ProgressInterval(20 * time.Millisecond).
+		Progress(func(p Progress) { samples = append(samples, p) }).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "a\nbc\nd\n" {
+		t.Fatalf("got %q", out)
+	}
+	if len(samples) < 3 {
+		t.Fatalf("got %d samples", len(samples))
+	}
+	last := samples[len(samples)-1]
+	if !last.Done || last.StdoutBytes != 7 || last.Lines != 3 || last.Elapsed < 200*time.Millisecond {
+		t.Fatalf("got %+v", last)
+	}
+	for i, p := range samples[:len(samples)-1] {
+		if p.Done || i > 0 && p.Elapsed < samples[i-1].Elapsed {
+			t.Fatalf("got %+v", samples)
+		}
+	}
+
+	var stderr []int64
+	c := NewSh(`echo a; echo bc >&2`).Progress(func(p Progress) { stderr = append(stderr, p.StderrBytes) })
+	c.Cmd.Stderr = new(bytes.Buffer)
+	if _, err := c.Output(); err != nil {
+		t.Fatal(err)
+	}
+	if len(stderr) != 1 || stderr[0] != 3 {
+		t.Fatalf("got %v", stderr)
+	}
+
+	if err := NewSh(`true`).ProgressInterval(0).Run(); err == nil {
+		t.Fatal("want error")
+	}
+}
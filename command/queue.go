@@ -0,0 +1,213 @@
+package command
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrQueueClosed is returned when enqueuing to a closed [Queue]
+	ErrQueueClosed = errors.New("queue closed")
+	// ErrJobCanceled is returned by [Job.Wait] when the job is canceled before run
+	ErrJobCanceled = errors.New("job canceled")
+)
+
+// Queue runs the enqueued commands by a fixed count of workers, the jobs of
+// higher priority run first, and the jobs of the same priority run in order,
+// see [OpenQueue] to persist the jobs.
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending jobHeap
+	running int
+	seq     uint64
+	closed  bool
+	wg      sync.WaitGroup
+	// jobs are the jobs of Submit by ID, which are kept after done
+	jobs map[uint64]*Job
+	// path is the file to persist the jobs of Submit, empty if not durable
+	path string
+}
+
+// Job is a command enqueued to a [Queue]
+type Job struct {
+	// ID is the sequence number of job in the queue, starting from 1
+	ID uint64
+	// Priority is the priority of job, the higher runs first
+	Priority int
+	// Command is the command of job
+	Command *Command
+	// Spec is the spec of job by [Queue.Submit], nil for [Queue.Enqueue]
+	Spec *JobSpec
+
+	q      *Queue
+	index  int
+	err    error
+	result *Result
+	done   chan struct{}
+}
+
+// NewQueue returns a Queue running at most workers commands at the same time
+func NewQueue(workers int) *Queue {
+	q := newQueue()
+	q.start(workers)
+	return q
+}
+
+func newQueue() *Queue {
+	q := &Queue{jobs: map[uint64]*Job{}}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// start starts the workers
+func (q *Queue) start(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+}
+
+// Enqueue adds the command c with priority to the queue, c is run by [Command.Run]
+// when a worker is available.
+func (q *Queue) Enqueue(c *Command, priority int) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	q.seq++
+	j := &Job{ID: q.seq, Priority: priority, Command: c, q: q, done: make(chan struct{})}
+	heap.Push(&q.pending, j)
+	q.cond.Signal()
+	return j, nil
+}
+
+// Len returns the count of pending jobs
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Running returns the count of running jobs
+func (q *Queue) Running() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running
+}
+
+// Close stops accepting jobs, then waits for the pending and running jobs done
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// work runs the pending jobs until the queue is closed and empty
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		j := heap.Pop(&q.pending).(*Job)
+		q.running++
+		q.mu.Unlock()
+
+		var err error
+		var result *Result
+		if j.Spec != nil {
+			result, err = runSpec(j.Command)
+		} else {
+			err = j.Command.Run()
+		}
+
+		q.mu.Lock()
+		q.running--
+		j.err, j.result = err, result
+		close(j.done)
+		// the job is run again after restart if failed to save
+		q.save()
+		q.mu.Unlock()
+	}
+}
+
+// Cancel removes the job from the queue if it's pending, or stops the command by
+// [Command.Stop] if it's running, it returns false if the job is already done.
+func (j *Job) Cancel() bool {
+	q := j.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-j.done:
+		return false
+	default:
+	}
+	if j.index >= 0 {
+		heap.Remove(&q.pending, j.index)
+		j.err = ErrJobCanceled
+		close(j.done)
+		q.save()
+		return true
+	}
+	j.Command.Stop()
+	return true
+}
+
+// Done returns a channel that's closed when the job is done or canceled
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the job done, and returns the error of [Command.Run], or
+// [ErrJobCanceled] if it's canceled before run.
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// jobHeap is the pending jobs ordered by priority then ID, index of the popped
+// job is -1
+type jobHeap []*Job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, k int) bool {
+	if h[i].Priority != h[k].Priority {
+		return h[i].Priority > h[k].Priority
+	}
+	return h[i].ID < h[k].ID
+}
+
+func (h jobHeap) Swap(i, k int) {
+	h[i], h[k] = h[k], h[i]
+	h[i].index = i
+	h[k].index = k
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	j := x.(*Job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	j := old[len(old)-1]
+	old[len(old)-1] = nil
+	j.index = -1
+	*h = old[:len(old)-1]
+	return j
+}
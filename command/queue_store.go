@@ -0,0 +1,226 @@
+package command
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// JobSpec is the serializable command of a job by [Queue.Submit], it's run
+// by [New] with Args and Parts, thus the parts are always escaped.
+type JobSpec struct {
+	Args  []string `json:"args"`
+	Parts []string `json:"parts,omitempty"`
+	// Env is the env of command, the env of current process if nil
+	Env []string `json:"env,omitempty"`
+	Dir string   `json:"dir,omitempty"`
+}
+
+// Result is the result of a job by [Queue.Submit]
+type Result struct {
+	// ExitCode is the exit code of command, -1 if it's not exited or killed by signal
+	ExitCode int `json:"exitCode"`
+	// Error is the error of run, empty if succeeded
+	Error string `json:"error,omitempty"`
+	// Stdout and Stderr are the output, only the first and last 32KiB are kept
+	Stdout    string    `json:"stdout,omitempty"`
+	Stderr    string    `json:"stderr,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// the states of the persisted jobs
+const (
+	jobPending  = "pending"
+	jobDone     = "done"
+	jobCanceled = "canceled"
+)
+
+// storedJob is a job persisted in the file of [OpenQueue]
+type storedJob struct {
+	ID       uint64  `json:"id"`
+	Priority int     `json:"priority"`
+	Spec     JobSpec `json:"spec"`
+	State    string  `json:"state"`
+	Result   *Result `json:"result,omitempty"`
+}
+
+// storedQueue is the content of the file of [OpenQueue]
+type storedQueue struct {
+	Seq  uint64      `json:"seq"`
+	Jobs []storedJob `json:"jobs"`
+}
+
+// OpenQueue returns a Queue like [NewQueue], which persists the jobs of
+// [Queue.Submit] into the JSON file of path, thus the pending jobs survive a
+// restart of the process, and the done jobs keep their results for inspection.
+//
+// The jobs running when the process exited are run again, so the jobs are run
+// at least once, they should be idempotent.
+func OpenQueue(path string, workers int) (*Queue, error) {
+	q := newQueue()
+	q.path = path
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var sq storedQueue
+		if err := json.Unmarshal(b, &sq); err != nil {
+			return nil, err
+		}
+		q.seq = sq.Seq
+		for _, v := range sq.Jobs {
+			spec := v.Spec
+			j := &Job{ID: v.ID, Priority: v.Priority, Command: spec.command(), Spec: &spec, q: q, index: -1, result: v.Result, done: make(chan struct{})}
+			switch v.State {
+			case jobPending:
+				heap.Push(&q.pending, j)
+			case jobCanceled:
+				j.err = ErrJobCanceled
+				close(j.done)
+			default:
+				if j.result != nil && j.result.Error != "" {
+					j.err = errors.New(j.result.Error)
+				}
+				close(j.done)
+			}
+			q.jobs[j.ID] = j
+			if j.ID > q.seq {
+				q.seq = j.ID
+			}
+		}
+	}
+	q.start(workers)
+	return q, nil
+}
+
+// Submit adds the command of spec with priority to the queue like [Queue.Enqueue],
+// and persists it if the queue is opened by [OpenQueue]. The output is kept in the
+// [Result] of job.
+func (q *Queue) Submit(spec JobSpec, priority int) (*Job, error) {
+	if len(spec.Args) == 0 {
+		return nil, errors.New("Submit: empty args")
+	}
+	spec.Args = append([]string(nil), spec.Args...)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	q.seq++
+	j := &Job{ID: q.seq, Priority: priority, Command: spec.command(), Spec: &spec, q: q, done: make(chan struct{})}
+	heap.Push(&q.pending, j)
+	q.jobs[j.ID] = j
+	if err := q.save(); err != nil {
+		heap.Remove(&q.pending, j.index)
+		delete(q.jobs, j.ID)
+		q.seq--
+		return nil, err
+	}
+	q.cond.Signal()
+	return j, nil
+}
+
+// Jobs returns the jobs of [Queue.Submit] ordered by ID, including the done ones
+func (q *Queue) Jobs() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID < jobs[k].ID })
+	return jobs
+}
+
+// Result returns the result of the job by [Queue.Submit], ok is false if it's not
+// done or canceled before run.
+func (j *Job) Result() (result Result, ok bool) {
+	j.q.mu.Lock()
+	defer j.q.mu.Unlock()
+	if j.result == nil {
+		return Result{}, false
+	}
+	return *j.result, true
+}
+
+// command returns the command of spec
+func (s *JobSpec) command() *Command {
+	c := New(append([]string(nil), s.Args...), s.Parts...)
+	if s.Env != nil {
+		c.Env(append([]string(nil), s.Env...))
+	}
+	return c.Dir(s.Dir)
+}
+
+// runSpec runs the command of a job by Submit, and returns its result
+func runSpec(c *Command) (*Result, error) {
+	stdout := &LimitedBuffer{N: 32 << 10}
+	stderr := &LimitedBuffer{N: 32 << 10}
+	r := &Result{StartTime: time.Now()}
+	err := c.Stdout(stdout).Stderr(stderr).Run()
+	r.EndTime = time.Now()
+	r.ExitCode = -1
+	if code, ok := c.ExitCode(); ok {
+		r.ExitCode = code
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.Stdout, r.Stderr = string(stdout.Bytes()), string(stderr.Bytes())
+	return r, err
+}
+
+// save persists the jobs of Submit if the queue is durable, q.mu is held
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	sq := storedQueue{Seq: q.seq, Jobs: make([]storedJob, 0, len(q.jobs))}
+	for _, j := range q.jobs {
+		v := storedJob{ID: j.ID, Priority: j.Priority, Spec: *j.Spec, State: jobPending, Result: j.result}
+		select {
+		case <-j.done:
+			v.State = jobDone
+			if j.err == ErrJobCanceled {
+				v.State = jobCanceled
+			}
+		default:
+		}
+		sq.Jobs = append(sq.Jobs, v)
+	}
+	sort.Slice(sq.Jobs, func(i, k int) bool { return sq.Jobs[i].ID < sq.Jobs[k].ID })
+	b, err := json.MarshalIndent(sq, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temp file then rename, thus the file is never half-written
+	f, err := os.CreateTemp(filepath.Dir(q.path), "."+filepath.Base(q.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), q.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := OpenQueue(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := q.Submit(JobSpec{Args: []string{"sh", "-c", "echo %s"}, Parts: []string{"a;b"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := q.Submit(JobSpec{Args: []string{"sh", "-c", "echo err >&2; exit 3"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := failed.Wait(); err == nil {
+		t.Fatal("want error")
+	}
+	q.Close()
+
+	q, err = OpenQueue(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	jobs := q.Jobs()
+	if len(jobs) != 2 {
+		t.Fatalf("got %d jobs", len(jobs))
+	}
+	r, ok := jobs[0].Result()
+	if !ok || r.ExitCode != 0 || r.Stdout != "a;b\n" || r.Error != "" {
+		t.Fatalf("got result %+v", r)
+	}
+	r, ok = jobs[1].Result()
+	if !ok || r.ExitCode != 3 || r.Stderr != "err\n" || jobs[1].Wait() == nil {
+		t.Fatalf("got result %+v", r)
+	}
+	j, err = q.Submit(JobSpec{Args: []string{"true"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.ID != 3 {
+		t.Fatalf("got id %d", j.ID)
+	}
+}
+
+func TestOpenQueueResume(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queue.json")
+	// the job 1 was running and the job 2 was canceled when the process exited
+	content := `{"seq": 2, "jobs": [
+		{"id": 1, "spec": {"args": ["sh", "-c", "echo resumed"]}, "state": "pending"},
+		{"id": 2, "spec": {"args": ["true"]}, "state": "canceled"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	q, err := OpenQueue(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := q.Jobs()
+	if err := jobs[0].Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs[1].Wait(); !errors.Is(err, ErrJobCanceled) {
+		t.Fatal(err)
+	}
+	q.Close()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"state": "done"`) || !strings.Contains(string(b), "resumed") {
+		t.Fatalf("not persisted: %s", b)
+	}
+	// no temp files left
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("got %d files", len(entries))
+	}
+}
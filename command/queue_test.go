@@ -0,0 +1,78 @@
+package command
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue(1)
+	// block the worker until all jobs enqueued
+	block, err := q.Enqueue(NewSh("sleep 0.2"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var order []string
+	var jobs []*Job
+	for _, v := range []struct {
+		name     string
+		priority int
+	}{{"low", 0}, {"high", 2}, {"mid1", 1}, {"mid2", 1}} {
+		name := v.name
+		j, err := q.Enqueue(NewSh("true").OnExit(func(*Command) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}), v.priority)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, j)
+	}
+	canceled, _ := q.Enqueue(NewSh("true"), 0)
+	if n := q.Len(); n < 5 {
+		t.Fatalf("got %d pending", n)
+	}
+	if !canceled.Cancel() {
+		t.Fatal("cancel pending job failed")
+	}
+	if err := canceled.Wait(); !errors.Is(err, ErrJobCanceled) {
+		t.Fatal(err)
+	}
+	q.Close()
+	if err := block.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range jobs {
+		if err := j.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := order; len(got) != 4 || got[0] != "high" || got[1] != "mid1" || got[2] != "mid2" || got[3] != "low" {
+		t.Fatalf("got order %v", got)
+	}
+	if _, err := q.Enqueue(NewSh("true"), 0); !errors.Is(err, ErrQueueClosed) {
+		t.Fatal(err)
+	}
+	if canceled.Cancel() {
+		t.Fatal("cancel done job")
+	}
+}
+
+func TestQueueCancelRunning(t *testing.T) {
+	q := NewQueue(1)
+	defer q.Close()
+	j, _ := q.Enqueue(NewSh("sleep 5"), 0)
+	for q.Running() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !j.Cancel() {
+		t.Fatal("cancel running job failed")
+	}
+	if err := j.Wait(); err == nil {
+		t.Fatal("want killed")
+	}
+}
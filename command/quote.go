@@ -0,0 +1,33 @@
+package command
+
+import "strings"
+
+// Quote returns s quoted by single quotes for POSIX shells, the result is
+// always a single literal word, like for a'b:
+//
+//	'a'\''b'
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for i := 0; i < len(s); i++ {
+		if v := s[i]; !shellNormal.contains(v) || v == '=' || v == '%' {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs returns args quoted by [Quote] and joined by space
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, v := range args {
+		quoted[i] = Quote(v)
+	}
+	return strings.Join(quoted, " ")
+}
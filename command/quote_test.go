@@ -0,0 +1,27 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":        "''",
+		"abc":     "abc",
+		"a/b.c-d": "a/b.c-d",
+		"a b":     "'a b'",
+		"a'b":     `'a'\''b'`,
+		"$HOME":   "'$HOME'",
+		"~":       "'~'",
+	}
+	for s, want := range tests {
+		if got := Quote(s); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", s, got, want)
+		}
+	}
+	if diff := cmp.Diff(QuoteArgs([]string{"sh", "-c", "echo $HOME"}), `sh -c 'echo $HOME'`); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// castRecorder writes the output events of asciinema cast v2
+type castRecorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	start   time.Time
+	now     func() time.Time
+	pending []byte
+}
+
+// castHeader is the first line of asciinema cast v2
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// event records p, the caller should hold r.mu
+func (r *castRecorder) event(p []byte) {
+	b := append(r.pending, p...)
+	// keep the incomplete utf-8 sequence for the next write
+	n := len(b)
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				n = len(b) - i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), b[n:]...)
+	r.write(b[:n])
+}
+
+func (r *castRecorder) write(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	elapsed := float64(r.now().Sub(r.start)/time.Microsecond) / 1e6
+	line, _ := json.Marshal([]interface{}{elapsed, "o", string(b)})
+	r.w.Write(append(line, '\n'))
+}
+
+func (r *castRecorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.write(r.pending)
+	r.pending = nil
+}
+
+// teeRecorder writes to w and records the same bytes, the writes of stdout
+// and stderr are serialized, since they may share the same w.
+type teeRecorder struct {
+	w io.Writer
+	r *castRecorder
+}
+
+func (t *teeRecorder) Write(p []byte) (int, error) {
+	t.r.mu.Lock()
+	defer t.r.mu.Unlock()
+	t.r.event(p)
+	if t.w == nil {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
+
+// Record writes the timing and output of stdout and stderr to w in asciinema cast v2
+// format when run, so it can be replayed by `asciinema play`, the size of terminal
+// is read from env COLUMNS and LINES, default to 80x24.
+//
+// Only the output is recorded, the input is not. The errors writing to w are
+// ignored, so the recording never breaks the command.
+func (c *Command) Record(w io.Writer) *Command {
+	return c.prepare(func(c *Command) error {
+		header := castHeader{
+			Version: 2,
+			Width:   envInt("COLUMNS", 80),
+			Height:  envInt("LINES", 24),
+			Title:   c.Redacted(c.String()),
+			Env:     map[string]string{},
+		}
+		for _, k := range []string{"SHELL", "TERM"} {
+			if v := os.Getenv(k); v != "" {
+				header.Env[k] = v
+			}
+		}
+		r := &castRecorder{w: w, now: c.now}
+		r.start = r.now()
+		header.Timestamp = r.start.Unix()
+		line, err := json.Marshal(header)
+		if err != nil {
+			return err
+		}
+		w.Write(append(line, '\n'))
+		if sameWriter(c.Cmd.Stdout, c.Cmd.Stderr) {
+			// keep the combined output in one pipe, thus in order
+			t := &teeRecorder{w: c.Cmd.Stdout, r: r}
+			c.Cmd.Stdout, c.Cmd.Stderr = t, t
+		} else {
+			c.Cmd.Stdout = &teeRecorder{w: c.Cmd.Stdout, r: r}
+			c.Cmd.Stderr = &teeRecorder{w: c.Cmd.Stderr, r: r}
+		}
+		c.mu.Lock()
+		c.onexit = append(c.onexit, func(c *Command) { r.flush() })
+		c.mu.Unlock()
+		return nil
+	})
+}
+
+// envInt returns the positive int value of env key, or def
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
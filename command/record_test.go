@@ -0,0 +1,107 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	var cast bytes.Buffer
+	var stdout bytes.Buffer
+	cmd := NewSh("echo hello; sleep 0.1; echo 世界 >&2").Record(&cast).Stdout(&stdout)
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "hello\n" {
+		t.Fatalf("got stdout %q", stdout.String())
+	}
+
+	s := bufio.NewScanner(&cast)
+	s.Scan()
+	var header castHeader
+	if err := json.Unmarshal(s.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width == 0 || header.Timestamp == 0 {
+		t.Fatalf("unexpected header %+v", header)
+	}
+	var output string
+	var last float64
+	for s.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(s.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		last = event[0].(float64)
+		if event[1] != "o" {
+			t.Fatalf("unexpected event %v", event)
+		}
+		output += event[2].(string)
+	}
+	if output != "hello\n世界\n" {
+		t.Fatalf("got output %q", output)
+	}
+	if last < 0.1 {
+		t.Fatalf("got last event time %v", last)
+	}
+}
+
+func TestRecordOutput(t *testing.T) {
+	var cast bytes.Buffer
+	_, err := NewSh("echo oops >&2; exit 1").Record(&cast).Output()
+	ee, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("got error %v", err)
+	}
+	if string(ee.Stderr) != "oops\n" {
+		t.Fatalf("got stderr %q", ee.Stderr)
+	}
+	if !bytes.Contains(cast.Bytes(), []byte(`"o","oops\n"]`)) {
+		t.Fatalf("stderr not recorded: %s", cast.Bytes())
+	}
+}
+
+func TestRecordCombinedOutput(t *testing.T) {
+	var cast bytes.Buffer
+	out, err := NewSh("for i in 1 2 3; do echo $i; echo $i >&2; done").Record(&cast).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the order of stdout and stderr is kept in one pipe
+	if string(out) != "1\n1\n2\n2\n3\n3\n" {
+		t.Fatalf("got %q", out)
+	}
+	s := bufio.NewScanner(&cast)
+	s.Scan()
+	var output string
+	for s.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(s.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		output += event[2].(string)
+	}
+	if output != string(out) {
+		t.Fatalf("got recorded %q", output)
+	}
+}
+
+func TestCastRecorderSplitRune(t *testing.T) {
+	var cast bytes.Buffer
+	r := &castRecorder{w: &cast, now: time.Now}
+	b := []byte("世")
+	r.event(b[:1])
+	r.event(b[1:])
+	r.flush()
+	var event []interface{}
+	if err := json.Unmarshal(cast.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event[2] != "世" {
+		t.Fatalf("got %q", event[2])
+	}
+}
@@ -0,0 +1,29 @@
+package command
+
+import "strings"
+
+// redactMask replaces the secrets in redacted strings
+const redactMask = "******"
+
+// Redact marks the secrets to be masked in any string the package renders for
+// human, like logs and debug output, the command itself is not affected.
+func (c *Command) Redact(secrets ...string) *Command {
+	c.mu.Lock()
+	for _, v := range secrets {
+		if v != "" {
+			c.secrets = append(c.secrets, v)
+		}
+	}
+	c.mu.Unlock()
+	return c
+}
+
+// Redacted returns s with the secrets marked by [Command.Redact] masked
+func (c *Command) Redacted(s string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, v := range c.secrets {
+		s = strings.ReplaceAll(s, v, redactMask)
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry holds the pre-approved command templates by name, the parameters
+// are always substituted into the %s of templates as escaped parts, so only the
+// registered commands can be run, see [HTTPHandler].
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]registryEntry
+}
+
+type registryEntry struct {
+	cmdArgs []string
+	params  []string
+	// env, timeout, user and retries are set by LoadRunbook
+	env          map[string]string
+	timeout      time.Duration
+	user         string
+	retries      int
+	retryBackoff time.Duration
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{templates: map[string]registryEntry{}}
+}
+
+// Register adds the command template of [New] with name, params are the
+// parameter names for each %s of cmdArgs in order.
+func (r *Registry) Register(name string, cmdArgs []string, params ...string) error {
+	if name == "" || len(cmdArgs) == 0 {
+		return fmt.Errorf("Register: empty name or cmdArgs")
+	}
+	n, err := countParts(cmdArgs)
+	if err != nil {
+		return fmt.Errorf("Register: %s: %w", name, err)
+	}
+	if n != len(params) {
+		return fmt.Errorf("Register: %s has %d placeholders but %d params", name, n, len(params))
+	}
+	seen := map[string]bool{}
+	for _, v := range params {
+		if v == "" || seen[v] {
+			return fmt.Errorf("Register: empty or duplicated param %q", v)
+		}
+		seen[v] = true
+	}
+	r.mu.Lock()
+	r.templates[name] = registryEntry{cmdArgs: append([]string(nil), cmdArgs...), params: append([]string(nil), params...)}
+	r.mu.Unlock()
+	return nil
+}
+
+// Names returns the sorted names of registered commands
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.templates))
+	for k := range r.templates {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Params returns the parameter names of the registered command
+func (r *Registry) Params(name string) ([]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.templates[name]
+	return append([]string(nil), e.params...), ok
+}
+
+// Command returns the registered command with the params substituted, all the
+// params must be given, and unknown params are rejected.
+func (r *Registry) Command(name string, params map[string]string) (*Command, error) {
+	r.mu.RLock()
+	e, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Command: %q not registered", name)
+	}
+	if len(params) != len(e.params) {
+		for k := range params {
+			if !contains(e.params, k) {
+				return nil, fmt.Errorf("Command: unknown param %q", k)
+			}
+		}
+	}
+	parts := make([]string, len(e.params))
+	for i, k := range e.params {
+		v, ok := params[k]
+		if !ok {
+			return nil, fmt.Errorf("Command: missing param %q", k)
+		}
+		parts[i] = v
+	}
+	c := New(append([]string(nil), e.cmdArgs...), parts...)
+	keys := make([]string, 0, len(e.env))
+	for k := range e.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c.setEnv(k, e.env[k])
+	}
+	if e.user != "" {
+		c.AsUser(e.user)
+	}
+	if e.retries > 0 {
+		c.Retry(e.retries, e.retryBackoff)
+	}
+	if e.timeout > 0 {
+		c.Timeout(e.timeout)
+	}
+	return c, c.LastError
+}
+
+// countParts returns the number of parts used by the placeholders of cmdArgs,
+// which are counted as [New] substitutes them without [Command.ExtendedVerbs],
+// each arg uses the parts from the first.
+func countParts(cmdArgs []string) (int, error) {
+	n := 0
+	for _, v := range cmdArgs {
+		parts := make([]string, strings.Count(v, "%"))
+		for i := range parts {
+			parts[i] = "_"
+		}
+		_, used, err := substituteParts(v, parts, dialectPOSIX, &render{})
+		if err != nil {
+			return 0, err
+		}
+		if used > n {
+			n = used
+		}
+	}
+	return n, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
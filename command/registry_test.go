@@ -0,0 +1,56 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("greet", []string{"sh", "-c", "echo hello %s"}); err == nil {
+		t.Fatal("should fail with params count mismatch")
+	}
+	if err := r.Register("greet", []string{"sh", "-c", "echo hello %s"}, "name"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(r.Names(), []string{"greet"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if _, err := r.Command("greet", map[string]string{}); err == nil {
+		t.Fatal("should fail with missing param")
+	}
+	if _, err := r.Command("greet", map[string]string{"name": "a", "x": "b"}); err == nil {
+		t.Fatal("should fail with unknown param")
+	}
+	c, err := r.Command("greet", map[string]string{"name": "$(id)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello $(id)\n" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestRegistryVerbs(t *testing.T) {
+	r := NewRegistry()
+	// the verbs are kept as is, like New without ExtendedVerbs
+	args := []string{"sh", "-c", "cat %F; ls %g; cd %p; date +%F; printf '%g'; echo %s"}
+	if err := r.Register("verbs", args, "a", "b"); err == nil {
+		t.Fatal("should fail with params count mismatch")
+	}
+	if err := r.Register("verbs", args, "name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("bad", []string{"sh", "-c", "echo 'a"}); err == nil {
+		t.Fatal("should fail with invalid template")
+	}
+	c, err := r.Command("verbs", map[string]string{"name": "n"})
+	if err != nil || c.LastError != nil {
+		t.Fatal(err, c.LastError)
+	}
+}
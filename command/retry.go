@@ -0,0 +1,115 @@
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Retry runs the command again up to n times if it exits with a non-zero status,
+// the delay before the i-th retry is backoff * 2^(i-1), by the clock of
+// [Command.WithClock]. It's not retried if the context is canceled, or it fails
+// to start. Wait returns the error of the last attempt.
+//
+// The attempts share Stdin, Stdout and Stderr, thus the output of all attempts is
+// written, and the Stdin is not replayed. OnStart is only called for the first
+// attempt, and the pipes of [Command.StdoutReader], [Command.StderrReader] and
+// [Command.StdinWriter] are closed after it, use Stdout and Stderr writers instead.
+func (c *Command) Retry(n int, backoff time.Duration) *Command {
+	if n < 0 || backoff < 0 {
+		c.LastError = fmt.Errorf("Retry: invalid retries %d or backoff %v", n, backoff)
+		return c
+	}
+	c.mu.Lock()
+	c.retries, c.retryBackoff = n, backoff
+	c.mu.Unlock()
+	return c
+}
+
+// OnRetry set f to run after an attempt of [Command.Retry] failed, before the
+// delay next of the retry, attempt is the number of the retry to run, from 2 since the
+// first attempt is 1, and err is the error of failed one. The Cmd can be changed
+// in f for the retry, like rotating the credentials in Cmd.Env.
+func (c *Command) OnRetry(f func(attempt int, err error, next time.Duration)) *Command {
+	c.mu.Lock()
+	c.onretry = append(c.onretry, f)
+	c.mu.Unlock()
+	return c
+}
+
+// OnFailure set f to run when the command fails to start, or Wait returns an
+// error, after all attempts of [Command.Retry], with the error returned.
+func (c *Command) OnFailure(f func(*Command, error)) *Command {
+	c.mu.Lock()
+	c.onfailure = append(c.onfailure, f)
+	c.mu.Unlock()
+	return c
+}
+
+// failed runs the functions of OnFailure with err if it's not nil
+func (c *Command) failed(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.RLock()
+	onfailure := c.onfailure
+	c.mu.RUnlock()
+	for _, f := range onfailure {
+		f(c, err)
+	}
+}
+
+// retryWait returns the wait function retrying the command after the attempt
+// returned by wait fails, args and chunks are the invocations of an attempt.
+func (c *Command) retryWait(wait func() error, args []string, chunks [][]string) func() error {
+	c.mu.RLock()
+	retries, backoff := c.retries, c.retryBackoff
+	c.mu.RUnlock()
+	if retries == 0 {
+		return wait
+	}
+	return func() error {
+		err := wait()
+		for attempt := 1; attempt <= retries; attempt++ {
+			if _, ok := err.(*exec.ExitError); !ok || c.Ctx.Err() != nil {
+				return err
+			}
+			delay := backoff << uint(attempt-1)
+			c.emit(Event{Type: EventRetryScheduled, Attempt: attempt + 1, Delay: delay, Err: err})
+			c.mu.RLock()
+			onretry := c.onretry
+			c.mu.RUnlock()
+			for _, f := range onretry {
+				f(attempt+1, err, delay)
+			}
+			if e := c.sleep(delay); e != nil {
+				return err
+			}
+			if wait, err = c.restart(args); err != nil {
+				return err
+			}
+			if len(chunks) > 0 {
+				wait = c.chunkWait(wait, chunks)
+			}
+			err = wait()
+		}
+		return err
+	}
+}
+
+// sleep waits for d by the clock of command, or returns the error if the context
+// is done first
+func (c *Command) sleep(d time.Duration) error {
+	if d <= 0 {
+		return c.Ctx.Err()
+	}
+	done := make(chan struct{})
+	t := c.getClock().AfterFunc(d, func() { close(done) })
+	select {
+	case <-done:
+		return nil
+	case <-c.Ctx.Done():
+		t.Stop()
+		return c.Ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	// succeed at the third attempt
+	script := `echo x >> %s; [ $(wc -l < %s) -ge 3 ]`
+	start := time.Now()
+	err := NewSh(script, counter, counter).Retry(3, 20*time.Millisecond).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Fatalf("no backoff, took %v", d)
+	}
+	b, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "x"); n != 3 {
+		t.Fatalf("got %d attempts", n)
+	}
+
+	c := NewSh("echo a; exit 2").Retry(2, 0)
+	out, err := c.Output()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if string(out) != "a\na\na\n" {
+		t.Fatalf("got %q", out)
+	}
+	if code, _ := c.ExitCode(); code != 2 {
+		t.Fatalf("got exit code %d", code)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	start := time.Now()
+	err := NewSh("exit 1").Retry(5, time.Second).Timeout(100 * time.Millisecond).Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if d := time.Since(start); d > 900*time.Millisecond {
+		t.Fatalf("not canceled, took %v", d)
+	}
+}
+
+func TestOnRetry(t *testing.T) {
+	var attempts []int
+	var delays []time.Duration
+	var failures []error
+	c := NewSh(`[ "$TOKEN" = b ]`).Env(append(os.Environ(), "TOKEN=a")).Retry(3, time.Millisecond)
+	c.OnRetry(func(attempt int, err error, next time.Duration) {
+		if err == nil {
+			t.Error("want error of the failed attempt")
+		}
+		attempts = append(attempts, attempt)
+		delays = append(delays, next)
+		// rotate the token for the retry
+		c.Cmd.Env = append(c.Cmd.Env, "TOKEN=b")
+	}).OnFailure(func(c *Command, err error) {
+		failures = append(failures, err)
+	})
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0] != 2 || delays[0] != time.Millisecond {
+		t.Fatalf("got %v and %v", attempts, delays)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("got failures %v", failures)
+	}
+
+	attempts = nil
+	err := NewSh("exit 2").Retry(2, 0).OnRetry(func(attempt int, err error, next time.Duration) {
+		attempts = append(attempts, attempt)
+	}).OnFailure(func(c *Command, err error) {
+		failures = append(failures, err)
+	}).Run()
+	if len(attempts) != 2 || attempts[1] != 3 {
+		t.Fatalf("got %v", attempts)
+	}
+	if len(failures) != 1 || failures[0] != err {
+		t.Fatalf("got failures %v of %v", failures, err)
+	}
+
+	failures = nil
+	err = New([]string{"/nonexistent"}).OnFailure(func(c *Command, err error) {
+		failures = append(failures, err)
+	}).Run()
+	if err == nil || len(failures) != 1 || failures[0] != err {
+		t.Fatalf("got failures %v of %v", failures, err)
+	}
+}
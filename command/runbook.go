@@ -0,0 +1,96 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// runbookEntry is a command of the runbook of [LoadRunbook]
+type runbookEntry struct {
+	Sh           string            `json:"sh"`
+	Args         []string          `json:"args"`
+	Params       []string          `json:"params"`
+	Env          map[string]string `json:"env"`
+	Timeout      string            `json:"timeout"`
+	User         string            `json:"user"`
+	Retries      int               `json:"retries"`
+	RetryBackoff string            `json:"retryBackoff"`
+}
+
+// LoadRunbook parses the JSON runbook of named command templates from r into a
+// Registry, the parameters are substituted into the %s of templates when the
+// command is created by [Registry.Command]. For example:
+//
+//	{
+//	  "commands": {
+//	    "backup": {
+//	      "sh": "tar czf %s %s",
+//	      "params": ["out", "dir"],
+//	      "env": {"LC_ALL": "C"},
+//	      "timeout": "10m",
+//	      "user": "backup",
+//	      "retries": 2,
+//	      "retryBackoff": "5s"
+//	    },
+//	    "disk": {"args": ["df", "-h"]}
+//	  }
+//	}
+//
+// Each command is either a script of "sh" run by `sh -c` like [NewSh], or the
+// "args" like [New]. The "env" is set over the env of current process, the
+// "timeout" and "retryBackoff" are in the format of [time.ParseDuration], see
+// [Command.AsUser], [Command.Timeout] and [Command.Retry]. Unknown fields are
+// rejected, since the runbook is meant to be reviewed.
+func LoadRunbook(r io.Reader) (*Registry, error) {
+	var file struct {
+		Commands map[string]runbookEntry `json:"commands"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("LoadRunbook: %w", err)
+	}
+	reg := NewRegistry()
+	for name, v := range file.Commands {
+		if (v.Sh == "") == (len(v.Args) == 0) {
+			return nil, fmt.Errorf("LoadRunbook: %s: either sh or args should be set", name)
+		}
+		cmdArgs := v.Args
+		if v.Sh != "" {
+			cmdArgs = []string{"sh", "-c", v.Sh}
+		}
+		if err := reg.Register(name, cmdArgs, v.Params...); err != nil {
+			return nil, fmt.Errorf("LoadRunbook: %w", err)
+		}
+		timeout, err := parseRunbookDuration(v.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("LoadRunbook: %s: timeout: %w", name, err)
+		}
+		backoff, err := parseRunbookDuration(v.RetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("LoadRunbook: %s: retryBackoff: %w", name, err)
+		}
+		if v.Retries < 0 {
+			return nil, fmt.Errorf("LoadRunbook: %s: negative retries", name)
+		}
+		e := reg.templates[name]
+		e.env, e.timeout, e.user = v.Env, timeout, v.User
+		e.retries, e.retryBackoff = v.Retries, backoff
+		reg.templates[name] = e
+	}
+	return reg, nil
+}
+
+// parseRunbookDuration parses the non-negative duration s, empty for 0
+func parseRunbookDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("negative duration %q", s)
+	}
+	return d, err
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRunbook(t *testing.T) {
+	reg, err := LoadRunbook(strings.NewReader(`{
+		"commands": {
+			"greet": {"sh": "echo $GREETING %s", "params": ["name"], "env": {"GREETING": "hello"}, "timeout": "5s", "retries": 1},
+			"list": {"args": ["echo", "%s"], "params": ["dir"]}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reg.Names(); len(got) != 2 || got[0] != "greet" || got[1] != "list" {
+		t.Fatalf("got names %v", got)
+	}
+	c, err := reg.Command("greet", map[string]string{"name": "a;b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello a;b\n" {
+		t.Fatalf("got %q", out)
+	}
+	if c.retries != 1 {
+		t.Fatalf("got retries %d", c.retries)
+	}
+}
+
+func TestLoadRunbookInvalid(t *testing.T) {
+	for _, v := range []string{
+		`{"commands": {"a": {"sh": "true", "unknown": 1}}}`,
+		`{"commands": {"a": {}}}`,
+		`{"commands": {"a": {"sh": "true", "args": ["true"]}}}`,
+		`{"commands": {"a": {"sh": "echo %s"}}}`,
+		`{"commands": {"a": {"sh": "true", "timeout": "soon"}}}`,
+		`{"commands": {"a": {"sh": "true", "retries": -1}}}`,
+		`{"commands": `,
+	} {
+		if _, err := LoadRunbook(strings.NewReader(v)); err == nil {
+			t.Errorf("want error for %s", v)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// unsafeEnv reports whether the env entry "KEY=VALUE" may inject code into
+// shells, like the exported bash functions used by Shellshock.
+func unsafeEnv(env string) bool {
+	key, value := env, ""
+	if i := strings.IndexByte(env, '='); i >= 0 {
+		key, value = env[:i], env[i+1:]
+	}
+	if key == "" || strings.HasPrefix(key, "BASH_FUNC_") || strings.ContainsAny(key, "%()") {
+		return true
+	}
+	if strings.HasPrefix(strings.TrimLeft(value, " \t"), "()") {
+		return true
+	}
+	return strings.ContainsAny(env, "\n\x00")
+}
+
+// filterEnv calls f with the key of each unsafe env of the command, and
+// returns the safe ones.
+func (c *Command) filterEnv(f func(key string) error) ([]string, error) {
+	env := c.Cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	safe := make([]string, 0, len(env))
+	for _, v := range env {
+		if !unsafeEnv(v) {
+			safe = append(safe, v)
+			continue
+		}
+		key := v
+		if i := strings.IndexByte(v, '='); i >= 0 {
+			key = v[:i]
+		}
+		if err := f(key); err != nil {
+			return nil, err
+		}
+	}
+	return safe, nil
+}
+
+// SanitizeEnv removes the env entries that look like exported bash functions,
+// like `() { ...` values and BASH_FUNC_ keys, or contain newlines or NULs, from
+// the env of command, which is inherited from the parent process by default and
+// passed through by `sudo -E` of [Command.UseSudo].
+func (c *Command) SanitizeEnv() *Command {
+	return c.prepare(func(c *Command) error {
+		env, _ := c.filterEnv(func(string) error { return nil })
+		c.Cmd.Env = env
+		return nil
+	})
+}
+
+// RejectUnsafeEnv refuses to start the command if any env entry is removed
+// by [Command.SanitizeEnv].
+func (c *Command) RejectUnsafeEnv() *Command {
+	return c.prepare(func(c *Command) error {
+		_, err := c.filterEnv(func(key string) error {
+			return fmt.Errorf("RejectUnsafeEnv: unsafe env %q", key)
+		})
+		return err
+	})
+}
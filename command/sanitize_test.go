@@ -0,0 +1,47 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnsafeEnv(t *testing.T) {
+	tests := map[string]bool{
+		"HOME=/root":                      false,
+		"EMPTY=":                          false,
+		"A=x() {":                         false,
+		"X=() { :; }; echo pwned":         true,
+		"X= () { :;}":                     true,
+		"BASH_FUNC_foo%%=() { echo a; }":  true,
+		"BASH_FUNC_foo()=() { echo a; }":  true,
+		"MULTI=a\nb":                      true,
+		"NUL=a\x00b":                      true,
+		"=value":                          true,
+		"PS1=\\u@\\h:\\w$ ":               false,
+		"LESSOPEN=||/usr/bin/lesspipe %s": false,
+	}
+	for env, want := range tests {
+		if got := unsafeEnv(env); got != want {
+			t.Errorf("unsafeEnv(%q) = %v, want %v", env, got, want)
+		}
+	}
+}
+
+func TestSanitizeEnv(t *testing.T) {
+	env := []string{"SAFE=1", "X=() { :; }; echo pwned", "BASH_FUNC_ls%%=() { echo hijacked; }"}
+	b, err := NewBash(`echo $SAFE; ls /dev/null`).Env(env).SanitizeEnv().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "1\n/dev/null\n" {
+		t.Fatalf("%q", b)
+	}
+
+	err = NewSh(`true`).Env(env).RejectUnsafeEnv().Run()
+	if err == nil || !strings.Contains(err.Error(), `"X"`) || strings.Contains(err.Error(), "pwned") {
+		t.Fatal(err)
+	}
+	if err := NewSh(`true`).Env(env[:1]).RejectUnsafeEnv().Run(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -9,12 +9,12 @@ import (
 	"strconv"
 )
 
-// prefixSuffixSaver is just a copy from os/exec/exec.go
+// LimitedBuffer is just a copy of prefixSuffixSaver from os/exec/exec.go
 
-// prefixSuffixSaver is an io.Writer which retains the first N bytes
+// LimitedBuffer is an io.Writer which retains the first N bytes
 // and the last N bytes written to it. The Bytes() methods reconstructs
 // it with a pretty error message.
-type prefixSuffixSaver struct {
+type LimitedBuffer struct {
 	N         int // max size of prefix or suffix
 	prefix    []byte
 	suffix    []byte // ring buffer once len(suffix) == N
@@ -28,7 +28,8 @@ type prefixSuffixSaver struct {
 	// now just for error messages. It's only ~64KB anyway.
 }
 
-func (w *prefixSuffixSaver) Write(p []byte) (n int, err error) {
+// Write implements io.Writer, it never returns error.
+func (w *LimitedBuffer) Write(p []byte) (n int, err error) {
 	lenp := len(p)
 	p = w.fill(&w.prefix, p)
 
@@ -54,7 +55,7 @@ func (w *prefixSuffixSaver) Write(p []byte) (n int, err error) {
 
 // fill appends up to len(p) bytes of p to *dst, such that *dst does not
 // grow larger than w.N. It returns the un-appended suffix of p.
-func (w *prefixSuffixSaver) fill(dst *[]byte, p []byte) (pRemain []byte) {
+func (w *LimitedBuffer) fill(dst *[]byte, p []byte) (pRemain []byte) {
 	if remain := w.N - len(*dst); remain > 0 {
 		add := minInt(len(p), remain)
 		*dst = append(*dst, p[:add]...)
@@ -63,7 +64,8 @@ func (w *prefixSuffixSaver) fill(dst *[]byte, p []byte) (pRemain []byte) {
 	return p
 }
 
-func (w *prefixSuffixSaver) Bytes() []byte {
+// Bytes returns the retained prefix and suffix, with the omitted bytes count in the middle.
+func (w *LimitedBuffer) Bytes() []byte {
 	if w.suffix == nil {
 		return w.prefix
 	}
@@ -81,6 +83,37 @@ func (w *prefixSuffixSaver) Bytes() []byte {
 	return buf.Bytes()
 }
 
+// tailBuffer is an io.Writer which retains the last n bytes written to it
+type tailBuffer struct {
+	buf []byte // ring buffer once len(buf) == n
+	n   int
+	off int // offset to write into buf
+}
+
+// Write implements io.Writer, it never returns error.
+func (w *tailBuffer) Write(p []byte) (int, error) {
+	lenp := len(p)
+	if overage := len(p) - w.n; overage > 0 {
+		p = p[overage:]
+	}
+	if remain := w.n - len(w.buf); remain > 0 {
+		add := minInt(len(p), remain)
+		w.buf = append(w.buf, p[:add]...)
+		p = p[add:]
+	}
+	for len(p) > 0 {
+		n := copy(w.buf[w.off:], p)
+		p = p[n:]
+		w.off = (w.off + n) % w.n
+	}
+	return lenp, nil
+}
+
+// Bytes returns the last n bytes in order
+func (w *tailBuffer) Bytes() []byte {
+	return append(append([]byte(nil), w.buf[w.off:]...), w.buf[:w.off]...)
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OutputScan runs the command and decodes its standard output into v, which
+// must be a pointer to a struct or a slice of structs. The fields are mapped by
+// the `command:"name"` tags, or the field names case-insensitively if no tags,
+// the tag "-" means the field is ignored.
+//
+// For a slice, the output is parsed as the table with header aligned by spaces,
+// see [Command.OutputTable], each row is decoded into an element, like `lsblk`:
+//
+//	var disks []struct {
+//		Name string `command:"NAME"`
+//		Size string `command:"SIZE"`
+//		RO   bool   `command:"RO"`
+//	}
+//	err := New([]string{"lsblk", "-d", "-o", "NAME,SIZE,RO"}).OutputScan(&disks)
+//
+// For a struct, the output is parsed as the lines of `key: value`, like
+// `smartctl -i` and `ipmitool mc info`, the lines without `:` are ignored.
+//
+// The fields can be string, bool, integers, floats, or [encoding.TextUnmarshaler].
+func (c *Command) OutputScan(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("OutputScan: v must be a non-nil pointer")
+	}
+	rv = rv.Elem()
+	var table bool
+	switch {
+	case rv.Kind() == reflect.Struct:
+	case rv.Kind() == reflect.Slice && indirectType(rv.Type().Elem()).Kind() == reflect.Struct:
+		table = true
+	default:
+		return fmt.Errorf("OutputScan: v must point to a struct or a slice of structs, not %s", rv.Type())
+	}
+
+	b, err := c.Output()
+	if err != nil {
+		return err
+	}
+	if !table {
+		if err = scanStruct(rv, parseKeyValues(string(b))); err != nil {
+			return fmt.Errorf("OutputScan: %w", err)
+		}
+		return nil
+	}
+	header, rows, err := parseTable(b, TableOptions{Header: true})
+	if err != nil {
+		return fmt.Errorf("OutputScan: %w", err)
+	}
+	elemType := rv.Type().Elem()
+	list := reflect.MakeSlice(rv.Type(), 0, len(rows))
+	for i, row := range rows {
+		values := make(map[string]string, len(header))
+		for j, name := range header {
+			if j < len(row) {
+				values[name] = row[j]
+			}
+		}
+		elem := reflect.New(indirectType(elemType))
+		if err = scanStruct(elem.Elem(), values); err != nil {
+			return fmt.Errorf("OutputScan: row %d: %w", i+1, err)
+		}
+		if elemType.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		list = reflect.Append(list, elem)
+	}
+	rv.Set(list)
+	return nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// parseKeyValues parses the lines of `key: value`, the first value is kept
+// for the duplicated keys.
+func parseKeyValues(s string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		n := strings.IndexByte(line, ':')
+		if n < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:n])
+		if _, ok := values[key]; !ok && key != "" {
+			values[key] = strings.TrimSpace(line[n+1:])
+		}
+	}
+	return values
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// scanStruct sets the fields of struct rv from values by the names of fields
+func scanStruct(rv reflect.Value, values map[string]string) error {
+	lower := make(map[string]string, len(values))
+	for k, v := range values {
+		lower[strings.ToLower(k)] = v
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, ok := field.Tag.Lookup("command")
+		if name == "-" {
+			continue
+		}
+		var s string
+		if ok {
+			s, ok = values[name]
+		} else {
+			name = field.Name
+			s, ok = lower[strings.ToLower(name)]
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(rv.Field(i), s); err != nil {
+			return fmt.Errorf("field %s of %q: %w", field.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// setField parses s into the field f by its type
+func setField(f reflect.Value, s string) error {
+	if f.CanAddr() && f.Addr().Type().Implements(textUnmarshalerType) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(v)
+	case reflect.Ptr:
+		v := reflect.New(f.Type().Elem())
+		if err := setField(v.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(v)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
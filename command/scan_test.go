@@ -0,0 +1,59 @@
+package command
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputScanTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	out := "NAME    SIZE RO MOUNTPOINT\nsda      20G  0 /\nsr0    1024M  1\n"
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var disks []struct {
+		Name       string
+		Size       string `command:"SIZE"`
+		RO         bool   `command:"RO"`
+		Mountpoint *string
+		Ignored    string `command:"-"`
+	}
+	if err := NewSh(`cat %s`, path).OutputScan(&disks); err != nil {
+		t.Fatal(err)
+	}
+	if len(disks) != 2 || disks[0].Name != "sda" || disks[0].RO || *disks[0].Mountpoint != "/" {
+		t.Fatalf("got %+v", disks)
+	}
+	if disks[1].Size != "1024M" || !disks[1].RO || *disks[1].Mountpoint != "" {
+		t.Fatalf("got %+v", disks[1])
+	}
+}
+
+func TestOutputScanKeyValue(t *testing.T) {
+	var info struct {
+		Model    string  `command:"Device Model"`
+		Capacity uint64  `command:"User Capacity"`
+		Temp     float64 `command:"Temperature"`
+		Addr     net.IP  `command:"IP Address"`
+		Missing  int
+	}
+	err := NewSh(`printf '=== START ===\nDevice Model:     Disk X\nUser Capacity : 500\nTemperature: 36.5\nIP Address: 10.0.0.1\n'`).OutputScan(&info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Model != "Disk X" || info.Capacity != 500 || info.Temp != 36.5 || info.Addr.String() != "10.0.0.1" {
+		t.Fatalf("got %+v", info)
+	}
+
+	var bad struct {
+		N int `command:"n"`
+	}
+	if err = NewSh(`echo n: x`).OutputScan(&bad); err == nil {
+		t.Fatal("want error of invalid int")
+	}
+	if err = NewSh(`true`).OutputScan(bad); err == nil {
+		t.Fatal("want error of non-pointer")
+	}
+}
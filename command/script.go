@@ -0,0 +1,151 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewScript returns a Command running the multi-line shell script with the parts
+// escaped like [NewSh]. The script is run by the interpreter of its shebang line
+// like `#!/bin/bash` or `#!/usr/bin/env zsh` if present, otherwise by sh, and the
+// parts are escaped by the rules of the interpreter.
+//
+// The placeholders are validated line by line, the placeholders in comments are
+// rejected with the line number, since they're never substituted by the shell.
+// The script works with [Command.StrictShell], and it's written to a temp file
+// readable only by the current user and run from there, if it exceeds the limit
+// of command line, see [ArgMax].
+func NewScript(script string, parts ...string) *Command {
+	interp := []string{"sh"}
+	if strings.HasPrefix(script, "#!") {
+		line := script[2:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			interp = fields
+		}
+	}
+	d := dialectPOSIX
+	switch strings.TrimSuffix(filepath.Base(interp[len(interp)-1]), ".exe") {
+	case "zsh":
+		d = dialectZsh
+	case "fish":
+		d = dialectFish
+	}
+	c := newCommand(d, append(interp, "-c", script), parts)
+	if c.LastError == nil {
+		if err := checkScriptLines(script); err != nil {
+			c.LastError = fmt.Errorf("NewScript: %w", err)
+		}
+	}
+	return c.wrapArgs(scriptFile)
+}
+
+// checkScriptLines returns error if a placeholder is in a comment of script, the
+// quotes and heredoc bodies are tracked across lines
+func checkScriptLines(script string) error {
+	inSingle, inDouble := false, false
+	// arith is the depth of the (( )) of arithmetic
+	arith := 0
+	// delims are the pending heredoc delimiters, the body starts from next line
+	var delims []heredocOp
+	var body *heredocOp
+	for n, line := range strings.Split(script, "\n") {
+		if body != nil {
+			if isHeredocDelim(line, body.delim, body.stripTabs) {
+				body = nil
+				if len(delims) > 0 {
+					body, delims = &delims[0], delims[1:]
+				}
+			}
+			continue
+		}
+	scan:
+		for i := 0; i < len(line); i++ {
+			switch c := line[i]; {
+			case inSingle:
+				inSingle = c != '\''
+			case c == '\\':
+				i++
+			case inDouble:
+				inDouble = c != '"'
+			case c == '\'':
+				inSingle = true
+			case c == '"':
+				inDouble = true
+			case c == '#' && (i == 0 || strings.IndexByte(" \t;&|()", line[i-1]) >= 0):
+				if p, _ := nextPlaceholder(line[i:], 0, "Fgp"); p >= 0 {
+					return fmt.Errorf("line %d: placeholder in comment", n+1)
+				}
+				break scan
+			case c == '(' && strings.HasPrefix(line[i:], "(("):
+				arith++
+				i++
+			case c == ')' && strings.HasPrefix(line[i:], "))") && arith > 0:
+				arith--
+				i++
+			case c == '<' && arith > 0:
+			case c == '<' && strings.HasPrefix(line[i:], "<<<"):
+				// a here-string
+				i += 2
+			case c == '<':
+				if m := heredocRe.FindStringSubmatchIndex(line[i:]); m != nil && m[0] == 0 {
+					h := heredocOp{stripTabs: m[3] > m[2]}
+					for _, k := range []int{4, 6, 10} {
+						if m[k] >= 0 {
+							h.delim = line[i+m[k] : i+m[k+1]]
+						}
+					}
+					delims = append(delims, h)
+					i += m[1] - 1
+				}
+			}
+		}
+		if len(delims) > 0 && !inSingle && !inDouble {
+			body, delims = &delims[0], delims[1:]
+		}
+	}
+	return nil
+}
+
+// scriptFile writes the script to a temp file if the command line is too
+// long, and runs the file instead, the file is owned by the user of
+// [Command.AsUser] if set.
+func scriptFile(c *Command) error {
+	i := c.scriptIndex()
+	if i < 1 {
+		return nil
+	}
+	script := c.Cmd.Args[i]
+	size := 0
+	for _, v := range c.Cmd.Args {
+		size += len(v) + 1
+	}
+	if size <= ArgMax()-argHeadroom && (maxArgLen == 0 || len(script)+1 <= maxArgLen) {
+		return nil
+	}
+	f, err := os.CreateTemp("", "better-command-script-*")
+	if err != nil {
+		return fmt.Errorf("NewScript: %w", err)
+	}
+	c.OnExit(func(*Command) { os.Remove(f.Name()) })
+	if err := c.chownToUser(f); err != nil {
+		f.Close()
+		return fmt.Errorf("NewScript: %w", err)
+	}
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		return fmt.Errorf("NewScript: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("NewScript: %w", err)
+	}
+	// replace `-c script` by the path
+	args := append([]string(nil), c.Cmd.Args[:i-1]...)
+	args = append(args, f.Name())
+	c.Cmd.Args = append(args, c.Cmd.Args[i+1:]...)
+	return nil
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewScript(t *testing.T) {
+	c := NewScript(`#!/usr/bin/env bash
+# greet the user
+name=%s
+cat <<EOF
+# not a comment: $name
+EOF
+echo "done" # 100%
+`, "a b;c")
+	if c.LastError != nil {
+		t.Fatal(c.LastError)
+	}
+	if c.Args[0] != "/usr/bin/env" || c.Args[1] != "bash" || c.Args[2] != "-c" {
+		t.Fatalf("got args %q", c.Args[:3])
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "# not a comment: a b;c\ndone\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	if c := NewScript("echo hi"); c.Args[0] != "sh" || c.Args[1] != "-c" {
+		t.Fatalf("got args %q", c.Args)
+	}
+}
+
+func TestNewScriptComment(t *testing.T) {
+	for _, v := range []struct {
+		script string
+		want   string
+	}{
+		{"echo a\n# remove %s\nrm -rf %s", "line 2:"},
+		{"echo 'a\n#' %s\necho b # %s", "line 3:"},
+		{"cat <<'X'\n# %s\nX\n  # %s", "line 4:"},
+		{"cat <<< X\n# %s\necho %s", "line 2:"},
+		{"echo $((1<<X))\n# %s\necho %s", "line 2:"},
+	} {
+		c := NewScript(v.script, "a", "b")
+		if c.LastError == nil || !strings.Contains(c.LastError.Error(), v.want) {
+			t.Errorf("got %v for %q, want %s", c.LastError, v.script, v.want)
+		}
+	}
+}
+
+func TestNewScriptStrict(t *testing.T) {
+	err := NewScript("false\necho not reached").StrictShell().Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestNewScriptTempFile(t *testing.T) {
+	// a single arg longer than the limit of Linux
+	script := "x=" + strings.Repeat("a", 200<<10) + "\necho ${#x}"
+	c := NewScript(script)
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "204802" && got != "204800" {
+		t.Fatalf("got %q", got)
+	}
+	if len(c.Args) != 2 || !strings.Contains(c.Args[1], "better-command-script-") {
+		t.Fatalf("got args %q", c.Args)
+	}
+}
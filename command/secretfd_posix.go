@@ -0,0 +1,71 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SecretFD passes content to the command over an inherited pipe, and sets the
+// env name to the fd number of the pipe, like the LISTEN_FDS of systemd, so
+// the secret never appears in the args or the environment of the command.
+//
+// The command reads the secret from the fd until EOF, like `cat <&"$TOKEN_FD"`
+// in shell, or opens /dev/fd/$TOKEN_FD.
+func (c *Command) SecretFD(name string, content []byte) *Command {
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		c.LastError = fmt.Errorf("SecretFD: invalid env name %q", name)
+		return c
+	}
+	return c.prepare(func(c *Command) error {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return fmt.Errorf("SecretFD: %w", err)
+		}
+		c.Cmd.ExtraFiles = append(c.Cmd.ExtraFiles, pr)
+		c.setEnv(name, strconv.Itoa(2+len(c.Cmd.ExtraFiles)))
+		c.OnStart(func(*Command) {
+			// the read end belongs to the child after start
+			pr.Close()
+			go func() {
+				pw.Write(content)
+				pw.Close()
+			}()
+		})
+		c.OnExit(func(*Command) {
+			pr.Close()
+			pw.Close()
+		})
+		return nil
+	})
+}
+
+// PassFile passes f to the command as an inherited fd, and sets the env NAME_FD
+// to the fd number, like `LISTEN_FD=3` of PassFile("LISTEN", listenerFile), thus
+// the sockets and pipes can be passed without counting the ExtraFiles.
+//
+// f belongs to the command once passed, it's closed in the current process after
+// the command started or failed to start, like the write end of a pipe which must
+// be closed for the reader to get EOF. Use f.Dup or f.Fd to keep using it.
+func (c *Command) PassFile(name string, f *os.File) *Command {
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		c.LastError = fmt.Errorf("PassFile: invalid env name %q", name)
+		return c
+	}
+	if f == nil {
+		c.LastError = fmt.Errorf("PassFile: nil file of %s", name)
+		return c
+	}
+	// closed on exit if it fails to start
+	c.OnExit(func(*Command) { f.Close() })
+	return c.prepare(func(c *Command) error {
+		c.Cmd.ExtraFiles = append(c.Cmd.ExtraFiles, f)
+		c.setEnv(name+"_FD", strconv.Itoa(2+len(c.Cmd.ExtraFiles)))
+		c.OnStart(func(*Command) { f.Close() })
+		return nil
+	})
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSecretFD(t *testing.T) {
+	secret := bytes.Repeat([]byte("s3cret"), 20000)
+	c := NewSh(`echo $TOKEN_FD; cat <&"$TOKEN_FD"`).SecretFD("TOKEN_FD", secret)
+	b, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "3\n" + string(secret); string(b) != want {
+		t.Fatalf("got %d bytes, want %d", len(b), len(want))
+	}
+	for _, v := range append(c.Args, c.Cmd.Env...) {
+		if strings.Contains(v, "s3cret") {
+			t.Fatal("secret in args or env")
+		}
+	}
+}
+
+func TestSecretFDUnread(t *testing.T) {
+	secret := bytes.Repeat([]byte("x"), 1<<20)
+	if err := NewSh(`true`).SecretFD("A", secret).SecretFD("B", nil).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSh(`true`).SecretFD("A=B", nil).Run(); err == nil {
+		t.Fatal("want invalid name error")
+	}
+}
+
+func TestPassFile(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	c := NewSh(`echo $OUT_FD $TOKEN_FD; echo abc >&"$OUT_FD"`).SecretFD("TOKEN_FD", []byte("x")).PassFile("OUT", w)
+	b, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "4 3\n" {
+		t.Fatalf("got %q", b)
+	}
+	// the write end is closed in the current process, thus EOF is read
+	b, err = io.ReadAll(r)
+	if err != nil || string(b) != "abc\n" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+
+	if err := NewSh(`true`).PassFile("A=B", r).Run(); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// SecretFD passes content to the command over an inherited pipe, and sets the
+// env name to the fd number of the pipe, it's not supported on windows.
+func (c *Command) SecretFD(name string, content []byte) *Command {
+	c.LastError = fmt.Errorf("SecretFD: not support windows yet")
+	return c
+}
+
+// PassFile passes f to the command as an inherited fd, and sets the env NAME_FD
+// to the fd number, it's not supported on windows.
+func (c *Command) PassFile(name string, f *os.File) *Command {
+	c.LastError = fmt.Errorf("PassFile: not support windows yet")
+	return c
+}
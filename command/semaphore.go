@@ -0,0 +1,87 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Semaphore caps the count of running commands, it can be shared by unrelated
+// commands with [Command.WithSemaphore]. The waiters acquire in FIFO order.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int
+	used    int
+	waiters []chan struct{}
+}
+
+// NewSemaphore returns a Semaphore allowing n commands to run at the same time
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire blocks until a slot is available or ctx is done, the fairness is kept,
+// thus it waits if there are earlier waiters even if a slot is available.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s.size < 1 {
+		return fmt.Errorf("invalid semaphore size %d", s.size)
+	}
+	s.mu.Lock()
+	if s.used < s.size && len(s.waiters) == 0 {
+		s.used++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.waiters = append(s.waiters, ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for i, v := range s.waiters {
+			if v == ready {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				s.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		s.mu.Unlock()
+		// the slot is granted at the same time
+		s.Release()
+		return ctx.Err()
+	}
+}
+
+// Release releases a slot acquired by Acquire
+func (s *Semaphore) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiters) > 0 {
+		// hand over the slot to the first waiter
+		close(s.waiters[0])
+		s.waiters = s.waiters[1:]
+		return
+	}
+	if s.used > 0 {
+		s.used--
+	}
+}
+
+// WithSemaphore makes the command acquire a slot of s before start, and release
+// it after Wait returns or the start fails, the wait is aborted if the context of
+// command is done, like by [Command.Timeout].
+func (c *Command) WithSemaphore(s *Semaphore) *Command {
+	return c.prepare(func(c *Command) error {
+		if err := s.Acquire(c.Ctx); err != nil {
+			return fmt.Errorf("WithSemaphore: %w", err)
+		}
+		go func() {
+			<-c.Done()
+			s.Release()
+		}()
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore(2)
+	var running, max int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := NewSh("sleep 0.05").WithSemaphore(s).OnStart(func(*Command) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+			}).OnExit(func(*Command) {
+				atomic.AddInt32(&running, -1)
+			}).Run()
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max != 2 {
+		t.Fatalf("got max running %d, want 2", max)
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	s := NewSemaphore(1)
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			if err := s.Acquire(context.Background()); err != nil {
+				t.Error(err)
+			}
+			order <- i
+			s.Release()
+		}()
+		// wait for the goroutine to be queued
+		for {
+			s.mu.Lock()
+			n := len(s.waiters)
+			s.mu.Unlock()
+			if n == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	s.Release()
+	for i := 0; i < 3; i++ {
+		if got := <-order; got != i {
+			t.Fatalf("got %d, want %d", got, i)
+		}
+	}
+}
+
+func TestSemaphoreCanceled(t *testing.T) {
+	s := NewSemaphore(1)
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := NewSh("true").WithSemaphore(s).Timeout(50 * time.Millisecond).Run()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	s.Release()
+	if err := NewSh("true").WithSemaphore(s).Run(); err != nil {
+		t.Fatal(err)
+	}
+}
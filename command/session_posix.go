@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import "os/exec"
+
+// NewSession runs the command in a new session by setsid(2) instead of only a
+// new process group, so it's detached from the controlling terminal and never
+// gets the SIGHUP when the terminal is closed, like long-lived daemons.
+//
+// The command is still the leader of its process group, thus the process group
+// kill on cancel works as before. It can't be combined with [Command.Interactive],
+// since the command has no controlling terminal.
+func (c *Command) NewSession() *Command {
+	// setpgid fails for the session leader
+	c.Cmd.SysProcAttr.Setsid = true
+	c.Cmd.SysProcAttr.Setpgid = false
+	return c
+}
+
+// detach sets cmd to run in a new session for StartDetached
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setpgid = false
+}
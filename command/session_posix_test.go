@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewSession(t *testing.T) {
+	c := NewSh(`ps -o sid= -p $$`).NewSession()
+	b, err := c.Output()
+	if err != nil {
+		t.Skip(err)
+	}
+	sid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid != c.Pid {
+		t.Fatalf("got session %d, want %d", sid, c.Pid)
+	}
+}
+
+func TestNewSessionKill(t *testing.T) {
+	c := NewSh(`sleep 10 & wait`).NewSession().Timeout(100 * time.Millisecond)
+	start := time.Now()
+	if err := c.Run(); err == nil {
+		t.Fatal("want killed")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("the process group is not killed")
+	}
+	// the killed processes may not be reaped yet
+	for i := 0; syscall.Kill(-c.Pid, 0) == nil; i++ {
+		if i > 100 {
+			t.Fatal("the process group is still alive")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
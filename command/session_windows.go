@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is the DETACHED_PROCESS flag of CreateProcess
+const detachedProcess = 0x00000008
+
+// NewSession runs the command in a new session by setsid(2), it's not
+// supported on windows.
+func (c *Command) NewSession() *Command {
+	c.LastError = fmt.Errorf("NewSession: not support windows yet")
+	return c
+}
+
+// detach sets cmd to run without the console of current process for StartDetached
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess
+}
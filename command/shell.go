@@ -5,79 +5,299 @@
 package command
 
 import (
-	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"os/exec"
-	"os/user"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/futurist/better-command/shlex"
 )
 
 const shellVars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
 
-var shellNormal = make(map[rune]bool, 0)
+// asciiSet is a 128-bit lookup table of ASCII characters
+type asciiSet [2]uint64
 
-func init() {
-	for _, v := range "#%+-.~/:=" + shellVars {
-		shellNormal[v] = true
+func makeASCIISet(chars string) asciiSet {
+	var s asciiSet
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		s[c>>6] |= 1 << (c & 63)
 	}
+	return s
 }
 
+// contains reports whether c is in s, false for non-ASCII bytes
+func (s *asciiSet) contains(c byte) bool {
+	return c < utf8.RuneSelf && s[c>>6]&(1<<(c&63)) != 0
+}
+
+var (
+	varChars = makeASCIISet(shellVars)
+	// # and ~ are special at the start of word, they are always escaped
+	shellNormal = makeASCIISet("%+-./:=" + shellVars)
+)
+
+// ReplaceShellString escape s by the class of token, for the POSIX shells
 func ReplaceShellString(s string, token *shlex.Token) string {
-	r := make([]rune, 0)
+	return replaceShellString(s, token, dialectPOSIX)
+}
+
+// replaceShellString escape s by the class of token, and the rules of shell dialect d
+func replaceShellString(s string, token *shlex.Token, d dialect) string {
+	escaping := token.TokenClass == shlex.EscapingQuoteRuneClass || token.TokenClass == shlex.UnknownRuneClass
+	fishQuoted := token.TokenClass == shlex.NonEscapingQuoteRuneClass && d == dialectFish
+	if !escaping && !fishQuoted {
+		return s
+	}
+	b := make([]byte, 0, len(s)*2)
 	inVar := 0
 	varPos := 0
-	for i, v := range s {
-		next, next2 := "", ""
-		if i+1 < len(s) {
-			next = s[i+1 : i+2]
-		}
-		if i+2 < len(s) {
-			next2 = s[i+2 : i+3]
+	for i := 0; i < len(s); {
+		v := s[i]
+		size := 1
+		if v >= utf8.RuneSelf {
+			_, size = utf8.DecodeRuneInString(s[i:])
 		}
-		if token.TokenClass == shlex.EscapingQuoteRuneClass || token.TokenClass == shlex.UnknownRuneClass {
-			isVarChar := strings.Contains(shellVars, string(v))
-			if inVar == 1 && !isVarChar {
-				inVar = 0
+		if fishQuoted {
+			// fish treats \\ and \' as escapes inside single quotes
+			if v == '\\' || v == '\'' {
+				b = append(b, '\\')
 			}
-			if inVar == 2 && v != '{' && !isVarChar {
-				inVar = 0
-				// for ${HOME:-}, we need \${HOME:-\}
-				if varPos > 0 && v != '}' {
-					r = append(r[0:varPos-1], append([]rune{'\\'}, r[varPos-1:]...)...)
-				}
+			b = append(b, s[i:i+size]...)
+			i += size
+			continue
+		}
+		isVarChar := varChars.contains(v)
+		if inVar == 1 && !isVarChar {
+			inVar = 0
+		}
+		if inVar == 2 && !(v == '{' && len(b) == varPos) && !isVarChar {
+			inVar = 0
+			if v == '}' {
 				varPos = 0
-				r = append(r, v)
+				b = append(b, v)
+				i++
 				continue
 			}
-			if v == '$' && strings.Contains(shellVars, next) {
-				varPos = len(r) + 1
-				inVar = 1
+			// for ${HOME:-}, we need \${HOME:-\}, and v is escaped below
+			if varPos > 0 {
+				b = insertBackslash(b, varPos-1)
 			}
-			if v == '$' && next == "{" && strings.Contains(shellVars, next2) {
-				varPos = len(r) + 1
-				inVar = 2
+			varPos = 0
+		}
+		if v == '$' && i+1 < len(s) && varChars.contains(s[i+1]) {
+			varPos = len(b) + 1
+			inVar = 1
+		}
+		if v == '$' && i+2 < len(s) && s[i+1] == '{' && varChars.contains(s[i+2]) && d != dialectFish {
+			varPos = len(b) + 1
+			inVar = 2
+		}
+		// $VAR || ${VAR}
+		if inVar > 0 {
+			b = append(b, v)
+			i++
+			continue
+		}
+		if escaped, next, ok := d.escapeRune(b, s, i); ok {
+			b = escaped
+			i = next
+			continue
+		}
+		if !shellNormal.contains(v) || (token.TokenClass > 0 && inVar == 0) {
+			if !isVarChar {
+				b = append(b, '\\')
 			}
-			// $VAR || ${VAR}
-			if inVar > 0 {
-				r = append(r, v)
-				continue
+		}
+		b = append(b, s[i:i+size]...)
+		i += size
+	}
+	if inVar == 2 && varPos > 0 {
+		// ${VAR without the closing brace
+		b = insertBackslash(b, varPos-1)
+	}
+	return string(b)
+}
+
+// insertBackslash inserts a backslash into b at i
+func insertBackslash(b []byte, i int) []byte {
+	b = append(b, 0)
+	copy(b[i+1:], b[i:])
+	b[i] = '\\'
+	return b
+}
+
+// prepare add f to run before the command start
+func (c *Command) prepare(f func(*Command) error) *Command {
+	c.mu.Lock()
+	c.prepares = append(c.prepares, f)
+	c.mu.Unlock()
+	return c
+}
+
+// wrapArgs add f to run after all prepares before the command start,
+// f should wrap the Args with another command, like `sh -l -c`.
+func (c *Command) wrapArgs(f func(*Command) error) *Command {
+	c.mu.Lock()
+	c.argsWrappers = append(c.argsWrappers, f)
+	c.mu.Unlock()
+	return c
+}
+
+// scriptIndex returns the index of script in Args, which is the arg after "-c",
+// or -1 if not found
+func (c *Command) scriptIndex() int {
+	for i, v := range c.Cmd.Args {
+		if v == "-c" && i+1 < len(c.Cmd.Args) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// render is the state of substituting the templates of a command by [substitute]
+type render struct {
+	// nonce is the random part of the temp file names of %F, thus the paths
+	// are kept when the templates are rendered again
+	nonce string
+	files []fileArg
+	// home is the home dir for the `~` of %p, the current user's if empty
+	home string
+	// params is set by WithParams, the %s are replaced by the env references
+	params bool
+	// verbs is set by ExtendedVerbs, the %F, %g and %p are placeholders
+	verbs bool
+}
+
+// substituteTokens replace each %s in format with the escaped parts,
+// starting from parts[*i], the format is at byte offset base of the template
+// for the error positions. The %F, %g and %p are kept as is unless r.verbs is
+// set.
+func substituteTokens(format string, base int, parts []string, i *int, d dialect, r *render) (string, error) {
+	var b strings.Builder
+	b.Grow(len(format) * 2)
+	l := shlex.NewStringTokenizer(format)
+	for {
+		if token, err := l.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			var e *shlex.Error
+			if errors.As(err, &e) {
+				return "", fmt.Errorf("template: %s at offset %d", e.Msg, base+e.Offset)
 			}
-			if !shellNormal[v] || (token.TokenClass > 0 && inVar == 0) {
-				if !isVarChar {
-					r = append(r, '\\')
+			return "", fmt.Errorf("template: %w", err)
+		} else {
+			// the sanitized parts may contain %s, so never scan them again
+			s := token.Value
+			if token.TokenType == shlex.CommentToken && strings.HasSuffix(s, "\n") {
+				// the tokenizer drops the # of the comment ended by newline,
+				// put it back after the leading spaces
+				if k := strings.IndexByte(format[token.Offset:], '#'); k >= 0 && k <= len(s) {
+					s = s[:k] + "#" + s[k:]
 				}
 			}
+			verbs := ""
+			if r != nil && r.verbs {
+				verbs += "F"
+			}
+			if token.TokenClass == shlex.UnknownRuneClass && token.Quotes == 0 && r != nil && r.verbs {
+				verbs += "gp"
+			}
+			pos := 0
+			for {
+				n, verb := nextPlaceholder(s, pos, verbs)
+				if n < 0 {
+					break
+				}
+				if *i >= len(parts) {
+					return "", fmt.Errorf("template: missing part %d for %%%c", *i+1, verb)
+				}
+				part := parts[*i]
+				var v string
+				switch verb {
+				case 'g':
+					if v, err = escapeGlob(part, d); err != nil {
+						return "", err
+					}
+				case 'p':
+					if v, err = r.expandPath(part, d); err != nil {
+						return "", err
+					}
+				case 'F':
+					path, err := r.tempFile(part)
+					if err != nil {
+						return "", err
+					}
+					v = replaceShellString(path, token, d)
+				default:
+					if r != nil && r.params {
+						v = paramRef(*i, token, d)
+						break
+					}
+					v = replaceShellString(part, token, d)
+					if v == "" && !token.IsNonEscape() {
+						// keep the empty argument
+						v = "''"
+					} else if token.IsNonEscape() && d != dialectFish {
+						// close the quotes, then an escaped quote, and reopen the quotes
+						v = strings.ReplaceAll(v, "'", `'\''`)
+					}
+				}
+				if token.TokenType == shlex.CommentToken {
+					// a newline would end the comment
+					v = strings.NewReplacer("\n", " ", "\r", " ").Replace(v)
+				}
+				b.WriteString(s[pos:n])
+				b.WriteString(v)
+				pos = n + 2
+				*i++
+			}
+			b.WriteString(s[pos:])
+		}
+	}
+	return b.String(), nil
+}
+
+// nextPlaceholder returns the index of the next %s in s from pos along with the
+// verb, or the next placeholder of the other verbs allowed in the token:
+//
+//   - %F: only at the start of a word or after `=`, like `cat %F` and `--config=%F`,
+//     so `date +%F` is kept.
+//   - %g: only in the unquoted words, at the start of a word or after `=` or `/`,
+//     like `ls %g` and `ls dir/%g`, so `printf '%g'` and `date +%g` are kept.
+//   - %p: only at the start of an unquoted word or after `=`.
+func nextPlaceholder(s string, pos int, verbs string) (int, byte) {
+	for pos < len(s) {
+		n := strings.IndexByte(s[pos:], '%')
+		if n < 0 || pos+n+1 >= len(s) {
+			return -1, 0
 		}
-		r = append(r, v)
+		n += pos
+		verb := s[n+1]
+		switch {
+		case verb == 's':
+			return n, verb
+		case strings.IndexByte(verbs, verb) < 0:
+		case verb == 'F' || verb == 'p':
+			if n == 0 || strings.IndexByte(" \t\n=", s[n-1]) >= 0 {
+				return n, verb
+			}
+		case verb == 'g':
+			if n == 0 || strings.IndexByte(" \t\n=/", s[n-1]) >= 0 {
+				return n, verb
+			}
+		}
+		pos = n + 1
 	}
-	return string(r)
+	return -1, 0
 }
 
 // Command is embedded [exec.Cmd] struct, with some more state to use.
@@ -94,51 +314,125 @@ type Command struct {
 	onstart []func(*Command)
 	onexit  []func(*Command)
 	mu      *sync.RWMutex
-}
 
-// sudo will return "sudo" command if non-root, or else ""
-func sudo() []string {
-	currentUser, _ := user.Current()
-	if currentUser != nil {
-		if currentUser.Uid == "0" {
-			return nil
-		}
-		return []string{"sudo", "-E"}
-	}
-	return nil
+	// dialect is the escaping rules of the shell
+	dialect dialect
+	// templates is the cmdArgs before substitution
+	templates []string
+	parts     []string
+	// secrets are masked in rendered strings, see Redact
+	secrets []string
+	// prepares run in order before start, the error will abort the start
+	prepares     []func(*Command) error
+	argsWrappers []func(*Command) error
+
+	normalizeNewlines   bool
+	trimTrailingNewline bool
+	captureTail         int
+	spillThreshold      int64
+	spillDir            string
+	stdoutWrappers      []func(io.Writer) (io.WriteCloser, error)
+	closeWrapped        func() error
+	startTime           time.Time
+	exitTime            time.Time
+	// wait is returned by the Executor, which replaces Cmd.Wait
+	wait  func() error
+	clock Clock
+	// autoChunk is the count of fixed args, 0 to disable AutoChunk
+	autoChunk int
+	// render is the state of substituting templates, and rendered is the result
+	render   render
+	rendered []string
+	// ignoreInterrupt is set by IgnoreParentInterrupt
+	ignoreInterrupt bool
+	// done is closed after Wait returns or Spawn fails
+	done chan struct{}
+	// stop cancels Ctx, it's kept since Cancel may be reset
+	stop context.CancelFunc
+	// procCtx of Cmd is canceled by kill to kill the process
+	procCtx    context.Context
+	kill       context.CancelFunc
+	killPolicy KillPolicy
+	killScope  KillScope
+	// cancelSignal and killDelay are set by CancelSignal
+	cancelSignal syscall.Signal
+	killDelay    time.Duration
+	// lastCPU is the CPU time at lastCPUTime sampled by ProcStat
+	lastCPU     time.Duration
+	lastCPUTime time.Time
+	// cause is the error of cancelWithCause
+	cause error
+	// retries and retryBackoff are set by Retry
+	retries      int
+	retryBackoff time.Duration
+	// stdinEscape is set by StdinEscape
+	stdinEscape Escaper
+	// timeout is the last duration of Timeout for Spec
+	timeout time.Duration
+	// events are the functions of OnEvent, called under eventMu
+	events  []func(Event)
+	eventMu *sync.Mutex
+	created time.Time
+	// onretry and onfailure are the functions of OnRetry and OnFailure
+	onretry   []func(int, error, time.Duration)
+	onfailure []func(*Command, error)
+	// beforeStart are the functions of BeforeStart
+	beforeStart []func(*exec.Cmd) error
+	// onsignal are the functions of OnSignal
+	onsignal []func(syscall.Signal)
+	// progressInterval is the interval of Progress
+	progressInterval time.Duration
+	// onwait are the functions run by Wait after the command exited
+	onwait []func(*Command)
+	// writeErr is the error of output writers by OnWriteError
+	writeErr error
+	// detached is set by StartDetached, thus the process is never killed
+	detached bool
+	// keepTempDir is set by KeepTempDirOnFailure
+	keepTempDir bool
+	// spawned is set when the command starts to spawn, then the cleanup is left
+	// to the spawn and Wait
+	spawned bool
 }
 
-// UseSudo to run command use `sudo` if not root, otherwise run normally
-func (c *Command) UseSudo() *Command {
-	s := sudo()
-	if s != nil {
-		c.Cmd.Args = append(s, c.Cmd.Args...)
-	}
-	return c
+// UseSudo to run command use `sudo` if not root, otherwise run normally.
+//
+// Without opts it runs `sudo -E` to preserve all env, with opts only
+// the env specified by [SudoPreserveEnv] is preserved.
+func (c *Command) UseSudo(opts ...SudoOption) *Command {
+	return c.elevate(newSudoOptions("sudo", opts))
 }
 
 // Context can set command context that can cause the command be killed when canceled.
 //
 // The provided context is used to kill the process (by calling
 // os.Process.Kill) if the context becomes done before the command
-// completes on its own.
+// completes on its own, or to terminate it by the [KillPolicy] if set.
 func (c *Command) Context(ctx context.Context) *Command {
 	go func() {
 		select {
 		case <-ctx.Done():
 			c.Cancel()
-			c.Cancel = nil
 		case <-c.Ctx.Done():
 		}
-		c.cleanup()
+		c.mu.RLock()
+		spawned := c.spawned
+		c.mu.RUnlock()
+		// the spawned command is cleaned up after exit, not in the grace period of
+		// KillPolicy, or by the spawn if it fails as the Ctx is done
+		if !spawned {
+			c.cleanup()
+		}
 	}()
 	return c
 }
 
-// Timeout run command with timeout, then kill the process.
+// Timeout run command with timeout, then kill the process, or terminate it by
+// the [KillPolicy] if set.
 func (c *Command) Timeout(timeout time.Duration) *Command {
-	ctx, cancel := context.WithTimeout(c.Ctx, timeout)
+	ctx, cancel := withClockTimeout(c.Ctx, c.getClock(), timeout)
 	c.mu.Lock()
+	c.timeout = timeout
 	c.onexit = append(c.onexit, func(c *Command) { cancel() })
 	c.mu.Unlock()
 	return c.Context(ctx)
@@ -150,8 +444,38 @@ func (c *Command) Env(env []string) *Command {
 	return c
 }
 
-// Dir run command with PWD set to dir
-func (c *Command) Dir(dir string) *Command {
+// setEnv set the env key to value, the env of current process is used as
+// base if command env not set
+func (c *Command) setEnv(key, value string) {
+	env := c.Cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	prefix := key + "="
+	for i, v := range env {
+		if strings.HasPrefix(v, prefix) {
+			env[i] = prefix + value
+			c.Cmd.Env = env
+			return
+		}
+	}
+	c.Cmd.Env = append(env, prefix+value)
+}
+
+// Dir run command with PWD set to dir, the %s in dir are substituted by parts
+// in order if any, like Dir("/srv/builds/%s", id), and each part must be a single
+// name, neither empty nor "." or "..", without the path separators, thus it can't
+// escape the dir. LastError will be set if dir doesn't exist or isn't a directory,
+// an empty dir runs command in the current dir.
+func (c *Command) Dir(dir string, parts ...string) *Command {
+	dir, err := dirPath(dir, parts)
+	if err == nil {
+		err = checkDir(dir)
+	}
+	if err != nil {
+		c.LastError = fmt.Errorf("Dir: %w", err)
+		return c
+	}
 	c.Cmd.Dir = dir
 	return c
 }
@@ -174,6 +498,21 @@ func (c *Command) Stderr(f io.Writer) *Command {
 	return c
 }
 
+// Quiet discards the stdout and stderr of command, while the last 32KB of stderr
+// is kept for the Stderr of [exec.ExitError], thus it's for the calls only caring
+// about the exit status without losing the context of errors.
+func (c *Command) Quiet() *Command {
+	stderr := &tailBuffer{n: 32 << 10}
+	c.Cmd.Stdout = io.Discard
+	c.Cmd.Stderr = stderr
+	return c.OnFailure(func(c *Command, err error) {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && ee.Stderr == nil {
+			ee.Stderr = c.normalizeOutput(stderr.Bytes())
+		}
+	})
+}
+
 // Shell set command shell to shellName instead of 'sh', it must accept '-c' as second arg
 func (c *Command) Shell(shellName string) *Command {
 	c.Cmd.Args[0] = shellName
@@ -197,6 +536,56 @@ func (c *Command) OnExit(f ...func(*Command)) *Command {
 	return c
 }
 
+// BeforeStart set f to run with the Cmd after all the settings of command are
+// applied, like the wrappers of [Command.UseSudo] and the writers of Output, just
+// before the process is started, thus it can set the fields not covered by the
+// methods, like ExtraFiles and the details of SysProcAttr. The command is not
+// started if f returns an error, which is returned by Spawn.
+//
+// f is only called for the first process, the processes of [Command.Retry] and
+// [Command.AutoChunk] copy the Args, Env, Dir, files and SysProcAttr from it.
+func (c *Command) BeforeStart(f func(cmd *exec.Cmd) error) *Command {
+	c.mu.Lock()
+	c.beforeStart = append(c.beforeStart, f)
+	c.mu.Unlock()
+	return c
+}
+
+// onWait add f to run by Wait after the command exited, before the OnExit
+// functions, it's not called if the command is not started.
+func (c *Command) onWait(f func(*Command)) *Command {
+	c.mu.Lock()
+	c.onwait = append(c.onwait, f)
+	c.mu.Unlock()
+	return c
+}
+
+// ProcAttr calls f with the SysProcAttr of command to set the fields not covered
+// by the methods, like Chroot or Cloneflags on Linux, thus they're combined with
+// the fields set by the package, like Setpgid and the Credential of
+// [Command.AsUser], instead of replacing the whole Cmd.SysProcAttr. The
+// fields set by f may be changed by the later methods setting the same fields.
+func (c *Command) ProcAttr(f func(attr *syscall.SysProcAttr)) *Command {
+	if c.Cmd.SysProcAttr == nil {
+		c.Cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	f(c.Cmd.SysProcAttr)
+	return c
+}
+
+// beforeStartHooks runs the functions of BeforeStart
+func (c *Command) beforeStartHooks() error {
+	c.mu.RLock()
+	beforeStart := c.beforeStart
+	c.mu.RUnlock()
+	for _, f := range beforeStart {
+		if err := f(c.Cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // NewBash just like [New], but run []string{"bash", "-c", cmdString} by default
 func NewBash(cmdString string, parts ...string) *Command {
 	return New([]string{"bash", "-c", cmdString}, parts...)
@@ -213,6 +602,18 @@ func NewSh(cmdString string, parts ...string) *Command {
 //
 //   - %s or "%s": will escape everything, except for shell variables like $ABC, or ${ABC}, any other variables form not accepted.
 //   - '%s': will escape everything, shell variables also be escaped.
+//   - %F: the part is written to a temp file readable only by the current user, and replaced
+//     by the path of the file, which is removed on exit. It keeps large or sensitive parts out
+//     of the command line, it's only a placeholder at the start of a word or after `=`, and
+//     only with [Command.ExtendedVerbs], otherwise it's kept as is like `stat -c %F`.
+//   - %g: the part is a glob pattern of `*`, `?` and bracket expressions like `[a-z]`, which
+//     are kept unescaped and everything else is escaped like '%s', the invalid patterns are
+//     rejected. It's only a placeholder in the unquoted words, at the start of a word or after
+//     `=` or `/`, and only with [Command.ExtendedVerbs], otherwise it's kept as is like `printf %g`.
+//   - %p: the part is a path, the leading `~` or `~user` is expanded to the home dir, of the
+//     target user of [Command.AsUser] if set, then escaped like '%s'. It's only a placeholder
+//     at the start of an unquoted word or after `=`, and only with [Command.ExtendedVerbs],
+//     otherwise it's kept as is like `find -printf %p`.
 //
 // Command returns the Cmd struct to execute the named program with
 // the given arguments.
@@ -237,35 +638,39 @@ func NewSh(cmdString string, parts ...string) *Command {
 // quoting yourself and provide the full command line in SysProcAttr.CmdLine,
 // leaving Args empty.
 func New(cmdArgs []string, parts ...string) *Command {
-	for i2, v := range cmdArgs {
-		c := make([]string, 0)
-		l := shlex.NewTokenizer(strings.NewReader(v))
-		i := 0
-		for {
-			if token, err := l.Next(); err != nil {
-				break
-			} else {
-				s := token.Value
-				for strings.Contains(s, "%s") {
-					sanitized := ReplaceShellString(parts[i], token)
-					s = strings.Replace(s, "%s", sanitized, 1)
-					i++
-				}
-				c = append(c, s)
-			}
+	return newCommand(dialectPOSIX, cmdArgs, parts)
+}
+
+// newCommand is [New] with the escaping rules of shell dialect d
+func newCommand(d dialect, cmdArgs []string, parts []string) *Command {
+	templates := append([]string(nil), cmdArgs...)
+	var lastError error
+	var r render
+	for i, v := range cmdArgs {
+		s, err := substitute(v, parts, d, &r)
+		if err != nil && lastError == nil {
+			lastError = err
 		}
-		cmdArgs[i2] = strings.Join(c, "")
+		cmdArgs[i] = s
 	}
 
 	// in go1.20 we should use context.WithCancelCause
 	ctx, cancel := context.WithCancel(context.Background())
-	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	// the process is killed by procCtx, which is canceled by the KillPolicy after ctx
+	procCtx, kill := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(procCtx, cmdArgs[0], cmdArgs[1:]...)
 	if cmd == nil {
 		cancel()
+		kill()
 		return nil
 	}
-	cmd.SysProcAttr = &syscall.SysProcAttr{}
-	c := &Command{Cmd: cmd, Ctx: ctx, Cancel: cancel, mu: new(sync.RWMutex)}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c := &Command{Cmd: cmd, Ctx: ctx, Cancel: cancel, stop: cancel, procCtx: procCtx, kill: kill, mu: new(sync.RWMutex), done: make(chan struct{}), LastError: lastError, dialect: d, templates: templates, parts: parts, render: r}
+	c.rendered = append([]string(nil), cmdArgs...)
+	c.eventMu = new(sync.Mutex)
+	c.created = time.Now()
 	c.onexit = make([]func(*Command), 0)
 	fn := c.initCmd(cmd)
 	if fn != nil {
@@ -303,72 +708,242 @@ func (c *Command) cleanup() {
 // thread state (for example, Linux or Plan 9 name spaces), the new
 // process will inherit the caller's thread state.
 func (c *Command) Run() error {
-	defer c.cleanup()
+	if err := c.Spawn(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Spawn starts the command but does not wait for it to complete,
+// the OnStart functions are called after started.
+//
+// After a successful call to Spawn the [Command.Wait] method must be called
+// in order to release associated system resources and run the OnExit functions.
+func (c *Command) Spawn() error {
+	err := c.spawn()
+	if err != nil {
+		c.debugf("start %s: %v", QuoteArgs(c.Cmd.Args), err)
+		c.failed(err)
+		c.markDone()
+	} else {
+		c.debugf("start pid %d: %s", c.Pid, QuoteArgs(c.Cmd.Args))
+	}
+	return err
+}
+
+// setup runs the prepares and args wrappers, and checks the policy before the
+// command starts, it returns the Args before the wrappers.
+func (c *Command) setup() ([]string, error) {
+	c.mu.Lock()
+	c.spawned = true
+	c.mu.Unlock()
+	c.mu.RLock()
+	prepares := append([]func(*Command) error{}, c.prepares...)
+	argsWrappers := append([]func(*Command) error{}, c.argsWrappers...)
+	c.mu.RUnlock()
+	for _, f := range prepares {
+		if err := f(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.writeFiles(); err != nil {
+		return nil, err
+	}
+	// the args wrappers run after all prepares, since prepares may modify the script
+	args := append([]string(nil), c.Cmd.Args...)
+	for _, f := range argsWrappers {
+		if err := f(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkPolicy(); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// spawn starts the command for Spawn
+func (c *Command) spawn() error {
 	if c.LastError != nil {
+		c.cleanup()
 		return c.LastError
 	}
 
-	if err := c.Start(); err != nil {
+	args, err := c.setup()
+	if err != nil {
+		c.cleanup()
 		return err
 	}
+	chunks, err := c.chunkArgs(args)
+	if err != nil {
+		c.cleanup()
+		return err
+	}
+
+	closeWrapped, err := c.wrapStdout()
+	if err != nil {
+		c.cleanup()
+		return err
+	}
+	if err := c.beforeStartHooks(); err != nil {
+		closeWrapped()
+		c.cleanup()
+		return err
+	}
+	if err := c.Ctx.Err(); err != nil {
+		closeWrapped()
+		c.cleanup()
+		return c.causeOf(err)
+	}
+	startTime := c.now()
+	var wait func() error
+	if e := currentExecutor(); e != nil {
+		wait, err = e.Start(c)
+	} else {
+		err = c.Cmd.Start()
+	}
+	if err != nil {
+		closeWrapped()
+		c.cleanup()
+		return err
+	}
+	if wait == nil {
+		wait = c.Cmd.Wait
+	}
+	if len(chunks) > 0 {
+		wait = c.chunkWait(wait, chunks)
+	}
+	wait = c.retryWait(wait, c.Cmd.Args, chunks)
 	c.mu.Lock()
+	c.startTime = startTime
+	c.wait = wait
 	if c.Process != nil {
 		c.Pid = c.Process.Pid
 	}
+	c.closeWrapped = closeWrapped
 	onstart := c.onstart
 	c.mu.Unlock()
+	go c.watchCancel()
+	c.emit(Event{Type: EventStarted})
 	for _, v := range onstart {
 		v(c)
 	}
-	return c.Wait()
+	return nil
+}
+
+// Wait waits for the command started by [Command.Spawn] to exit, then runs
+// the OnExit functions, see [exec.Cmd.Wait].
+func (c *Command) Wait() error {
+	defer c.markDone()
+	defer c.cleanup()
+	c.mu.RLock()
+	wait := c.wait
+	c.mu.RUnlock()
+	if wait == nil {
+		wait = c.Cmd.Wait
+	}
+	err := c.causeOf(wait())
+	exitTime := c.now()
+	c.mu.Lock()
+	c.exitTime = exitTime
+	elapsed := exitTime.Sub(c.startTime)
+	closeWrapped := c.closeWrapped
+	c.closeWrapped = nil
+	writeErr := c.writeErr
+	c.mu.Unlock()
+	if closeWrapped != nil {
+		if e := closeWrapped(); err == nil {
+			err = e
+		}
+	}
+	if err == nil {
+		err = writeErr
+	}
+	c.emitExited(err)
+	if err != nil {
+		c.debugf("exit pid %d after %v: %v", c.Pid, elapsed, err)
+	} else {
+		c.debugf("exit pid %d after %v: exit status 0", c.Pid, elapsed)
+	}
+	c.mu.RLock()
+	onwait := c.onwait
+	c.mu.RUnlock()
+	for _, f := range onwait {
+		f(c)
+	}
+	if sig, ok := c.termSignal(); ok {
+		c.mu.RLock()
+		onsignal := c.onsignal
+		c.mu.RUnlock()
+		for _, f := range onsignal {
+			f(sig)
+		}
+	}
+	c.failed(err)
+	return err
 }
 
 // Output runs the command and returns its standard output.
 // Any returned error will usually be of type *ExitError.
 // If c.Stderr was nil, Output populates ExitError.Stderr.
 func (c *Command) Output() ([]byte, error) {
+	stdout := c.newCapture()
+	defer closeCapture(stdout)
+	err := c.output(stdout)
+	return c.normalizeOutput(stdout.Bytes()), err
+}
+
+// output runs the command with stdout written into w
+func (c *Command) output(w io.Writer) error {
 	defer c.cleanup()
 	if c.LastError != nil {
-		return nil, c.LastError
+		return c.LastError
 	}
 
 	if c.Cmd.Stdout != nil {
-		return nil, errors.New("exec: Stdout already set")
+		return errors.New("exec: Stdout already set")
 	}
-	var stdout bytes.Buffer
-	c.Cmd.Stdout = &stdout
+	c.Cmd.Stdout = w
 
-	captureErr := c.Cmd.Stderr == nil
-	if captureErr {
-		c.Cmd.Stderr = &prefixSuffixSaver{N: 32 << 10}
+	var stderr *LimitedBuffer
+	if c.Cmd.Stderr == nil {
+		// keep the buffer, since the prepares may wrap Stderr
+		stderr = &LimitedBuffer{N: 32 << 10}
+		c.Cmd.Stderr = stderr
 	}
 
 	err := c.Run()
-	if err != nil && captureErr {
+	if err != nil && stderr != nil {
 		if ee, ok := err.(*exec.ExitError); ok {
-			ee.Stderr = c.Cmd.Stderr.(*prefixSuffixSaver).Bytes()
+			ee.Stderr = c.normalizeOutput(stderr.Bytes())
 		}
 	}
-	return stdout.Bytes(), err
+	return err
 }
 
 // CombinedOutput runs the command and returns its combined standard
 // output and standard error.
 func (c *Command) CombinedOutput() ([]byte, error) {
+	b := c.newCapture()
+	defer closeCapture(b)
+	err := c.combinedOutput(b)
+	return c.normalizeOutput(b.Bytes()), err
+}
+
+// combinedOutput runs the command with both stdout and stderr written into w
+func (c *Command) combinedOutput(w io.Writer) error {
 	defer c.cleanup()
 	if c.LastError != nil {
-		return nil, c.LastError
+		return c.LastError
 	}
 
 	if c.Cmd.Stdout != nil {
-		return nil, errors.New("exec: Stdout already set")
+		return errors.New("exec: Stdout already set")
 	}
 	if c.Cmd.Stderr != nil {
-		return nil, errors.New("exec: Stderr already set")
+		return errors.New("exec: Stderr already set")
 	}
-	var b bytes.Buffer
-	c.Cmd.Stdout = &b
-	c.Cmd.Stderr = &b
-	err := c.Run()
-	return b.Bytes(), err
+	c.Cmd.Stdout = w
+	c.Cmd.Stderr = w
+	return c.Run()
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -21,13 +22,18 @@ func (c *Command) initCmd(cmd *exec.Cmd) func(*Command) {
 	killChild := func(c *Command) {
 		c.mu.RLock()
 		pid := c.Pid
+		scope := c.killScope
+		detached := c.detached
 		c.mu.RUnlock()
-		if pid == 0 || c.Ctx.Err() == nil {
+		policy := c.getKillPolicy()
+		// the process group is killed after the steps of policy, and never
+		// killed with ChildOnly or StartDetached
+		if pid == 0 || detached || c.Ctx.Err() == nil || len(policy) > 0 || scope == ChildOnly {
 			return
 		}
 		// Kill by negative PID to kill the process group, which includes
 		// the top-level process we spawned as well as any subprocesses
-		// it spawned.
+		// it spawned, see KillScope.
 		err := syscall.Kill(-pid, syscall.SIGKILL)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "kill:", err)
@@ -36,7 +42,8 @@ func (c *Command) initCmd(cmd *exec.Cmd) func(*Command) {
 	return killChild
 }
 
-// AsUser run command with osuser
+// AsUser run command with osuser, the uid, primary gid and supplementary groups
+// of osuser are set, and HOME, USER, LOGNAME, SHELL env are populated.
 func (c *Command) AsUser(osuser string) *Command {
 	if runtime.GOOS == "windows" {
 		c.LastError = fmt.Errorf("AsUesr: not support windows yet")
@@ -52,21 +59,167 @@ func (c *Command) AsUser(osuser string) *Command {
 		c.LastError = fmt.Errorf("AsUesr: %w", err)
 		return c
 	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		c.LastError = fmt.Errorf("AsUesr: %w", err)
+		return c
+	}
+	groups := make([]uint32, 0)
+	if ids, err := u.GroupIds(); err == nil {
+		for _, v := range ids {
+			if g, err := strconv.ParseUint(v, 10, 32); err == nil {
+				groups = append(groups, uint32(g))
+			}
+		}
+	}
 	c.Cmd.SysProcAttr.Credential = &syscall.Credential{
-		Uid: uint32(uid),
+		Uid:    uint32(uid),
+		Gid:    uint32(gid),
+		Groups: groups,
 	}
-	hasHome := false
 	envs := c.Cmd.Env
+	// fix user env
+	for _, kv := range [][2]string{
+		{"HOME", u.HomeDir},
+		{"USER", u.Username},
+		{"LOGNAME", u.Username},
+		{"SHELL", lookupShell(u.Username)},
+	} {
+		envs = replaceEnv(envs, kv[0], kv[1])
+	}
+	c.Cmd.Env = envs
+	c.setHome(u.HomeDir)
+	return c
+}
+
+// replaceEnv set key=value in envs, append it if not exists
+func replaceEnv(envs []string, key, value string) []string {
 	for i, v := range envs {
-		// fix user HOME env
-		if strings.HasPrefix(v, "HOME=") {
-			envs[i] = "HOME=" + u.HomeDir
-			hasHome = true
+		if strings.HasPrefix(v, key+"=") {
+			envs[i] = key + "=" + value
+			return envs
+		}
+	}
+	return append(envs, key+"="+value)
+}
+
+// lookupShell returns the login shell of username from /etc/passwd,
+// or /bin/sh if not found.
+func lookupShell(username string) string {
+	b, err := os.ReadFile("/etc/passwd")
+	if err != nil {
+		return "/bin/sh"
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) >= 7 && fields[0] == username && fields[6] != "" {
+			return fields[6]
 		}
 	}
-	if !hasHome {
-		envs = append(envs, "HOME="+u.HomeDir)
+	return "/bin/sh"
+}
+
+// loginShell returns the shell to run the login of shell by `-l -c`, the shells
+// not taking the sh script, like fish, csh and tcsh, are replaced by sh, which
+// loads the ~/.profile instead of their own profiles.
+func loginShell(shell string) string {
+	switch filepath.Base(shell) {
+	case "fish", "csh", "tcsh":
+		return "/bin/sh"
+	}
+	return shell
+}
+
+// AsGroup run command with the primary group of group name or numeric gid,
+// the uid is kept as current user if not set by AsUser or AsUID.
+func (c *Command) AsGroup(group string) *Command {
+	id := group
+	if g, err := user.LookupGroup(group); err == nil {
+		id = g.Gid
+	}
+	gid, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.LastError = fmt.Errorf("AsGroup: unknown group %q", group)
+		return c
+	}
+	if c.Cmd.SysProcAttr.Credential == nil {
+		c.Cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid:         uint32(os.Getuid()),
+			NoSetGroups: true,
+		}
+	}
+	c.Cmd.SysProcAttr.Credential.Gid = uint32(gid)
+	return c
+}
+
+// AsUID run command with numeric uid and gid, without looking up the user database,
+// the supplementary groups are dropped, and the env is not changed.
+func (c *Command) AsUID(uid, gid uint32) *Command {
+	c.Cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    uid,
+		Gid:    gid,
+		Groups: []uint32{},
 	}
-	c.Cmd.Env = envs
 	return c
 }
+
+// defaultLoginPath is the PATH of login env before the profile of user loaded
+const defaultLoginPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// AsUserLogin run command with osuser like [Command.AsUser], but through the login shell
+// of osuser, like `su - osuser -c`, thus the profile of osuser is loaded, and their PATH
+// is used. The env is reset to a login env, only TERM is kept from current env.
+//
+// If the login shell is fish, csh or tcsh, `sh -l` is used instead, thus the ~/.profile
+// is loaded instead of their own profiles.
+func (c *Command) AsUserLogin(osuser string) *Command {
+	if c.AsUser(osuser); c.LastError != nil {
+		return c
+	}
+	u, _ := user.Lookup(osuser)
+	shell := lookupShell(u.Username)
+	c.Cmd.Env = []string{
+		"HOME=" + u.HomeDir,
+		"USER=" + u.Username,
+		"LOGNAME=" + u.Username,
+		"SHELL=" + shell,
+		"PATH=" + defaultLoginPath,
+	}
+	if term, ok := os.LookupEnv("TERM"); ok {
+		c.Cmd.Env = append(c.Cmd.Env, "TERM="+term)
+	}
+	login := loginShell(shell)
+	return c.wrapArgs(func(c *Command) error {
+		c.Cmd.Args = append([]string{login, "-l", "-c", `exec "$0" "$@"`}, c.Cmd.Args...)
+		c.setPath(login)
+		return c.LastError
+	})
+}
+
+// chownToUser changes the owner of f to the user of [Command.AsUser] and friends
+// if set, thus the temp files readable only by the owner can be read by the command.
+func (c *Command) chownToUser(f *os.File) error {
+	cred := c.Cmd.SysProcAttr.Credential
+	if cred == nil || int(cred.Uid) == os.Geteuid() {
+		return nil
+	}
+	return f.Chown(int(cred.Uid), -1)
+}
+
+// credentialNames returns the user and group names of the credential set by
+// [Command.AsUser] and friends, or the numeric id if not found.
+func (c *Command) credentialNames() (string, string) {
+	cred := c.Cmd.SysProcAttr.Credential
+	if cred == nil {
+		return "", ""
+	}
+	uid := strconv.FormatUint(uint64(cred.Uid), 10)
+	gid := strconv.FormatUint(uint64(cred.Gid), 10)
+	if u, err := user.LookupId(uid); err == nil {
+		uid = u.Username
+	}
+	if g, err := user.LookupGroupId(gid); err == nil {
+		gid = g.Name
+	}
+	return uid, gid
+}
@@ -4,14 +4,195 @@
 package command
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
 	"strings"
+	"syscall"
 	"testing"
 )
 
 func TestShellAsUser(t *testing.T) {
 	cmd := NewSh(`whoami`).AsUser("nobody")
+	if os.Geteuid() == 0 {
+		b, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(b)) != "nobody" {
+			t.Fatal("AsUser failed", string(b))
+		}
+		return
+	}
 	err := cmd.Run()
 	if !strings.Contains(err.Error(), "operation not permitted") {
 		t.Fatal("AsUser failed", err)
 	}
 }
+
+func TestNewScriptTempFileAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("not root")
+	}
+	script := "x=" + strings.Repeat("a", 200<<10) + "\necho ${#x}"
+	out, err := NewScript(script).AsUser("nobody").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "204800\n" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestShellAsUserEnv(t *testing.T) {
+	cmd := NewSh(`whoami`).AsUser("nobody")
+	if cmd.LastError != nil {
+		t.Fatal(cmd.LastError)
+	}
+	env := strings.Join(cmd.Cmd.Env, "\n")
+	for _, v := range []string{"USER=nobody", "LOGNAME=nobody", "SHELL="} {
+		if !strings.Contains(env, v) {
+			t.Fatal("env should contain "+v, env)
+		}
+	}
+	if cmd.SysProcAttr.Credential.Gid == 0 && cmd.SysProcAttr.Credential.Uid != 0 {
+		t.Fatal("gid should be set")
+	}
+}
+
+func TestShellAsUID(t *testing.T) {
+	cmd := NewSh(`id -u; id -g`).AsUID(65534, 65534)
+	if os.Geteuid() != 0 {
+		if err := cmd.Run(); err == nil {
+			t.Fatal("should not be permitted for non-root")
+		}
+		return
+	}
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "65534\n65534\n" {
+		t.Fatal("uid and gid should be 65534", string(b))
+	}
+}
+
+func TestShellAsGroup(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("need root")
+	}
+	b, err := NewSh(`id -g`).AsGroup("65534").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "65534\n" {
+		t.Fatal("gid should be 65534", string(b))
+	}
+	if cmd := NewSh(`id -g`).AsGroup("no-such-group"); cmd.LastError == nil {
+		t.Fatal("should error for unknown group")
+	}
+}
+
+func TestLoginShell(t *testing.T) {
+	for shell, want := range map[string]string{
+		"/bin/bash":           "/bin/bash",
+		"/usr/bin/zsh":        "/usr/bin/zsh",
+		"/usr/bin/fish":       "/bin/sh",
+		"/bin/csh":            "/bin/sh",
+		"/usr/local/bin/tcsh": "/bin/sh",
+	} {
+		if got := loginShell(shell); got != want {
+			t.Errorf("loginShell(%q) = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+func TestShellAsUserLogin(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+	if shell := lookupShell(u.Username); !strings.HasSuffix(shell, "sh") {
+		t.Skip("no login shell for current user", shell)
+	}
+	b, err := NewSh(`echo $USER $HOME`).Env([]string{"USER=other"}).AsUserLogin(u.Username).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != u.Username+" "+u.HomeDir+"\n" {
+		t.Fatal("should run with login env", string(b))
+	}
+}
+
+func TestShellAsUserTilde(t *testing.T) {
+	u, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip(err)
+	}
+	cmd := NewSh(`echo %p`, "~/x").ExtendedVerbs().Shell("bash").AsUser("nobody")
+	if want := "echo " + Quote(u.HomeDir+"/x"); cmd.Args[2] != want {
+		t.Fatalf("got %q, want %q", cmd.Args[2], want)
+	}
+	if cmd.Args[0] != "bash" {
+		t.Fatal("the shell should be kept", cmd.Args)
+	}
+}
+
+func TestShellBeforeStart(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var args []string
+	out, err := NewSh(`echo a >&3`).UseSudo().BeforeStart(func(cmd *exec.Cmd) error {
+		args = cmd.Args
+		cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+		return nil
+	}).Output()
+	w.Close()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	b := make([]byte, 10)
+	n, _ := r.Read(b)
+	if string(b[:n]) != "a\n" {
+		t.Fatalf("got %q", b[:n])
+	}
+	if os.Geteuid() != 0 && args[0] != "sudo" {
+		t.Fatalf("should be called after the wrappers, got %v", args)
+	}
+
+	errAbort := errors.New("abort")
+	started := false
+	err = NewSh(`true`).OnStart(func(*Command) { started = true }).BeforeStart(func(cmd *exec.Cmd) error {
+		return errAbort
+	}).Run()
+	if err != errAbort || started {
+		t.Fatalf("got %v, started %v", err, started)
+	}
+}
+
+func TestShellProcAttr(t *testing.T) {
+	c := NewSh(`ps -o sid= -p $$`).ProcAttr(func(attr *syscall.SysProcAttr) {
+		attr.Setsid = true
+		attr.Setpgid = false
+	}).AsUID(uint32(os.Getuid()), uint32(os.Getgid()))
+	attr := c.Cmd.SysProcAttr
+	if !attr.Setsid || attr.Credential == nil || attr.Credential.Uid != uint32(os.Getuid()) {
+		t.Fatalf("got %+v", attr)
+	}
+	if os.Geteuid() != 0 {
+		// setting the credential needs root
+		return
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid := strings.TrimSpace(string(out)); sid != strconv.Itoa(c.Pid) {
+		t.Fatalf("got sid %s of %d", sid, c.Pid)
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -56,6 +57,25 @@ func TestReplaceShellString(t *testing.T) {
 	}
 }
 
+func TestNewMissingPart(t *testing.T) {
+	for _, v := range []struct {
+		format string
+		parts  []string
+	}{
+		{"echo %s", nil},
+		{"echo %s %s", []string{"a"}},
+		{"cat %F", nil},
+		{"ls %g", nil},
+		{"cd %p", nil},
+		{"cat <<EOF\n%s\nEOF\n", nil},
+	} {
+		c := NewSh(v.format, v.parts...).ExtendedVerbs()
+		if c.LastError == nil || !strings.Contains(c.LastError.Error(), "missing part") {
+			t.Errorf("got %v for %q", c.LastError, v.format)
+		}
+	}
+}
+
 func TestNewBash(t *testing.T) {
 	cmd := NewBash("echo")
 	if cmd.Args[0] != "bash" {
@@ -141,6 +161,17 @@ func TestShellCombinedOutput(t *testing.T) {
 	}
 }
 
+func TestShellQuiet(t *testing.T) {
+	err := NewSh(`echo abc; echo def >&2; exit 1`).Quiet().Run()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || string(ee.Stderr) != "def\n" {
+		t.Fatalf("got %v", err)
+	}
+	if err = NewSh(`echo abc`).Quiet().Run(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestShellUseSudo(t *testing.T) {
 	cmd := NewSh(`whoami`).UseSudo()
 	b, err := cmd.Output()
@@ -289,3 +320,86 @@ func TestShellTimeout(t *testing.T) {
 		t.Fatal("context failed")
 	}
 }
+
+func TestNewPlaceholderInArgument(t *testing.T) {
+	cmd := NewSh(`echo %s %s`, "%s", "abc")
+	if diff := cmp.Diff(cmd.Args, []string{"sh", "-c", `echo %s abc`}); diff != "" {
+		t.Fatal(diff, cmd.Args)
+	}
+}
+
+func TestNewEscapeSpecial(t *testing.T) {
+	b, err := NewSh(`for a in %s %s '%s' '%s'; do echo x$a; done; echo x%sx`, "", "#c", "it's", "", "a\nb").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(b), "x\nx#c\nxit's\nx\nxa\nbx\n"); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestNewComment(t *testing.T) {
+	cmd := NewSh("echo a # b %s\n  # c\necho d", "x\ny")
+	if diff := cmp.Diff(cmd.Args, []string{"sh", "-c", "echo a # b x y\n  # c\necho d"}); diff != "" {
+		t.Fatal(diff, cmd.Args)
+	}
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(b), "a\nd\n"); diff != "" {
+		t.Fatal(diff)
+	}
+	for _, s := range []string{"#x", "echo a ## x", "echo a\n  #x"} {
+		if args := NewSh(s).Args; args[2] != s {
+			t.Errorf("comment at the end should be kept: %q", args[2])
+		}
+	}
+}
+
+func TestNewEscapeBrokenVar(t *testing.T) {
+	b, err := NewSh(`echo %s`, "${A;echo injected} ${0 } ${0{} ${").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(b), "${A;echo injected} ${0 } ${0{} ${\n"); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func BenchmarkReplaceShellString(b *testing.B) {
+	tokens := map[string]*shlex.Token{
+		"normal": {TokenClass: shlex.UnknownRuneClass},
+		"double": {TokenClass: shlex.EscapingQuoteRuneClass},
+		"single": {TokenClass: shlex.NonEscapingQuoteRuneClass},
+	}
+	s := strings.Repeat(`a b;c$HOME${PATH}"*'日本`, 16)
+	for name, token := range tokens {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(s)))
+			for i := 0; i < b.N; i++ {
+				ReplaceShellString(s, token)
+			}
+		})
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	parts := []string{"a b", "$HOME/x y", "it's", strings.Repeat("z;", 64)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewSh(`echo %s "%s" '%s' x%sx | cat`, parts...)
+	}
+}
+
+func TestNewUnterminatedQuote(t *testing.T) {
+	err := NewSh(`echo %s; echo 'abc`, "x").Run()
+	if err == nil || err.Error() != "template: unterminated quote at offset 14" {
+		t.Fatal(err)
+	}
+	err = NewSh("cat <<EOF\n%s\nEOF\necho \"%s", "a", "b").Run()
+	if err == nil || err.Error() != "template: unterminated quote at offset 22" {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 )
 
@@ -12,8 +13,34 @@ func (c *Command) initCmd(cmd *exec.Cmd) func(*Command) {
 	return nil
 }
 
+func (c *Command) chownToUser(*os.File) error {
+	return nil
+}
+
+func (c *Command) credentialNames() (string, string) {
+	return "", ""
+}
+
 // AsUser run command with osuser
 func (c *Command) AsUser(osuser string) *Command {
 	c.LastError = fmt.Errorf("AsUesr: not support windows yet")
 	return c
 }
+
+// AsGroup run command with group
+func (c *Command) AsGroup(group string) *Command {
+	c.LastError = fmt.Errorf("AsGroup: not support windows yet")
+	return c
+}
+
+// AsUID run command with numeric uid and gid
+func (c *Command) AsUID(uid, gid uint32) *Command {
+	c.LastError = fmt.Errorf("AsUID: not support windows yet")
+	return c
+}
+
+// AsUserLogin run command with osuser through the login shell
+func (c *Command) AsUserLogin(osuser string) *Command {
+	c.LastError = fmt.Errorf("AsUserLogin: not support windows yet")
+	return c
+}
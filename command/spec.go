@@ -0,0 +1,135 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Spec is the serializable definition of a Command, to store the commands,
+// transmit them to agents, or log them structurally, see [Command.Spec].
+//
+// The marshaled JSON has the Secrets masked in Args, Env and Dir, and never
+// contains the Secrets themselves, thus it's safe to log, but a redacted Spec
+// can not be run anymore.
+type Spec struct {
+	// Args is the rendered command line, which is run as is
+	Args []string
+	// Env is the env of command, the env of current process if nil
+	Env []string
+	Dir string
+	// User is the user of [Command.AsUser]
+	User string
+	// Timeout is the duration of [Command.Timeout], 0 means no timeout
+	Timeout time.Duration
+	// Secrets are the secrets of [Command.Redact], which are never marshaled
+	Secrets []string
+	// Redacted reports whether the secrets are masked
+	Redacted bool
+}
+
+// specJSON is the JSON form of Spec
+type specJSON struct {
+	Args     []string `json:"args"`
+	Env      []string `json:"env,omitempty"`
+	Dir      string   `json:"dir,omitempty"`
+	User     string   `json:"user,omitempty"`
+	Timeout  string   `json:"timeout,omitempty"`
+	Redacted bool     `json:"redacted,omitempty"`
+}
+
+// Spec returns the definition of c, the wrappers applied when the command
+// starts, like [Command.UseSudo], are not included.
+func (c *Command) Spec() Spec {
+	c.mu.RLock()
+	s := Spec{
+		Args:    append([]string(nil), c.Cmd.Args...),
+		Dir:     c.Cmd.Dir,
+		Timeout: c.timeout,
+		Secrets: append([]string(nil), c.secrets...),
+	}
+	c.mu.RUnlock()
+	if c.Cmd.Env != nil {
+		s.Env = append([]string(nil), c.Cmd.Env...)
+	}
+	s.User, _ = c.credentialNames()
+	return s
+}
+
+// MarshalJSON implements [json.Marshaler], the Secrets are masked
+func (s Spec) MarshalJSON() ([]byte, error) {
+	redacted := s.Redacted
+	mask := func(v string) string {
+		for _, secret := range s.Secrets {
+			if secret != "" && strings.Contains(v, secret) {
+				v = strings.ReplaceAll(v, secret, redactMask)
+				redacted = true
+			}
+		}
+		return v
+	}
+	j := specJSON{Args: make([]string, len(s.Args)), Dir: mask(s.Dir), User: s.User}
+	for i, v := range s.Args {
+		j.Args[i] = mask(v)
+	}
+	if s.Env != nil {
+		j.Env = make([]string, len(s.Env))
+		for i, v := range s.Env {
+			j.Env[i] = mask(v)
+		}
+	}
+	if s.Timeout > 0 {
+		j.Timeout = s.Timeout.String()
+	}
+	j.Redacted = redacted
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], the timeout is parsed by [time.ParseDuration]
+func (s *Spec) UnmarshalJSON(b []byte) error {
+	var j specJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	var timeout time.Duration
+	if j.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(j.Timeout); err != nil {
+			return fmt.Errorf("Spec: invalid timeout: %w", err)
+		}
+	}
+	*s = Spec{Args: j.Args, Env: j.Env, Dir: j.Dir, User: j.User, Timeout: timeout, Redacted: j.Redacted}
+	return nil
+}
+
+// Command returns the Command of s, which runs the Args as is without substitution.
+func (s Spec) Command() (*Command, error) {
+	if len(s.Args) == 0 {
+		return nil, errors.New("Command: empty args")
+	}
+	if s.Redacted {
+		return nil, errors.New("Command: the spec is redacted")
+	}
+	c := New([]string{""})
+	c.Cmd.Args = append([]string(nil), s.Args...)
+	c.templates, c.rendered = nil, nil
+	if c.setPath(s.Args[0]); c.LastError != nil {
+		return nil, fmt.Errorf("Command: %w", c.LastError)
+	}
+	if s.Env != nil {
+		c.Env(append([]string(nil), s.Env...))
+	}
+	c.Dir(s.Dir).Redact(s.Secrets...)
+	if s.User != "" {
+		c.AsUser(s.User)
+	}
+	if s.Timeout > 0 {
+		c.Timeout(s.Timeout)
+	}
+	if c.LastError != nil {
+		return nil, fmt.Errorf("Command: %w", c.LastError)
+	}
+	return c, nil
+}
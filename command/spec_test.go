@@ -0,0 +1,72 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpec(t *testing.T) {
+	c := NewSh(`echo %s "$TOKEN"`, "it's").Env([]string{"TOKEN=s3cret"}).Dir("/").Timeout(time.Minute)
+	s := c.Spec()
+	want := Spec{
+		Args:    []string{"sh", "-c", `echo it\'s $TOKEN`},
+		Env:     []string{"TOKEN=s3cret"},
+		Dir:     "/",
+		Timeout: time.Minute,
+	}
+	if diff := cmp.Diff(want, s); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Spec
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+	c, err = got.Command()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "it's s3cret\n" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestSpecRedacted(t *testing.T) {
+	s := NewSh(`echo %s`, "s3cret").Redact("s3cret").Spec()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s3cret") || !strings.Contains(string(b), `"redacted":true`) {
+		t.Fatalf("the secret should be masked: %s", b)
+	}
+	// the spec keeps the secrets in memory
+	if _, err = s.Command(); err != nil {
+		t.Fatal(err)
+	}
+	var got Spec
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = got.Command(); err == nil {
+		t.Fatal("want error of redacted spec")
+	}
+	if err = json.Unmarshal([]byte(`{"args":["true"],"timeout":"1x"}`), &got); err == nil {
+		t.Fatal("want error of invalid timeout")
+	}
+}
@@ -0,0 +1,126 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// SpillBuffer is an io.Writer which keeps the written bytes in memory until
+// Threshold bytes, then moves them into a temp file created in Dir, and
+// writes the rest bytes into the file.
+//
+// Close must be called to remove the temp file.
+type SpillBuffer struct {
+	// Threshold is the max bytes kept in memory, 0 means never spill
+	Threshold int64
+	// Dir is the directory for the temp file, "" means [os.TempDir]
+	Dir string
+
+	mem  bytes.Buffer
+	file *os.File
+	size int64
+}
+
+// Write implements io.Writer
+func (w *SpillBuffer) Write(p []byte) (int, error) {
+	if w.file == nil && w.Threshold > 0 && w.size+int64(len(p)) > w.Threshold {
+		f, err := os.CreateTemp(w.Dir, "better-command-*")
+		if err != nil {
+			return 0, err
+		}
+		if _, err := f.Write(w.mem.Bytes()); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return 0, err
+		}
+		w.file = f
+		w.mem = bytes.Buffer{}
+	}
+	var n int
+	var err error
+	if w.file != nil {
+		n, err = w.file.Write(p)
+	} else {
+		n, err = w.mem.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt
+func (w *SpillBuffer) ReadAt(p []byte, off int64) (int, error) {
+	if w.file != nil {
+		return w.file.ReadAt(p, off)
+	}
+	return bytes.NewReader(w.mem.Bytes()).ReadAt(p, off)
+}
+
+// Size returns the total bytes written
+func (w *SpillBuffer) Size() int64 {
+	return w.size
+}
+
+// Spilled reports whether the bytes have been moved into temp file
+func (w *SpillBuffer) Spilled() bool {
+	return w.file != nil
+}
+
+// Reader returns a reader to read from the beginning of the buffer
+func (w *SpillBuffer) Reader() io.Reader {
+	return io.NewSectionReader(w, 0, w.size)
+}
+
+// Bytes read all the bytes into memory, it returns nil if read failed
+func (w *SpillBuffer) Bytes() []byte {
+	if w.file == nil {
+		return w.mem.Bytes()
+	}
+	b := make([]byte, w.size)
+	if _, err := w.file.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil
+	}
+	return b
+}
+
+// Close removes the temp file if any
+func (w *SpillBuffer) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	f := w.file
+	w.file = nil
+	w.size = 0
+	err := f.Close()
+	if e := os.Remove(f.Name()); err == nil {
+		err = e
+	}
+	return err
+}
+
+// SpillToDisk make captured output to be written into temp file in dir
+// when exceeds threshold bytes, see [SpillBuffer].
+//
+// Use [Command.OutputSpill] or [Command.CombinedOutputSpill] to read the
+// output without loading into memory.
+func (c *Command) SpillToDisk(threshold int64, dir string) *Command {
+	c.spillThreshold = threshold
+	c.spillDir = dir
+	return c
+}
+
+// OutputSpill runs the command like [Command.Output], but returns the
+// standard output as [SpillBuffer], the caller should Close it after use.
+func (c *Command) OutputSpill() (*SpillBuffer, error) {
+	w := &SpillBuffer{Threshold: c.spillThreshold, Dir: c.spillDir}
+	err := c.output(w)
+	return w, err
+}
+
+// CombinedOutputSpill runs the command like [Command.CombinedOutput], but
+// returns the combined output as [SpillBuffer], the caller should Close it after use.
+func (c *Command) CombinedOutputSpill() (*SpillBuffer, error) {
+	w := &SpillBuffer{Threshold: c.spillThreshold, Dir: c.spillDir}
+	err := c.combinedOutput(w)
+	return w, err
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestSpillToDisk(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewSh(`printf 0123456789`).SpillToDisk(4, dir).OutputSpill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Spilled() || w.Size() != 10 {
+		t.Fatal("output should be spilled", w.Size())
+	}
+	b := make([]byte, 3)
+	if _, err := w.ReadAt(b, 5); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "567" {
+		t.Fatal("ReadAt should be 567", string(b))
+	}
+	all, _ := io.ReadAll(w.Reader())
+	if string(all) != "0123456789" {
+		t.Fatal("Reader should read all", string(all))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Fatal("temp file should be removed")
+	}
+
+	out, err := NewSh(`printf 0123456789`).SpillToDisk(4, dir).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "0123456789" {
+		t.Fatal("CombinedOutput should read spilled output", string(out))
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Fatal("temp file should be removed")
+	}
+}
+
+func TestSpillBufferInMemory(t *testing.T) {
+	w, err := NewSh(`printf abc`).OutputSpill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if w.Spilled() || string(w.Bytes()) != "abc" {
+		t.Fatal("output should be kept in memory")
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrStartTimeout is returned when the command doesn't output in the duration
+// of [Command.StartTimeout]
+var ErrStartTimeout = errors.New("no output before start timeout")
+
+// StartTimeout limits the duration of the process creation plus the first byte of
+// stdout or stderr, independent of [Command.Timeout], then the command is terminated
+// like canceled, and Wait returns [ErrStartTimeout].
+//
+// The stdout and stderr are passed through a pipe to detect the output, even if
+// they're files. The process creation can't be interrupted if it hangs in the
+// kernel, the error is returned after it's done.
+func (c *Command) StartTimeout(d time.Duration) *Command {
+	if d <= 0 {
+		c.LastError = fmt.Errorf("StartTimeout: invalid duration %v", d)
+		return c
+	}
+	return c.prepare(func(c *Command) error {
+		var once sync.Once
+		t := c.getClock().AfterFunc(d, func() {
+			once.Do(func() {
+				c.cancelWithCause(fmt.Errorf("StartTimeout: %w in %v", ErrStartTimeout, d))
+			})
+		})
+		started := func() {
+			once.Do(func() { t.Stop() })
+		}
+		stdout := &firstByteWriter{w: c.Cmd.Stdout, f: started}
+		if sameWriter(c.Cmd.Stdout, c.Cmd.Stderr) {
+			// keep them same, thus exec writes them in one goroutine
+			c.Cmd.Stdout, c.Cmd.Stderr = stdout, stdout
+		} else {
+			c.Cmd.Stdout = stdout
+			c.Cmd.Stderr = &firstByteWriter{w: c.Cmd.Stderr, f: started}
+		}
+		c.OnExit(func(*Command) { started() })
+		return nil
+	})
+}
+
+// firstByteWriter calls f before writing to w, w is discarded if nil
+type firstByteWriter struct {
+	w io.Writer
+	f func()
+}
+
+func (w *firstByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.f()
+	}
+	if w.w == nil {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+// sameWriter reports whether a and b are the same writer like [exec.Cmd] does,
+// the writers of CombinedOutput.
+func sameWriter(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	defer func() { recover() }()
+	return a == b
+}
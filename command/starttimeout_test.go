@@ -0,0 +1,35 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStartTimeout(t *testing.T) {
+	start := time.Now()
+	err := NewSh(`sleep 3; echo hi`).StartTimeout(100 * time.Millisecond).Run()
+	if !errors.Is(err, ErrStartTimeout) {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("took %v", d)
+	}
+
+	out, err := NewSh(`echo hi >&2; sleep 0.3; echo done`).StartTimeout(100 * time.Millisecond).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "done\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	// the combined output is written in order
+	out, err = NewSh(`for i in 1 2 3; do echo $i; echo e$i >&2; done`).StartTimeout(time.Second).CombinedOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "1\ne1\n2\ne2\n3\ne3\n" {
+		t.Fatalf("got %q", out)
+	}
+}
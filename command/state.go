@@ -0,0 +1,84 @@
+package command
+
+import "syscall"
+
+// Done returns a channel that's closed when the command is done, that's after
+// [Command.Wait] returns and the OnExit functions are called, or after
+// [Command.Spawn] fails, thus it can be selected with other channels.
+func (c *Command) Done() <-chan struct{} {
+	return c.done
+}
+
+// Running reports whether the command is started and not done yet.
+func (c *Command) Running() bool {
+	c.mu.RLock()
+	started := !c.startTime.IsZero()
+	c.mu.RUnlock()
+	if !started {
+		return false
+	}
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// ExitCode returns the exit code of the exited command, it's -1 if the command
+// is terminated by a signal, ok is false if the command is not done yet.
+func (c *Command) ExitCode() (code int, ok bool) {
+	select {
+	case <-c.done:
+	default:
+		return 0, false
+	}
+	if c.ProcessState == nil {
+		return 0, false
+	}
+	return c.ProcessState.ExitCode(), true
+}
+
+// TermSignal returns the signal terminated the exited command, like SIGKILL
+// of the OOM killer or SIGSEGV, ok is false if the command is not done yet, or
+// it's not terminated by a signal.
+func (c *Command) TermSignal() (sig syscall.Signal, ok bool) {
+	select {
+	case <-c.done:
+	default:
+		return 0, false
+	}
+	return c.termSignal()
+}
+
+// termSignal returns the signal terminated the process of ProcessState
+func (c *Command) termSignal() (syscall.Signal, bool) {
+	if c.ProcessState == nil {
+		return 0, false
+	}
+	ws, ok := c.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return 0, false
+	}
+	return ws.Signal(), true
+}
+
+// OnSignal set f to run when the command exited since it's terminated by a signal,
+// after Wait is done, see [Command.TermSignal].
+func (c *Command) OnSignal(f func(sig syscall.Signal)) *Command {
+	c.mu.Lock()
+	c.onsignal = append(c.onsignal, f)
+	c.mu.Unlock()
+	return c
+}
+
+// markDone closes the done channel if not closed
+func (c *Command) markDone() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
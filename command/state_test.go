@@ -0,0 +1,49 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDone(t *testing.T) {
+	c := New([]string{"sh", "-c", "sleep 0.1; exit 3"})
+	if c.Running() {
+		t.Fatal("running before spawn")
+	}
+	if _, ok := c.ExitCode(); ok {
+		t.Fatal("exit code before spawn")
+	}
+	if err := c.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Running() {
+		t.Fatal("not running after spawn")
+	}
+	go c.Wait()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("not done")
+	}
+	if c.Running() {
+		t.Fatal("running after done")
+	}
+	if code, ok := c.ExitCode(); !ok || code != 3 {
+		t.Fatalf("got exit code %d %v, want 3", code, ok)
+	}
+}
+
+func TestDoneSpawnError(t *testing.T) {
+	c := New([]string{"not-exists-command-xyz"})
+	if err := c.Spawn(); err == nil {
+		t.Fatal("want error")
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("not done after spawn error")
+	}
+	if _, ok := c.ExitCode(); ok {
+		t.Fatal("exit code without process")
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import "time"
+
+// Stats is the execution statistics of a command
+type Stats struct {
+	// WallTime is the elapsed real time from start to exit
+	WallTime time.Duration
+	// UserTime is the user CPU time of the exited process and its children
+	UserTime time.Duration
+	// SystemTime is the system CPU time of the exited process and its children
+	SystemTime time.Duration
+	// MaxRSS is the maximum resident set size in bytes, 0 if not supported
+	MaxRSS int64
+	// MinorFaults is the page faults serviced without any I/O, 0 if not supported
+	MinorFaults int64
+	// MajorFaults is the page faults serviced that required I/O, 0 if not supported
+	MajorFaults int64
+}
+
+// Stats returns the execution statistics after the command exit,
+// it returns the zero Stats if the command is not exited.
+func (c *Command) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.ProcessState == nil {
+		return Stats{}
+	}
+	s := Stats{
+		WallTime:   c.exitTime.Sub(c.startTime),
+		UserTime:   c.ProcessState.UserTime(),
+		SystemTime: c.ProcessState.SystemTime(),
+	}
+	setRusage(&s, c.ProcessState.SysUsage())
+	return s
+}
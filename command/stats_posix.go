@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"runtime"
+	"syscall"
+)
+
+func setRusage(s *Stats, sysUsage interface{}) {
+	ru, ok := sysUsage.(*syscall.Rusage)
+	if !ok || ru == nil {
+		return
+	}
+	// ru_maxrss is in kilobytes except on darwin
+	s.MaxRSS = int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" {
+		s.MaxRSS *= 1024
+	}
+	s.MinorFaults = int64(ru.Minflt)
+	s.MajorFaults = int64(ru.Majflt)
+}
@@ -0,0 +1,23 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	cmd := NewSh(`sleep 0.1`)
+	if cmd.Stats() != (Stats{}) {
+		t.Fatal("stats should be zero before run")
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	s := cmd.Stats()
+	if s.WallTime < time.Millisecond*100 {
+		t.Fatal("wall time should be at least 100ms", s.WallTime)
+	}
+	if s.MaxRSS <= 0 {
+		t.Fatal("max rss should be recorded", s.MaxRSS)
+	}
+}
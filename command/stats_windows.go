@@ -0,0 +1,7 @@
+//go:build windows
+// +build windows
+
+package command
+
+func setRusage(s *Stats, sysUsage interface{}) {
+}
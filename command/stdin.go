@@ -0,0 +1,154 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StdinString set command stdin to s, if parts provided, the %s in s will be
+// replaced by parts and safely escaped, same as the cmdString of [NewSh].
+func (c *Command) StdinString(s string, parts ...string) *Command {
+	if len(parts) > 0 {
+		var err error
+		if s, err = substitute(s, parts, c.dialect, nil); err != nil {
+			c.LastError = fmt.Errorf("StdinString: %w", err)
+			return c
+		}
+	}
+	c.Cmd.Stdin = strings.NewReader(s)
+	return c
+}
+
+// Escaper escapes a part substituted into the stdin by [Command.StdinTemplate].
+type Escaper func(string) string
+
+// EscapeNone keeps the part as is, for the trusted parts or the stdin without syntax.
+func EscapeNone(s string) string { return s }
+
+// EscapeShell quotes the part as a single literal word of POSIX shells by [Quote],
+// it's the default Escaper of [Command.StdinTemplate].
+func EscapeShell(s string) string { return Quote(s) }
+
+// StdinTemplate set command stdin to tmpl with each %s replaced by parts in order,
+// escaped by the Escaper of [Command.StdinEscape], which is [EscapeShell] by default.
+// The %% is replaced by %, and the other % are kept as is.
+// Unlike [Command.StdinString], tmpl is not parsed as a shell script, so it can be
+// any data, like SQL with the parts quoted by a custom Escaper:
+//
+//	NewSh(`psql`).StdinEscape(func(s string) string {
+//		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+//	}).StdinTemplate(`SELECT * FROM users WHERE name = %s;`, name)
+func (c *Command) StdinTemplate(tmpl string, parts ...string) *Command {
+	if n := strings.Count(strings.ReplaceAll(tmpl, "%%", ""), "%s"); n != len(parts) {
+		c.LastError = fmt.Errorf("StdinTemplate: %d placeholders but %d parts", n, len(parts))
+		return c
+	}
+	r := &stdinTemplate{}
+	c.Cmd.Stdin = r
+	return c.prepare(func(c *Command) error {
+		// render with the Escaper set at last, unless the stdin is replaced
+		if c.Cmd.Stdin == io.Reader(r) {
+			r.Reader = strings.NewReader(renderStdin(tmpl, parts, c.stdinEscape))
+		}
+		return nil
+	})
+}
+
+// StdinEscape set the Escaper of the parts of [Command.StdinTemplate],
+// nil resets it to [EscapeShell].
+func (c *Command) StdinEscape(esc Escaper) *Command {
+	c.stdinEscape = esc
+	return c
+}
+
+// stdinTemplate is the stdin of [Command.StdinTemplate], rendered before start
+type stdinTemplate struct {
+	io.Reader
+}
+
+// renderStdin replace each %s in tmpl with the parts escaped by esc, and %% with %.
+func renderStdin(tmpl string, parts []string, esc Escaper) string {
+	if esc == nil {
+		esc = EscapeShell
+	}
+	var b strings.Builder
+	i := 0
+	for k := 0; k < len(tmpl); k++ {
+		if tmpl[k] == '%' && k+1 < len(tmpl) {
+			switch tmpl[k+1] {
+			case 's':
+				b.WriteString(esc(parts[i]))
+				i++
+				k++
+				continue
+			case '%':
+				b.WriteByte('%')
+				k++
+				continue
+			}
+		}
+		b.WriteByte(tmpl[k])
+	}
+	return b.String()
+}
+
+// StdinBytes set command stdin to b
+func (c *Command) StdinBytes(b []byte) *Command {
+	c.Cmd.Stdin = bytes.NewReader(b)
+	return c
+}
+
+// StdinFile set command stdin to the file of path, the file will be closed
+// when command exit, LastError will be set if failed to open the file.
+func (c *Command) StdinFile(path string) *Command {
+	f, err := os.Open(path)
+	if err != nil {
+		c.LastError = fmt.Errorf("StdinFile: %w", err)
+		return c
+	}
+	c.Cmd.Stdin = f
+	return c.OnExit(func(*Command) { f.Close() })
+}
+
+// NoStdin set command stdin to [os.DevNull] explicitly, the command will read EOF immediately.
+func (c *Command) NoStdin() *Command {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		c.LastError = fmt.Errorf("NoStdin: %w", err)
+		return c
+	}
+	c.Cmd.Stdin = f
+	return c.OnExit(func(*Command) { f.Close() })
+}
+
+// InteractiveStdin set command stdin to [os.Stdin], pass through the terminal of parent process.
+func (c *Command) InteractiveStdin() *Command {
+	c.Cmd.Stdin = os.Stdin
+	return c
+}
+
+// StdinWriter returns a writer that will be connected to the command stdin
+// when the command starts, closing the writer makes the command read EOF.
+// Unlike [exec.Cmd.StdinPipe], it is always closed when the command exit,
+// thus the command never hangs reading stdin.
+func (c *Command) StdinWriter() (io.WriteCloser, error) {
+	if c.Cmd.Stdin != nil {
+		return nil, errors.New("exec: Stdin already set")
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	c.Cmd.Stdin = pr
+	// the read end belongs to the child after start
+	c.OnStart(func(*Command) { pr.Close() })
+	c.OnExit(func(*Command) {
+		pr.Close()
+		pw.Close()
+	})
+	return pw, nil
+}
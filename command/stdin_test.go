@@ -0,0 +1,118 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdinString(t *testing.T) {
+	b, err := NewSh(`cat`).StdinString("abc").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Fatal("stdin should be abc", string(b))
+	}
+	b, err = NewSh(`cat`).StdinString("echo %s", "a;b").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `echo a\;b` {
+		t.Fatal("stdin should be escaped", string(b))
+	}
+}
+
+func TestStdinTemplate(t *testing.T) {
+	b, err := NewSh(`cat`).StdinTemplate("echo %s 100%% %d", "a'b;c").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `echo 'a'\''b;c' 100% %d` {
+		t.Fatal("stdin should be quoted", string(b))
+	}
+	b, err = NewSh(`sh`).StdinTemplate("printf '%%s' %s", "$HOME;exit 1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "$HOME;exit 1" {
+		t.Fatal("stdin should be escaped for shell", string(b))
+	}
+
+	sql := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	// the Escaper set after the template is used
+	b, err = NewSh(`cat`).StdinTemplate("WHERE name = %s;", "o'brien").StdinEscape(sql).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "WHERE name = 'o''brien';" {
+		t.Fatal("stdin should be escaped for sql", string(b))
+	}
+	b, err = NewSh(`cat`).StdinEscape(EscapeNone).StdinTemplate("%s", "a b").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a b" {
+		t.Fatal("stdin should be kept", string(b))
+	}
+
+	if c := NewSh(`cat`).StdinTemplate("%s %%s", "a", "b"); c.LastError == nil {
+		t.Fatal("want error of too many parts")
+	}
+}
+
+func TestStdinBytes(t *testing.T) {
+	b, err := NewSh(`cat`).StdinBytes([]byte("abc")).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Fatal("stdin should be abc", string(b))
+	}
+}
+
+func TestStdinFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "in.txt")
+	os.WriteFile(file, []byte("abc"), 0600)
+	b, err := NewSh(`cat`).StdinFile(file).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Fatal("stdin should be abc", string(b))
+	}
+	_, err = NewSh(`cat`).StdinFile(file + ".none").Output()
+	if err == nil {
+		t.Fatal("should error when file not exists")
+	}
+}
+
+func TestNoStdin(t *testing.T) {
+	b, err := NewSh(`cat; printf ok`).NoStdin().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ok" {
+		t.Fatal("stdin should be empty", string(b))
+	}
+}
+
+func TestStdinWriter(t *testing.T) {
+	cmd := NewSh(`cat`)
+	w, err := cmd.StdinWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.Write([]byte("abc"))
+		w.Close()
+	}()
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Fatal("stdin should be abc", string(b))
+	}
+}
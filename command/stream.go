@@ -0,0 +1,108 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Stream is the standard stream of a command
+type Stream int
+
+// Standard streams of a command
+const (
+	StreamStdin Stream = iota
+	StreamStdout
+	StreamStderr
+)
+
+func (s Stream) String() string {
+	switch s {
+	case StreamStdin:
+		return "stdin"
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	}
+	return "unknown"
+}
+
+// OutputChunk is a piece of output sent by [Command.StreamOutput]
+type OutputChunk struct {
+	// Stream is where the Data come from, StreamStdout or StreamStderr
+	Stream Stream
+	// Data is the bytes written by the command
+	Data []byte
+	// Time is when the Data be written
+	Time time.Time
+	// Done is true for the last chunk sent after the command exit, with Err
+	// set to the error returned by [Command.Wait]
+	Done bool
+	// Err is the exit error when Done
+	Err error
+}
+
+// chunkWriter send the written bytes into ch until done be closed
+type chunkWriter struct {
+	stream Stream
+	ch     chan<- OutputChunk
+	done   <-chan struct{}
+	now    func() time.Time
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	chunk := OutputChunk{Stream: w.stream, Data: append([]byte(nil), p...), Time: w.now()}
+	select {
+	case w.ch <- chunk:
+		return len(p), nil
+	case <-w.done:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+// StreamOutput starts the command and returns a channel receiving the output
+// chunks of stdout and stderr as they are written, the last chunk is Done with
+// the exit error, then the channel is closed.
+//
+// The returned stop function kills the command and stops the streaming,
+// it's safe to call it multiple times or after the command exit.
+func (c *Command) StreamOutput() (<-chan OutputChunk, func()) {
+	ch := make(chan OutputChunk)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			c.mu.RLock()
+			cancel := c.Cancel
+			c.mu.RUnlock()
+			if cancel != nil {
+				cancel()
+			}
+		})
+	}
+	send := func(err error) {
+		select {
+		case ch <- OutputChunk{Time: c.now(), Done: true, Err: err}:
+		case <-done:
+		}
+		close(ch)
+	}
+
+	if c.Cmd.Stdout != nil {
+		go send(errors.New("exec: Stdout already set"))
+		return ch, stop
+	}
+	if c.Cmd.Stderr != nil {
+		go send(errors.New("exec: Stderr already set"))
+		return ch, stop
+	}
+	c.Cmd.Stdout = &chunkWriter{stream: StreamStdout, ch: ch, done: done, now: c.now}
+	c.Cmd.Stderr = &chunkWriter{stream: StreamStderr, ch: ch, done: done, now: c.now}
+	go func() {
+		send(c.Run())
+	}()
+	return ch, stop
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamOutput(t *testing.T) {
+	ch, stop := NewSh(`printf abc; sleep 0.05; printf def 1>&2; exit 2`).StreamOutput()
+	defer stop()
+	var stdout, stderr string
+	var last OutputChunk
+	for chunk := range ch {
+		switch chunk.Stream {
+		case StreamStdout:
+			stdout += string(chunk.Data)
+		case StreamStderr:
+			stderr += string(chunk.Data)
+		}
+		last = chunk
+	}
+	if stdout != "abc" || stderr != "def" {
+		t.Fatal("output should be abc and def", stdout, stderr)
+	}
+	if !last.Done || last.Err == nil {
+		t.Fatal("last chunk should be done with exit error", last)
+	}
+}
+
+func TestStreamOutputStop(t *testing.T) {
+	ch, stop := NewSh(`while true; do printf a; sleep 0.01; done`).StreamOutput()
+	start := time.Now()
+	<-ch
+	stop()
+	for range ch {
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("stop should kill the command")
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// StrictShell prepends `set -euo pipefail;` to the script of [NewSh] or
+// [NewBash] when start, thus the script exits on the first failed statement.
+// The pipefail is omitted for sh and dash, since it's not POSIX.
+func (c *Command) StrictShell() *Command {
+	return c.prepare(func(c *Command) error {
+		i := c.scriptIndex()
+		if i < 2 {
+			return errors.New("StrictShell: no shell script found")
+		}
+		c.Cmd.Args[i] = strictPrefix(c.Cmd.Args[i-2]) + c.Cmd.Args[i]
+		return nil
+	})
+}
+
+// strictPrefix returns the strict mode statement for shell
+func strictPrefix(shell string) string {
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "sh", "dash", "ash":
+		return "set -eu; "
+	}
+	return "set -euo pipefail; "
+}
@@ -0,0 +1,21 @@
+package command
+
+import "testing"
+
+func TestStrictShell(t *testing.T) {
+	b, err := NewBash(`false | true; printf ok`).StrictShell().Output()
+	if err == nil {
+		t.Fatal("pipefail should fail", string(b))
+	}
+	b, err = NewSh(`false; printf ok`).StrictShell().Output()
+	if err == nil || string(b) != "" {
+		t.Fatal("sh should exit on failed statement", string(b))
+	}
+	cmd := NewSh(`printf $UNSET_VAR_FOR_TEST`).StrictShell()
+	if err := cmd.Run(); err == nil {
+		t.Fatal("unset variable should fail")
+	}
+	if cmd.Args[2] != "set -eu; printf $UNSET_VAR_FOR_TEST" {
+		t.Fatal("script should be prefixed", cmd.Args[2])
+	}
+}
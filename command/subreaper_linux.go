@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// prSetChildSubreaper is PR_SET_CHILD_SUBREAPER of prctl(2)
+const prSetChildSubreaper = 36
+
+var (
+	subreaperOnce sync.Once
+	subreaperErr  error
+)
+
+// Subreaper makes the current process the child subreaper by prctl(2), thus the
+// orphaned descendants of command are re-parented to the current process instead
+// of init, like the helpers double-forked by a shell. When the command exits, the
+// re-parented processes in the process group of command are reaped after they're
+// killed as usual, instead of being left as zombies, they're also reaped when
+// they exit later if not killed, like with [ChildOnly] of [Command.KillScope].
+//
+// It's process wide and kept enabled once set, the descendants escaping the process
+// group by setsid(2) are re-parented but not reaped. It's only supported on Linux.
+func (c *Command) Subreaper() *Command {
+	subreaperOnce.Do(func() {
+		if _, _, e := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); e != 0 {
+			subreaperErr = e
+		}
+	})
+	if subreaperErr != nil {
+		c.LastError = fmt.Errorf("Subreaper: %w", subreaperErr)
+		return c
+	}
+	return c.onWait(func(c *Command) {
+		c.mu.RLock()
+		pid := c.Pid
+		c.mu.RUnlock()
+		reapOrphans(pid)
+	})
+}
+
+// reapOrphans waits for the re-parented processes in the process group pgid in
+// background, the leader pgid is waited by exec.
+func reapOrphans(pgid int) {
+	if pgid == 0 {
+		return
+	}
+	stats, err := listProcStats()
+	if err != nil {
+		return
+	}
+	self := os.Getpid()
+	for _, st := range stats {
+		if st.Ppid != self || st.Pgrp != pgid || st.Pid == pgid {
+			continue
+		}
+		go func(pid int) {
+			var ws syscall.WaitStatus
+			for {
+				if _, err := syscall.Wait4(pid, &ws, 0, nil); err != syscall.EINTR {
+					return
+				}
+			}
+		}(st.Pid)
+	}
+}
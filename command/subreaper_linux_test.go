@@ -0,0 +1,41 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubreaper(t *testing.T) {
+	if os.Getenv("BETTER_COMMAND_SUBREAPER_HELPER") == "" {
+		// it's process wide, so run in another process
+		cmd := exec.Command(os.Args[0], "-test.run=TestSubreaper$", "-test.v")
+		cmd.Env = append(os.Environ(), "BETTER_COMMAND_SUBREAPER_HELPER=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatal(err, string(out))
+		}
+		return
+	}
+	out, err := NewSh(`sh -c 'sleep 0.2 >/dev/null 2>&1 & echo $!'`).KillScope(ChildOnly).Subreaper().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	st, err := readProcStat(pid)
+	if err != nil || st.Ppid != os.Getpid() {
+		t.Fatalf("should be re-parented, got %+v, %v", st, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := readProcStat(pid); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("not reaped")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
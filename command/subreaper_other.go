@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package command
+
+import "fmt"
+
+// Subreaper makes the current process the child subreaper by prctl(2), it's
+// only supported on Linux.
+func (c *Command) Subreaper() *Command {
+	c.LastError = fmt.Errorf("Subreaper: only supported on Linux")
+	return c
+}
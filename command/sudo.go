@@ -0,0 +1,203 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// sudoOptions is the options of sudo and other privilege escalation backends
+type sudoOptions struct {
+	backend     string
+	path        string
+	user        string
+	group       string
+	preserveEnv bool
+	envList     []string
+	nonInteract bool
+	password    string
+}
+
+// newSudoOptions returns the options of backend with opts applied,
+// all env is preserved if no opts given.
+func newSudoOptions(backend string, opts []SudoOption) *sudoOptions {
+	o := &sudoOptions{backend: backend}
+	if len(opts) == 0 {
+		o.preserveEnv = true
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// needed reports whether the escalation is needed, it's not needed for root
+// unless a target user or group is set.
+func (o *sudoOptions) needed() bool {
+	currentUser, _ := user.Current()
+	if currentUser == nil {
+		return false
+	}
+	return currentUser.Uid != "0" || o.user != "" || o.group != ""
+}
+
+// args returns the backend command with flags
+func (o *sudoOptions) args() ([]string, error) {
+	path := o.path
+	if path == "" {
+		path = o.backend
+	}
+	args := []string{path}
+	switch o.backend {
+	case "sudo":
+		if o.nonInteract {
+			args = append(args, "-n")
+		}
+		if o.password != "" {
+			args = append(args, "-A")
+		}
+		if o.user != "" {
+			args = append(args, "-u", o.user)
+		}
+		if o.group != "" {
+			args = append(args, "-g", o.group)
+		}
+		if len(o.envList) > 0 {
+			args = append(args, "--preserve-env="+strings.Join(o.envList, ","))
+		} else if o.preserveEnv {
+			args = append(args, "-E")
+		}
+		return args, nil
+	case "run0":
+		if o.nonInteract {
+			args = append(args, "--no-ask-password")
+		}
+		if o.user != "" {
+			args = append(args, "--user="+o.user)
+		}
+		if o.group != "" {
+			args = append(args, "--group="+o.group)
+		}
+		for _, v := range o.envList {
+			args = append(args, "--setenv="+v)
+		}
+		if o.password != "" {
+			return nil, errors.New("run0: password not supported")
+		}
+		return args, nil
+	case "doas", "pkexec":
+		if o.backend == "doas" && o.nonInteract {
+			args = append(args, "-n")
+		}
+		if o.user != "" {
+			if o.backend == "doas" {
+				args = append(args, "-u", o.user)
+			} else {
+				args = append(args, "--user", o.user)
+			}
+		}
+		if o.group != "" {
+			return nil, fmt.Errorf("%s: group not supported", o.backend)
+		}
+		if o.password != "" {
+			return nil, fmt.Errorf("%s: password not supported", o.backend)
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("unknown privilege escalation backend: %s", o.backend)
+}
+
+// elevate run command with the privilege escalation backend of o
+func (c *Command) elevate(o *sudoOptions) *Command {
+	if !o.needed() {
+		return c
+	}
+	args, err := o.args()
+	if err != nil {
+		c.LastError = err
+		return c
+	}
+	c.Cmd.Args = append(args, c.Cmd.Args...)
+	c.setPath(args[0])
+	c.sudoPassword(o)
+	return c
+}
+
+// SudoOption is the option of [Command.UseSudo]
+type SudoOption func(*sudoOptions)
+
+// SudoUser run command as user, by `sudo -u user`
+func SudoUser(user string) SudoOption {
+	return func(o *sudoOptions) { o.user = user }
+}
+
+// SudoGroup run command with primary group, by `sudo -g group`
+func SudoGroup(group string) SudoOption {
+	return func(o *sudoOptions) { o.group = group }
+}
+
+// SudoPreserveEnv preserve the env names by `sudo --preserve-env=LIST`,
+// or all env by `sudo -E` if no names given.
+func SudoPreserveEnv(names ...string) SudoOption {
+	return func(o *sudoOptions) {
+		o.preserveEnv = true
+		o.envList = append(o.envList, names...)
+	}
+}
+
+// SudoNonInteractive make sudo fail instead of prompting password, by `sudo -n`
+func SudoNonInteractive() SudoOption {
+	return func(o *sudoOptions) { o.nonInteract = true }
+}
+
+// SudoPath use the sudo binary of path instead of "sudo"
+func SudoPath(path string) SudoOption {
+	return func(o *sudoOptions) { o.path = path }
+}
+
+// SudoPassword supply the password to sudo, it's the same as [SudoAskpass], the
+// password never appears in argv, and it's redacted, see [Command.Redact].
+//
+// It's not written to the stdin by `sudo -S`, since sudo doesn't read it when
+// no password is needed, like a cached credential or NOPASSWD, then the command
+// would read the password as its input.
+func SudoPassword(password string) SudoOption {
+	return SudoAskpass(password)
+}
+
+// SudoAskpass supply the password to sudo via a generated SUDO_ASKPASS helper by
+// `sudo -A`, the stdin of command is untouched. The helper and the password are
+// stored in a private temp dir, which is removed when the command exit.
+func SudoAskpass(password string) SudoOption {
+	return func(o *sudoOptions) { o.password = password }
+}
+
+// askpassScript is the SUDO_ASKPASS helper, which prints the password file in the same dir
+const askpassScript = "#!/bin/sh\ncat \"$(dirname \"$0\")/password\"\n"
+
+// sudoPassword supply the password of o to sudo
+func (c *Command) sudoPassword(o *sudoOptions) {
+	if o.password == "" {
+		return
+	}
+	c.Redact(o.password)
+	dir, err := os.MkdirTemp("", "better-command-askpass-*")
+	if err != nil {
+		c.LastError = fmt.Errorf("UseSudo: %w", err)
+		return
+	}
+	c.OnExit(func(*Command) { os.RemoveAll(dir) })
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte(o.password+"\n"), 0600); err != nil {
+		c.LastError = fmt.Errorf("UseSudo: %w", err)
+		return
+	}
+	helper := filepath.Join(dir, "askpass")
+	if err := os.WriteFile(helper, []byte(askpassScript), 0700); err != nil {
+		c.LastError = fmt.Errorf("UseSudo: %w", err)
+		return
+	}
+	c.setEnv("SUDO_ASKPASS", helper)
+}
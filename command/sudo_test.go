@@ -0,0 +1,57 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSudoOptions(t *testing.T) {
+	args, _ := newSudoOptions("sudo", []SudoOption{SudoUser("nobody"), SudoGroup("nogroup"), SudoPreserveEnv("PATH", "HOME"), SudoNonInteractive(), SudoPath("/usr/bin/sudo")}).args()
+	if diff := cmp.Diff(args, []string{"/usr/bin/sudo", "-n", "-u", "nobody", "-g", "nogroup", "--preserve-env=PATH,HOME"}); diff != "" {
+		t.Fatal(diff)
+	}
+	args, _ = newSudoOptions("sudo", nil).args()
+	if diff := cmp.Diff(args, []string{"sudo", "-E"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSudoPassword(t *testing.T) {
+	args, _ := newSudoOptions("sudo", []SudoOption{SudoPassword("secret")}).args()
+	if diff := cmp.Diff(args, []string{"sudo", "-A"}); diff != "" {
+		t.Fatal(diff)
+	}
+	// the password never reaches the stdin of command, even if sudo doesn't prompt
+	o := newSudoOptions("sudo", []SudoOption{SudoPassword("secret")})
+	cmd := NewSh(`cat`).StdinString("abc")
+	cmd.sudoPassword(o)
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" {
+		t.Fatal("password should not be written to stdin", string(b))
+	}
+	if cmd.Redacted("pass: secret") != "pass: ******" {
+		t.Fatal("password should be redacted")
+	}
+}
+
+func TestSudoAskpass(t *testing.T) {
+	cmd := NewSh(`"$SUDO_ASKPASS"`)
+	cmd.sudoPassword(&sudoOptions{password: "secret"})
+	b, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "secret\n" {
+		t.Fatal("askpass should print the password", string(b))
+	}
+	for _, v := range cmd.Args {
+		if strings.Contains(v, "secret") {
+			t.Fatal("password should not be in args")
+		}
+	}
+}
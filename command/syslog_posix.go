@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// syslogWriter sends every line as a syslog message
+type syslogWriter struct {
+	lineWriter
+	w *syslog.Writer
+}
+
+func (w *syslogWriter) Close() error {
+	err := w.flush()
+	if e := w.w.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// SyslogWriter returns a writer sending every written line to the system logger
+// with the tag, the priority is the syslog severity (0 emerg to 7 debug) of the
+// user facility, default to 6 info, like 3 err for stderr.
+//
+// It can be set to Stdout/Stderr of the command, close it after the command
+// exit to send the last line without line ending.
+func SyslogWriter(tag string, priority ...int) (io.WriteCloser, error) {
+	p := int(syslog.LOG_INFO)
+	if len(priority) > 0 {
+		p = priority[0]
+	}
+	if p < 0 || p > 7 {
+		return nil, fmt.Errorf("SyslogWriter: invalid priority %d", p)
+	}
+	sw, err := syslog.New(syslog.Priority(p)|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, fmt.Errorf("SyslogWriter: %w", err)
+	}
+	w := &syslogWriter{w: sw}
+	w.emit = func(line string) error {
+		_, err := sw.Write([]byte(line))
+		return err
+	}
+	return w, nil
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"io"
+)
+
+// SyslogWriter returns a writer sending every written line to the system logger
+func SyslogWriter(tag string, priority ...int) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("SyslogWriter: not support windows yet")
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"strconv"
+	"time"
+)
+
+// SystemdRunOptions is the options of [Command.TransientUnit]
+type SystemdRunOptions struct {
+	// Unit is the name of transient unit, systemd generates one if empty
+	Unit string
+	// Scope runs the command in a transient scope unit by `--scope`, the command
+	// is still the child process, otherwise it runs as a transient service, with
+	// the stdio piped and exit code passed back by `--wait --pipe`.
+	Scope bool
+	// User talks to the service manager of the calling user by `--user`
+	User bool
+	// Slice puts the unit in the slice
+	Slice string
+	// MemoryMax is the MemoryMax property, like "512M"
+	MemoryMax string
+	// CPUQuota is the CPUQuota property, like "50%"
+	CPUQuota string
+	// RuntimeMax is the RuntimeMaxSec property
+	RuntimeMax time.Duration
+	// Properties are extra properties, like "TasksMax=10"
+	Properties []string
+}
+
+// args returns the systemd-run command with flags, the env and dir are passed
+// to the transient service, since it's not the child of current process.
+func (o SystemdRunOptions) args(env []string, dir string) []string {
+	args := []string{"systemd-run", "--quiet", "--collect"}
+	if o.User {
+		args = append(args, "--user")
+	}
+	if o.Scope {
+		args = append(args, "--scope")
+	} else {
+		args = append(args, "--wait", "--pipe")
+		for _, v := range env {
+			args = append(args, "--setenv="+v)
+		}
+		if dir != "" {
+			args = append(args, "--working-directory="+dir)
+		}
+	}
+	if o.Unit != "" {
+		args = append(args, "--unit="+o.Unit)
+	}
+	if o.Slice != "" {
+		args = append(args, "--slice="+o.Slice)
+	}
+	props := make([]string, 0, len(o.Properties)+3)
+	if o.MemoryMax != "" {
+		props = append(props, "MemoryMax="+o.MemoryMax)
+	}
+	if o.CPUQuota != "" {
+		props = append(props, "CPUQuota="+o.CPUQuota)
+	}
+	if o.RuntimeMax > 0 {
+		props = append(props, "RuntimeMaxSec="+strconv.FormatFloat(o.RuntimeMax.Seconds(), 'f', -1, 64))
+	}
+	props = append(props, o.Properties...)
+	for _, v := range props {
+		args = append(args, "--property="+v)
+	}
+	return append(args, "--")
+}
+
+// TransientUnit runs the command in a transient systemd unit by `systemd-run`, so the
+// resource limits and the cleanup are done by systemd, see [SystemdRunOptions].
+func (c *Command) TransientUnit(opts SystemdRunOptions) *Command {
+	return c.wrapArgs(func(c *Command) error {
+		args := opts.args(c.Cmd.Env, c.Cmd.Dir)
+		c.Cmd.Args = append(args, c.Cmd.Args...)
+		c.setPath(args[0])
+		return c.LastError
+	})
+}
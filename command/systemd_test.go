@@ -0,0 +1,30 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSystemdRunOptions(t *testing.T) {
+	args := SystemdRunOptions{
+		Unit:       "job",
+		MemoryMax:  "512M",
+		CPUQuota:   "50%",
+		RuntimeMax: time.Minute + time.Second/2,
+		Properties: []string{"TasksMax=10"},
+	}.args([]string{"A=1"}, "/tmp")
+	want := []string{
+		"systemd-run", "--quiet", "--collect", "--wait", "--pipe", "--setenv=A=1", "--working-directory=/tmp",
+		"--unit=job", "--property=MemoryMax=512M", "--property=CPUQuota=50%", "--property=RuntimeMaxSec=60.5",
+		"--property=TasksMax=10", "--",
+	}
+	if diff := cmp.Diff(args, want); diff != "" {
+		t.Fatal(diff)
+	}
+	args = SystemdRunOptions{Scope: true, User: true}.args([]string{"A=1"}, "/tmp")
+	if diff := cmp.Diff(args, []string{"systemd-run", "--quiet", "--collect", "--user", "--scope", "--"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
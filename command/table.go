@@ -0,0 +1,194 @@
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TableOptions is the format of the table parsed by [Command.OutputTable]
+type TableOptions struct {
+	// Comma is the delimiter of columns, like ',' for CSV or '\t' for TSV, which
+	// are parsed by [csv.Reader]. 0 means the columns are aligned by spaces, like
+	// the output of `df`, `docker ps` and `ps aux`.
+	Comma rune
+	// Header means the first row is the header of columns, which is excluded
+	// from the rows. The columns aligned by spaces are located by the header,
+	// thus the cells may contain spaces, like the `STATUS` of `docker ps`,
+	// otherwise the columns are always split by spaces.
+	Header bool
+	// Skip is the number of lines to skip before the table
+	Skip int
+}
+
+// OutputTable runs the command and parses its standard output as a table of opts,
+// the empty lines are skipped.
+func (c *Command) OutputTable(opts TableOptions) ([][]string, error) {
+	b, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	_, rows, err := parseTable(b, opts)
+	if err != nil {
+		return nil, fmt.Errorf("OutputTable: %w", err)
+	}
+	return rows, nil
+}
+
+// OutputRecords runs the command and parses its standard output as a table of
+// opts like [Command.OutputTable], then maps each row to a record by the
+// header, thus opts.Header is always true.
+func (c *Command) OutputRecords(opts TableOptions) ([]map[string]string, error) {
+	b, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	opts.Header = true
+	header, rows, err := parseTable(b, opts)
+	if err != nil {
+		return nil, fmt.Errorf("OutputRecords: %w", err)
+	}
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		m := make(map[string]string, len(header))
+		for j, name := range header {
+			if j < len(row) {
+				m[name] = row[j]
+			} else {
+				m[name] = ""
+			}
+		}
+		records[i] = m
+	}
+	return records, nil
+}
+
+// parseTable returns the header if opts.Header and the rows of table b
+func parseTable(b []byte, opts TableOptions) ([]string, [][]string, error) {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	lines := strings.Split(string(b), "\n")
+	if opts.Skip > len(lines) {
+		opts.Skip = len(lines)
+	}
+	lines = lines[opts.Skip:]
+
+	var rows [][]string
+	switch {
+	case opts.Comma != 0:
+		r := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+		r.Comma = opts.Comma
+		// the quotes are rare in TSV, so keep them as is
+		r.LazyQuotes = opts.Comma != ','
+		var err error
+		if rows, err = r.ReadAll(); err != nil {
+			return nil, nil, err
+		}
+	case opts.Header:
+		rows = splitAligned(lines)
+	default:
+		for _, line := range lines {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				rows = append(rows, fields)
+			}
+		}
+	}
+	if !opts.Header {
+		return nil, rows, nil
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("no header")
+	}
+	return rows[0], rows[1:], nil
+}
+
+// splitAligned splits the columns aligned by spaces, the first non-empty line is
+// the header. The columns are separated by the positions which are spaces in all
+// lines, then each column without header is merged into the previous one, like the
+// arguments of `COMMAND`, so is the header word without data after a single space,
+// like `Mounted on` of `df`.
+func splitAligned(lines []string) [][]string {
+	var table [][]rune
+	width := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		r := []rune(strings.TrimRight(line, " \t"))
+		table = append(table, r)
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	if len(table) == 0 {
+		return nil
+	}
+	isBlank := func(r []rune, pos int) bool {
+		return pos >= len(r) || r[pos] == ' ' || r[pos] == '\t'
+	}
+	hasText := func(r []rune, start, end int) bool {
+		for pos := start; pos < end; pos++ {
+			if !isBlank(r, pos) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// columns are the [start, end) of non-blank positions in all lines
+	var columns [][2]int
+	for pos := 0; pos < width; pos++ {
+		blank := true
+		for _, r := range table {
+			if !isBlank(r, pos) {
+				blank = false
+				break
+			}
+		}
+		switch {
+		case blank:
+		case len(columns) > 0 && columns[len(columns)-1][1] == pos:
+			columns[len(columns)-1][1] = pos + 1
+		default:
+			columns = append(columns, [2]int{pos, pos + 1})
+		}
+	}
+	merged := columns[:0]
+	for _, col := range columns {
+		header := hasText(table[0], col[0], col[1])
+		data := false
+		for _, r := range table[1:] {
+			if hasText(r, col[0], col[1]) {
+				data = true
+				break
+			}
+		}
+		// the header of a single space like `Mounted on` is kept as one column,
+		// but the empty column is kept, like the `PORTS` of `docker ps`
+		if len(merged) > 0 && (!header || (!data && col[0]-merged[len(merged)-1][1] == 1)) {
+			merged[len(merged)-1][1] = col[1]
+			continue
+		}
+		merged = append(merged, col)
+	}
+	// the last column takes the rest of lines
+	merged[len(merged)-1][1] = width
+
+	rows := make([][]string, len(table))
+	for i, r := range table {
+		row := make([]string, len(merged))
+		for j, col := range merged {
+			start, end := col[0], col[1]
+			if start > len(r) {
+				start = len(r)
+			}
+			if end > len(r) {
+				end = len(r)
+			}
+			row[j] = strings.TrimSpace(string(r[start:end]))
+		}
+		rows[i] = row
+	}
+	return rows
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTable(t *testing.T) {
+	for _, v := range []struct {
+		name   string
+		in     string
+		opts   TableOptions
+		header []string
+		rows   [][]string
+	}{
+		{
+			"df",
+			"Filesystem     1K-blocks    Used Available Use% Mounted on\n" +
+				"/dev/sda1       20509264 5033332  14410972  26% /\n" +
+				"tmpfs             816036       0    816036   0% /run/user/0\n",
+			TableOptions{Header: true},
+			[]string{"Filesystem", "1K-blocks", "Used", "Available", "Use%", "Mounted on"},
+			[][]string{
+				{"/dev/sda1", "20509264", "5033332", "14410972", "26%", "/"},
+				{"tmpfs", "816036", "0", "816036", "0%", "/run/user/0"},
+			},
+		},
+		{
+			"docker ps",
+			"CONTAINER ID   IMAGE     STATUS         PORTS     NAMES\n" +
+				"4c01db0b339c   nginx     Up 2 hours               web\n" +
+				"d7886598dbe2   redis     Up 5 minutes             cache\n",
+			TableOptions{Header: true},
+			[]string{"CONTAINER ID", "IMAGE", "STATUS", "PORTS", "NAMES"},
+			[][]string{
+				{"4c01db0b339c", "nginx", "Up 2 hours", "", "web"},
+				{"d7886598dbe2", "redis", "Up 5 minutes", "", "cache"},
+			},
+		},
+		{
+			"ps",
+			"  PID TTY      CMD\n" +
+				"    1 ?        init splash\n" +
+				"  123 pts/0    sleep 10\n",
+			TableOptions{Header: true},
+			[]string{"PID", "TTY", "CMD"},
+			[][]string{{"1", "?", "init splash"}, {"123", "pts/0", "sleep 10"}},
+		},
+		{
+			"fields",
+			"skipped\na  b\n\nc d e\n",
+			TableOptions{Skip: 1},
+			nil,
+			[][]string{{"a", "b"}, {"c", "d", "e"}},
+		},
+		{
+			"tsv",
+			"name\tsize\na \"b\"\t1\n",
+			TableOptions{Comma: '\t', Header: true},
+			[]string{"name", "size"},
+			[][]string{{`a "b"`, "1"}},
+		},
+	} {
+		header, rows, err := parseTable([]byte(v.in), v.opts)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if diff := cmp.Diff(v.header, header); diff != "" {
+			t.Errorf("%s header (-want +got):\n%s", v.name, diff)
+		}
+		if diff := cmp.Diff(v.rows, rows); diff != "" {
+			t.Errorf("%s rows (-want +got):\n%s", v.name, diff)
+		}
+	}
+}
+
+func TestOutputRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("name,size\n\"a,b\",1\nc,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	records, err := NewSh(`cat %s`, path).OutputRecords(TableOptions{Comma: ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"name": "a,b", "size": "1"}, {"name": "c", "size": "2"}}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Fatalf("records (-want +got):\n%s", diff)
+	}
+	rows, err := NewSh(`cat %s`, path).OutputTable(TableOptions{Comma: ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "name" {
+		t.Fatalf("got %q", rows)
+	}
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// tempDirEnv is the env of the dir created by [Command.TempDir]
+const tempDirEnv = "BC_TMPDIR"
+
+// TempDir creates a fresh temp dir by [os.MkdirTemp] with pattern, and runs the
+// command in it, the dir is also set to the env BC_TMPDIR, thus the scratch files
+// of command never collide, and it's removed with all its content on exit. The dir
+// is created immediately, thus the input files can be written into [Command.WorkDir]
+// before the command runs.
+//
+// Use [Command.KeepTempDirOnFailure] to keep the dir for debugging if the command fails.
+func (c *Command) TempDir(pattern string) *Command {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		c.LastError = fmt.Errorf("TempDir: %w", err)
+		return c
+	}
+	c.Cmd.Dir = dir
+	c.OnExit(func(c *Command) {
+		c.mu.RLock()
+		keep := c.keepTempDir
+		c.mu.RUnlock()
+		if keep && (c.ProcessState == nil || !c.ProcessState.Success()) {
+			c.debugf("keep temp dir %s", dir)
+			return
+		}
+		os.RemoveAll(dir)
+	})
+	return c.prepare(func(c *Command) error {
+		c.setEnv(tempDirEnv, dir)
+		return nil
+	})
+}
+
+// KeepTempDirOnFailure keeps the dir of [Command.TempDir] if the command failed to
+// start or exited unsuccessfully, thus its content can be inspected.
+func (c *Command) KeepTempDirOnFailure() *Command {
+	c.mu.Lock()
+	c.keepTempDir = true
+	c.mu.Unlock()
+	return c
+}
+
+// WorkDir returns the working dir of command, like the one created by
+// [Command.TempDir], it's empty if the command runs in the current dir.
+func (c *Command) WorkDir() string {
+	return c.Cmd.Dir
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTempDir(t *testing.T) {
+	c := NewSh(`pwd; echo $BC_TMPDIR; cat in`).TempDir("bc-test-*")
+	dir := c.WorkDir()
+	if !strings.HasPrefix(filepath.Base(dir), "bc-test-") {
+		t.Fatalf("got dir %q", dir)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "in"), []byte("abc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	real, _ := filepath.EvalSymlinks(dir)
+	if lines := strings.Split(string(b), "\n"); len(lines) != 4 ||
+		(lines[0] != dir && lines[0] != real) || lines[1] != dir || lines[2] != "abc" {
+		t.Fatalf("got %q", b)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("dir not removed: %v", err)
+	}
+
+	// kept on failure
+	c = NewSh(`touch out; exit 1`).TempDir("bc-test-*").KeepTempDirOnFailure()
+	dir = c.WorkDir()
+	defer os.RemoveAll(dir)
+	if err := c.Run(); err == nil {
+		t.Fatal("want error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); err != nil {
+		t.Fatal(err)
+	}
+
+	// removed on success even if kept on failure
+	c = NewSh(`true`).TempDir("bc-test-*").KeepTempDirOnFailure()
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.WorkDir()); !os.IsNotExist(err) {
+		t.Fatalf("dir not removed: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// fileArg is the content of a %F placeholder, written to path before start
+type fileArg struct {
+	path    string
+	content string
+}
+
+// tempFile returns a random path in the temp dir for the content of %F, the
+// file is created exclusively by [Command.writeFiles] so it can't be taken over.
+func (r *render) tempFile(content string) (string, error) {
+	if r.nonce == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("%%F: %w", err)
+		}
+		r.nonce = hex.EncodeToString(b)
+	}
+	name := "better-command-" + r.nonce + "-" + strconv.Itoa(len(r.files))
+	path := filepath.Join(os.TempDir(), name)
+	r.files = append(r.files, fileArg{path: path, content: content})
+	return path, nil
+}
+
+// writeFiles writes the content of %F placeholders to their temp files, readable
+// only by the current user, and removes them on exit.
+func (c *Command) writeFiles() error {
+	c.mu.RLock()
+	files := c.render.files
+	c.mu.RUnlock()
+	for _, v := range files {
+		path := v.path
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("%%F: %w", err)
+		}
+		c.OnExit(func(*Command) { os.Remove(path) })
+		_, err = f.WriteString(v.content)
+		if e := f.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			return fmt.Errorf("%%F: %w", err)
+		}
+	}
+	return nil
+}
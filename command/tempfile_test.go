@@ -0,0 +1,52 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFilePlaceholder(t *testing.T) {
+	c := NewSh(`cat %F; echo; echo %s --path=%F`, "secret\ncontent", "x", "y").ExtendedVerbs()
+	path := c.Args[2][strings.LastIndex(c.Args[2], "--path=")+len("--path="):]
+	if strings.Contains(c.Args[2], "secret") {
+		t.Fatal("the content should not be on the command line", c.Args)
+	}
+	b, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(b), "\n")
+	if len(lines) != 4 || lines[0] != "secret" || lines[1] != "content" || lines[2] != "x --path="+path {
+		t.Fatalf("%q", b)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("the temp file should be removed", err)
+	}
+}
+
+func TestNewFilePlaceholderLiteral(t *testing.T) {
+	b, err := NewSh(`echo +%F %s`, "a").ExtendedVerbs().Output()
+	if err != nil || string(b) != "+%F a\n" {
+		t.Fatal(string(b), err)
+	}
+	// %F is kept as is without ExtendedVerbs, like the baseline
+	for _, v := range []struct {
+		cmd  *Command
+		want []string
+	}{
+		{New([]string{"stat", "-c", "%F", "/"}), []string{"stat", "-c", "%F", "/"}},
+		{New([]string{"stat", "--format=%F", "/"}), []string{"stat", "--format=%F", "/"}},
+		{New([]string{"stat", "-c", "%F", "%s"}, "/"), []string{"stat", "-c", "%F", "/"}},
+		{NewSh(`stat -c %F %s`, "/"), []string{"sh", "-c", "stat -c %F /"}},
+	} {
+		if v.cmd.LastError != nil {
+			t.Error(v.cmd.LastError)
+		}
+		if diff := cmp.Diff(v.cmd.Args, v.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
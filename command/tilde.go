@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// expandPath expands the leading `~` or `~user` of the path p of a %p
+// placeholder, then escapes it like '%s'.
+func (r *render) expandPath(p string, d dialect) (string, error) {
+	p, err := expandHome(p, r.home)
+	if err != nil {
+		return "", fmt.Errorf("%%p: %w", err)
+	}
+	if p == "" {
+		return "''", nil
+	}
+	b := make([]byte, 0, len(p)*2)
+	for i := 0; i < len(p); {
+		b, i = appendEscaped(b, p, i, d)
+	}
+	return string(b), nil
+}
+
+// expandHome expands the leading `~` of p to home, or to the home dir of the
+// current user if home is empty, and `~user` to the home dir of user.
+func expandHome(p, home string) (string, error) {
+	if !strings.HasPrefix(p, "~") {
+		return p, nil
+	}
+	name, rest := p[1:], ""
+	if i := strings.IndexAny(name, "/"+string(os.PathSeparator)); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	if name != "" {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.HomeDir + rest, nil
+	}
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", err
+		}
+	}
+	return home + rest, nil
+}
+
+// setHome sets the home dir for the `~` of %p, and renders the templates
+// again if they have %p.
+func (c *Command) setHome(home string) {
+	c.render.home = home
+	for _, v := range c.templates {
+		if strings.Contains(v, "%p") {
+			c.rerender()
+			return
+		}
+	}
+}
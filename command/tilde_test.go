@@ -0,0 +1,50 @@
+package command
+
+import (
+	"os/user"
+	"testing"
+)
+
+func TestExpandHome(t *testing.T) {
+	tests := map[string]string{
+		"~":     "/home/a",
+		"~/x/y": "/home/a/x/y",
+		"a~":    "a~",
+		"/~":    "/~",
+		"":      "",
+	}
+	for p, want := range tests {
+		got, err := expandHome(p, "/home/a")
+		if err != nil || got != want {
+			t.Errorf("expandHome(%q) = %q, %v, want %q", p, got, err, want)
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		got, err := expandHome("~"+u.Username+"/x", "/home/a")
+		if err != nil || got != u.HomeDir+"/x" {
+			t.Errorf("got %q, %v, want %q", got, err, u.HomeDir+"/x")
+		}
+	}
+	if _, err := expandHome("~no-such-user-here", ""); err == nil {
+		t.Error("want error for unknown user")
+	}
+}
+
+func TestNewPath(t *testing.T) {
+	t.Setenv("HOME", "/tmp/home dir")
+	b, err := NewSh(`echo %p --dir=%p '%p' 100%p %s`, "~/a $b", "~", "x").ExtendedVerbs().Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/tmp/home dir/a $b --dir=/tmp/home dir %p 100%p x\n"; string(b) != want {
+		t.Fatalf("got %q, want %q", b, want)
+	}
+	// %p is kept as is without ExtendedVerbs, like the baseline
+	b, err = New([]string{"find", "/", "-maxdepth", "0", "-printf", "%p"}).Output()
+	if err != nil || string(b) != "/" {
+		t.Fatal(string(b), err)
+	}
+	if c := NewSh(`find %s -printf %p`, "a b"); c.LastError != nil || c.Args[2] != `find a\ b -printf %p` {
+		t.Fatalf("got %q, %v", c.Args, c.LastError)
+	}
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Trace enables `set -x` for the script of [NewSh] or [NewBash], and writes
+// the trace into w.
+//
+// For bash the trace is written to a dedicated fd via BASH_XTRACEFD, thus
+// the stderr of the command is untouched, other shells don't support it,
+// so the trace is still written to stderr.
+func (c *Command) Trace(w io.Writer) *Command {
+	return c.prepare(func(c *Command) error {
+		i := c.scriptIndex()
+		if i < 2 {
+			return errors.New("Trace: no shell script found")
+		}
+		c.Cmd.Args[i] = "set -x; " + c.Cmd.Args[i]
+		if strings.TrimSuffix(filepath.Base(c.Cmd.Args[i-2]), ".exe") != "bash" {
+			return nil
+		}
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		c.Cmd.ExtraFiles = append(c.Cmd.ExtraFiles, pw)
+		c.setEnv("BASH_XTRACEFD", strconv.Itoa(2+len(c.Cmd.ExtraFiles)))
+		done := make(chan struct{})
+		go func() {
+			io.Copy(w, pr)
+			pr.Close()
+			close(done)
+		}()
+		c.OnStart(func(*Command) { pw.Close() })
+		c.OnExit(func(*Command) {
+			pw.Close()
+			<-done
+		})
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	trace := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	b, err := NewBash(`printf abc; printf def 1>&2`).Trace(trace).Stderr(stderr).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "abc" || stderr.String() != "def" {
+		t.Fatal("output should not be polluted", string(b), stderr.String())
+	}
+	if !strings.Contains(trace.String(), "+ printf abc") {
+		t.Fatal("trace should be written", trace.String())
+	}
+}
+
+func TestTraceSh(t *testing.T) {
+	trace := new(bytes.Buffer)
+	_, err := NewSh(`printf abc; exit 1`).Trace(trace).Output()
+	if ee, ok := err.(*exec.ExitError); !ok || !strings.Contains(string(ee.Stderr), "printf abc") {
+		t.Fatal("trace should be written to stderr for sh", err)
+	}
+}
@@ -0,0 +1,133 @@
+package command
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Transcript is the time-ordered record of the streams of a command by
+// [Command.Transcript]
+type Transcript []Chunk
+
+// String formats the transcript as the lines prefixed by the time and stream,
+// like `2006-01-02T15:04:05.000000Z07:00 stdout | text`, for the incident reports.
+func (t Transcript) String() string {
+	var b strings.Builder
+	for _, v := range t {
+		prefix := v.Time.Format("2006-01-02T15:04:05.000000Z07:00") + " " + v.Stream.String() + " | "
+		lines := strings.SplitAfter(string(v.Data), "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			b.WriteString(prefix)
+			b.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				b.WriteByte('\n')
+			}
+		}
+	}
+	return b.String()
+}
+
+// mergeReader records the data read from r as the stream of mergeBuffer
+type mergeReader struct {
+	b      *mergeBuffer
+	r      io.Reader
+	stream Stream
+}
+
+func (r *mergeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.b.add(r.stream, p[:n])
+	}
+	return n, err
+}
+
+// Transcript runs the command and returns the record of its stdout and stderr
+// with the timestamps, in the order of [Command.MergedOutput], along with the stdin
+// read by the command if it's set by the methods like [Command.StdinTemplate] and
+// [Command.StdinString], the files like [os.Stdin] are not recorded. The secrets of
+// [Command.Redact] are masked, thus it's suitable to be attached to the incident
+// reports when a maintenance command misbehaves. The secrets split across the
+// chunks of a stream are moved into the chunk where they end to be masked.
+func (c *Command) Transcript() (Transcript, error) {
+	b := &mergeBuffer{now: c.now}
+	// after the prepares setting the stdin, like StdinTemplate renders it
+	c.prepare(func(c *Command) error {
+		if _, ok := c.Cmd.Stdin.(*os.File); !ok && c.Cmd.Stdin != nil {
+			c.Cmd.Stdin = &mergeReader{b, c.Cmd.Stdin, StreamStdin}
+		}
+		return nil
+	})
+	err := c.mergedOutput(b)
+	return Transcript(c.redactChunks(b.chunks)), err
+}
+
+// redactChunks masks the secrets of [Command.Redact] in chunks, the secrets
+// across the chunks of a stream are moved into the chunk where they end, the
+// chunks left empty are removed.
+func (c *Command) redactChunks(chunks []Chunk) []Chunk {
+	c.mu.RLock()
+	secrets := append([]string(nil), c.secrets...)
+	c.mu.RUnlock()
+	if len(secrets) == 0 {
+		return chunks
+	}
+	streams := map[Stream][]int{}
+	for i, v := range chunks {
+		streams[v.Stream] = append(streams[v.Stream], i)
+	}
+	for _, list := range streams {
+		var b strings.Builder
+		cuts := make([]int, len(list))
+		for k, i := range list {
+			b.Write(chunks[i].Data)
+			cuts[k] = b.Len()
+		}
+		s := b.String()
+		moveCuts(s, secrets, cuts)
+		start := 0
+		for k, i := range list {
+			chunks[i].Data = []byte(s[start:cuts[k]])
+			start = cuts[k]
+		}
+	}
+	out := chunks[:0]
+	for _, v := range chunks {
+		if len(v.Data) == 0 {
+			continue
+		}
+		if s := c.Redacted(string(v.Data)); s != string(v.Data) {
+			v.Data = []byte(s)
+		}
+		v.Seq = len(out)
+		out = append(out, v)
+	}
+	return out
+}
+
+// moveCuts moves the cuts of s inside the secrets back to the start of them
+func moveCuts(s string, secrets []string, cuts []int) {
+	for moved := true; moved; {
+		moved = false
+		for _, v := range secrets {
+			for i := 0; ; {
+				n := strings.Index(s[i:], v)
+				if n < 0 {
+					break
+				}
+				start, end := i+n, i+n+len(v)
+				for k, cut := range cuts {
+					if cut > start && cut < end {
+						cuts[k] = start
+						moved = true
+					}
+				}
+				i = end
+			}
+		}
+	}
+}
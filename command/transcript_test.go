@@ -0,0 +1,42 @@
+package command
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	tr, err := NewSh(`read a; echo "got $a"; sleep 0.05; echo oops >&2; exit 1`).
+		StdinTemplate("%s\n", "hunter2").StdinEscape(EscapeNone).Redact("hunter2").Transcript()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	var got []string
+	for _, v := range tr {
+		got = append(got, v.Stream.String()+":"+string(v.Data))
+	}
+	if s := strings.Join(got, ","); s != "stdin:******\n,stdout:got ******\n,stderr:oops\n" {
+		t.Fatalf("got %q", s)
+	}
+	re := regexp.MustCompile(`^(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ (stdin|stdout|stderr) \| .*\n){3}$`)
+	if s := tr.String(); !re.MatchString(s) || !strings.Contains(s, "stderr | oops\n") {
+		t.Fatalf("got %q", s)
+	}
+	// the secret written in two halves
+	tr, err = NewSh(`printf 'a hun'; sleep 0.05; printf 'ter2 b\n'; sleep 0.05; echo hunter2 >&2`).
+		Redact("hunter2").Transcript()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for i, v := range tr {
+		if v.Seq != i {
+			t.Fatalf("got %+v", v)
+		}
+		got = append(got, v.Stream.String()+":"+string(v.Data))
+	}
+	if s := strings.Join(got, ","); s != "stdout:a ,stdout:****** b\n,stderr:******\n" {
+		t.Fatalf("got %q", s)
+	}
+}
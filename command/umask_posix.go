@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// Umask runs the command with the file mode creation mask, like
+// `sh -c 'umask 0027 && exec "$0" "$@"' program args...`, since the umask
+// can't be set for the child only without affecting the whole process.
+//
+// The wrappers chained before it, like [Command.UseSudo], run inside and get
+// the mask, the ones chained after run outside.
+func (c *Command) Umask(mask os.FileMode) *Command {
+	if mask&^os.ModePerm != 0 {
+		c.LastError = fmt.Errorf("Umask: invalid mask %o", mask)
+		return c
+	}
+	return c.wrapArgs(func(c *Command) error {
+		script := fmt.Sprintf(`umask %04o && exec "$0" "$@"`, uint32(mask))
+		c.Cmd.Args = append([]string{"sh", "-c", script, c.Cmd.Path}, c.Cmd.Args[1:]...)
+		c.setPath("sh")
+		return c.LastError
+	})
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUmask(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	if err := New([]string{"touch", path}).Umask(0077).Run(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Fatalf("got %o, want 600", perm)
+	}
+
+	b, err := NewSh(`umask; echo "$@"`).Umask(0027).Output()
+	if err != nil || string(b) != "0027\n\n" {
+		t.Fatalf("%q %v", b, err)
+	}
+	if err := NewSh(`true`).Umask(os.ModeDir).Run(); err == nil {
+		t.Fatal("want invalid mask error")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// Umask runs the command with the file mode creation mask, it's not supported
+// on windows.
+func (c *Command) Umask(mask os.FileMode) *Command {
+	c.LastError = fmt.Errorf("Umask: not support windows yet")
+	return c
+}
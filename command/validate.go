@@ -0,0 +1,356 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the expansions found in a shell word
+const (
+	expandGlob = 1 << iota
+	expandSubst
+	expandTilde
+	expandBrace
+)
+
+// shellWord is a word or an operator of the shell script lexed by [lexShell]
+type shellWord struct {
+	// op is the operator like ";" and "|", or the newline, empty for words
+	op string
+	// value is the word with quotes removed, $VAR and ${VAR} are kept as is
+	value string
+	// expand is the expansions other than the variables in the word
+	expand int
+}
+
+// CountParts returns the number of parts used by the placeholders of the shell
+// script template, as [NewSh] substitutes them, thus the tools like linters can
+// supply the parts to check it.
+func CountParts(template string) (int, error) {
+	n, err := countParts([]string{template})
+	if err != nil {
+		return 0, fmt.Errorf("CountParts: %w", err)
+	}
+	return n, nil
+}
+
+// ValidateSafe renders the shell script template with parts by the rules of [NewSh],
+// then verifies by re-tokenizing the script that each part is kept literally in
+// the word it's substituted in, thus no part can change the structure of script,
+// like adding words, operators, command substitutions or globs.
+//
+// The $VAR and ${VAR} in the parts substituted to %s and "%s" are allowed,
+// since they are expanded by design. The %F, %g and %p are kept as is, like
+// [NewSh] without [Command.ExtendedVerbs].
+func ValidateSafe(template string, parts ...string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("ValidateSafe: not enough parts for %%s")
+		}
+	}()
+	if _, err := lexShell(template); err != nil {
+		return fmt.Errorf("ValidateSafe: template: %w", err)
+	}
+	got, err := substitute(template, parts, dialectPOSIX, nil)
+	if err != nil {
+		return fmt.Errorf("ValidateSafe: %w", err)
+	}
+	// render with the placeholder parts, which never need escaping
+	names := make([]string, len(parts))
+	for i := range parts {
+		names[i] = "BetterCommandPart" + strconv.Itoa(i) + "Z"
+	}
+	want, err := substitute(template, names, dialectPOSIX, nil)
+	if err != nil {
+		return fmt.Errorf("ValidateSafe: %w", err)
+	}
+	if strings.Contains(template, "BetterCommandPart") {
+		return fmt.Errorf("ValidateSafe: template contains the reserved word BetterCommandPart")
+	}
+
+	wantWords, err := lexShell(want)
+	if err != nil {
+		return fmt.Errorf("ValidateSafe: template: %w", err)
+	}
+	gotWords, err := lexShell(got)
+	if err != nil {
+		return fmt.Errorf("ValidateSafe: %w", err)
+	}
+	if len(gotWords) != len(wantWords) {
+		return fmt.Errorf("ValidateSafe: parts change the number of words from %d to %d", len(wantWords), len(gotWords))
+	}
+	for i, w := range wantWords {
+		g := gotWords[i]
+		if w.op != g.op || w.expand != g.expand {
+			return fmt.Errorf("ValidateSafe: parts change the word %d from %q to %q", i, w.value+w.op, g.value+g.op)
+		}
+		value := w.value
+		for j := len(parts) - 1; j >= 0; j-- {
+			value = strings.ReplaceAll(value, names[j], parts[j])
+		}
+		if value != g.value {
+			return fmt.Errorf("ValidateSafe: parts not kept literally in word %d, got %q, want %q", i, g.value, value)
+		}
+	}
+	return nil
+}
+
+// heredocOp is a heredoc operator waiting for its body after the newline
+type heredocOp struct {
+	delim     string
+	quoted    bool
+	stripTabs bool
+}
+
+// lexShell splits the POSIX shell script s into words and operators, by the
+// rules of quoting, the heredoc bodies are returned as words.
+func lexShell(s string) ([]shellWord, error) {
+	var words []shellWord
+	var b strings.Builder
+	inWord, quoted, expand := false, false, 0
+	// an unquoted { is in the word, which may start brace expansion
+	braceOpen := false
+	// the next word is the heredoc delimiter
+	var delimOp *heredocOp
+	var heredocs []heredocOp
+	flush := func() {
+		if !inWord {
+			return
+		}
+		if delimOp != nil {
+			delimOp.delim = b.String()
+			delimOp.quoted = quoted
+			heredocs = append(heredocs, *delimOp)
+			delimOp = nil
+		}
+		words = append(words, shellWord{value: b.String(), expand: expand})
+		b.Reset()
+		inWord, quoted, expand, braceOpen = false, false, 0, false
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			flush()
+			i++
+		case c == '\n':
+			flush()
+			words = append(words, shellWord{op: "\n"})
+			i++
+			for _, h := range heredocs {
+				body, next, ok := heredocBody(s, i, h)
+				if !ok {
+					return nil, fmt.Errorf("unterminated heredoc %q at offset %d", h.delim, i)
+				}
+				w := shellWord{value: body}
+				if !h.quoted {
+					w.value, w.expand = unescapeDouble(body, false)
+				}
+				words = append(words, w)
+				i = next
+			}
+			heredocs = nil
+		case c == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case strings.IndexByte(";&|<>()", c) >= 0:
+			flush()
+			j := i + 1
+			for j < len(s) && s[j] == c && j-i < 2 {
+				j++
+			}
+			op := s[i:j]
+			if op == "<<" {
+				delimOp = &heredocOp{}
+				if j < len(s) && s[j] == '-' {
+					delimOp.stripTabs = true
+					j++
+				}
+			}
+			words = append(words, shellWord{op: s[i:j]})
+			i = j
+		case c == '\\':
+			inWord, quoted = true, true
+			if i+1 >= len(s) {
+				b.WriteByte(c)
+				i++
+				break
+			}
+			if s[i+1] == '\n' {
+				// line continuation
+				i += 2
+				break
+			}
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			b.WriteString(s[i+1 : i+1+size])
+			i += 1 + size
+		case c == '\'':
+			inWord, quoted = true, true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote at offset %d", i)
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+		case c == '"':
+			inWord, quoted = true, true
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated quote at offset %d", i)
+			}
+			v, e := unescapeDouble(s[i+1:end], true)
+			b.WriteString(v)
+			expand |= e
+			i = end + 1
+		case c == '$' || c == '`':
+			inWord = true
+			n, e := scanDollar(s[i:])
+			b.WriteString(s[i : i+n])
+			expand |= e
+			i += n
+		default:
+			switch {
+			case c == '*' || c == '?' || c == '[':
+				expand |= expandGlob
+			case c == '{':
+				braceOpen = true
+			case c == '}' && braceOpen:
+				expand |= expandBrace
+			case c == '~' && !inWord:
+				expand |= expandTilde
+			}
+			inWord = true
+			b.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	if len(heredocs) > 0 {
+		return nil, fmt.Errorf("unterminated heredoc %q", heredocs[0].delim)
+	}
+	return words, nil
+}
+
+// heredocBody returns the body of heredoc h starting at i, and the offset after
+// the delimiter line.
+func heredocBody(s string, i int, h heredocOp) (string, int, bool) {
+	var b strings.Builder
+	for i < len(s) {
+		end := strings.IndexByte(s[i:], '\n')
+		next := len(s)
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += i
+			next = end + 1
+		}
+		if isHeredocDelim(s[i:end], h.delim, h.stripTabs) {
+			return b.String(), next, true
+		}
+		line := s[i:end]
+		if h.stripTabs {
+			line = strings.TrimLeft(line, "\t")
+		}
+		b.WriteString(line + "\n")
+		i = next
+	}
+	return "", i, false
+}
+
+// unescapeDouble removes the backslashes escaping `$`, "`", `\`, and the newline
+// in s, and `"` if inside double quotes, then reports the expansions other
+// than the variables.
+func unescapeDouble(s string, dquote bool) (string, int) {
+	var b strings.Builder
+	expand := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			next := s[i+1]
+			switch {
+			case next == '\n':
+			case next == '$' || next == '`' || next == '\\' || next == '"' && dquote:
+				b.WriteByte(next)
+			default:
+				b.WriteString(s[i : i+2])
+			}
+			i += 2
+		case c == '$' || c == '`':
+			n, e := scanDollar(s[i:])
+			b.WriteString(s[i : i+n])
+			expand |= e
+			i += n
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), expand
+}
+
+// scanDollar returns the length of the expansion at the start of s, which
+// starts with `$` or "`", and the kind of expansion, 0 for variables.
+func scanDollar(s string) (int, int) {
+	if s[0] == '`' {
+		end := strings.IndexByte(s[1:], '`')
+		if end < 0 {
+			return len(s), expandSubst
+		}
+		return end + 2, expandSubst
+	}
+	if len(s) < 2 {
+		return 1, 0
+	}
+	switch c := s[1]; {
+	case c == '(' || c == '{':
+		close := byte(')')
+		if c == '{' {
+			close = '}'
+		}
+		depth := 0
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case c:
+				depth++
+			case close:
+				depth--
+				if depth == 0 {
+					if c == '{' && !strings.ContainsAny(s[2:i], "$`") {
+						return i + 1, 0
+					}
+					return i + 1, expandSubst
+				}
+			}
+		}
+		return len(s), expandSubst
+	case c == '\'':
+		// $'...' of bash
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '\'' {
+				return i + 1, expandSubst
+			}
+		}
+		return len(s), expandSubst
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		i := 2
+		for i < len(s) && varChars.contains(s[i]) {
+			i++
+		}
+		return i, 0
+	case c >= '0' && c <= '9' || strings.IndexByte("@*#?-$!", c) >= 0:
+		return 2, 0
+	}
+	return 1, 0
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var validateTemplates = []string{
+	`echo %s`,
+	`echo '%s'`,
+	`echo "%s"`,
+	`echo a%sb`,
+	`echo %s | cat -- %s; echo done`,
+	"cat <<EOF\n%s\nEOF",
+	"cat <<'EOF'\n%s\nEOF",
+}
+
+var validateParts = []string{
+	"",
+	"simple",
+	"a b",
+	"it's",
+	`"quoted"`,
+	"$HOME ${HOME}",
+	"$(id) `id` ${HOME:-$(id)}",
+	"a;b|c&d>e<f",
+	"line1\nline2",
+	"#comment",
+	"~root",
+	"*.go ? [a]",
+	"{a,b}",
+	`back\slash\`,
+	"tab\tcr\r",
+	"$'\\x41'",
+	"'; rm -rf / #",
+}
+
+func TestValidateSafe(t *testing.T) {
+	for _, tpl := range validateTemplates {
+		for _, v := range validateParts {
+			parts := []string{v}
+			if tpl == `echo %s | cat -- %s; echo done` {
+				parts = []string{v, v}
+			}
+			if err := ValidateSafe(tpl, parts...); err != nil {
+				t.Errorf("ValidateSafe(%q, %q): %v", tpl, v, err)
+			}
+		}
+	}
+
+	if err := ValidateSafe("cat <<EOF\n%s\nEOF", "x\nEOF\nid"); err == nil {
+		t.Fatal("should fail with heredoc delimiter in part")
+	}
+	if err := ValidateSafe(`echo %s %s`, "a"); err == nil {
+		t.Fatal("should fail with not enough parts")
+	}
+	if err := ValidateSafe(`echo 'unterminated`); err == nil {
+		t.Fatal("should fail with unterminated quote")
+	}
+}
+
+func TestCountParts(t *testing.T) {
+	for _, v := range []struct {
+		template string
+		want     int
+	}{
+		{`echo %s`, 1},
+		{`echo %s | cat -- "%s"`, 2},
+		{"cat <<EOF\n%s\nEOF\necho '%s'", 2},
+		{`date +%F; printf %g; find -printf %p`, 0},
+	} {
+		if n, err := CountParts(v.template); err != nil || n != v.want {
+			t.Errorf("CountParts(%q) = %d, %v, want %d", v.template, n, err, v.want)
+		}
+	}
+	if _, err := CountParts(`echo 'unterminated`); err == nil {
+		t.Error("should fail with unterminated quote")
+	}
+}
+
+func TestLexShell(t *testing.T) {
+	words, err := lexShell("echo a\\ b 'c d' \"$HOME $(id)\" *.go && x <<-EOF\n\tbody $X\n\tEOF\n# comment\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []shellWord{
+		{value: "echo"},
+		{value: "a b"},
+		{value: "c d"},
+		{value: "$HOME $(id)", expand: expandSubst},
+		{value: "*.go", expand: expandGlob},
+		{op: "&&"},
+		{value: "x"},
+		{op: "<<-"},
+		{value: "EOF"},
+		{op: "\n"},
+		{value: "body $X\n"},
+		{op: "\n"},
+	}
+	if diff := cmp.Diff(words, want, cmp.AllowUnexported(shellWord{})); diff != "" {
+		t.Fatal(diff)
+	}
+}
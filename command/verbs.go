@@ -0,0 +1,17 @@
+package command
+
+import "fmt"
+
+// ExtendedVerbs enables the placeholders %F, %g and %p of the template, which
+// are kept as is by default, since the templates may use them for other
+// commands, like `stat -c %F`, `printf %g` and `find -printf %p`. The parts are substituted again, so it
+// should be called right after the command is created:
+//
+//	NewSh(`curl --config %F %s`, config, url).ExtendedVerbs()
+func (c *Command) ExtendedVerbs() *Command {
+	c.render.verbs = true
+	if c.rerender(); c.LastError != nil {
+		c.LastError = fmt.Errorf("ExtendedVerbs: %w", c.LastError)
+	}
+	return c
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// winrmPasswordEnv passes the password of [WinRMCredential] to PowerShell,
+// so it never appears in the command line.
+const winrmPasswordEnv = "BETTER_COMMAND_WINRM_PASSWORD"
+
+// WinRMCredential is the credential of the remote windows host, the current
+// user is used if User is empty.
+type WinRMCredential struct {
+	User     string
+	Password string
+}
+
+// NewWinRM return a Command to run the PowerShell script on the remote windows host
+// by PowerShell remoting over WinRM, the stdout and stderr are streamed back,
+// and it exits with the exit code of the last native command of script.
+//
+// Each %s in script is replaced by the part quoted by single quotes for PowerShell,
+// which is always literal, so don't quote the %s in script again.
+//
+// It runs `powershell` on windows and `pwsh` on other platforms.
+func NewWinRM(host string, creds WinRMCredential, script string, parts ...string) *Command {
+	body, err := substitutePowerShell(script, parts)
+	shell := "pwsh"
+	if runtime.GOOS == "windows" {
+		shell = "powershell"
+	}
+	c := newCommand(dialectPOSIX, []string{shell, "-NoProfile", "-NonInteractive", "-Command"}, nil)
+	c.Cmd.Args = append(c.Cmd.Args, winrmScript(host, creds.User, body))
+	if err != nil {
+		c.LastError = fmt.Errorf("NewWinRM: %w", err)
+	}
+	if creds.Password != "" {
+		c.setEnv(winrmPasswordEnv, creds.Password)
+		c.Redact(creds.Password)
+	}
+	return c
+}
+
+// substitutePowerShell replace each %s in script with the quoted parts
+func substitutePowerShell(script string, parts []string) (string, error) {
+	var b strings.Builder
+	i := 0
+	for {
+		n := strings.Index(script, "%s")
+		if n < 0 {
+			break
+		}
+		if i >= len(parts) {
+			return "", fmt.Errorf("not enough parts for %%s")
+		}
+		b.WriteString(script[:n] + quotePowerShell(parts[i]))
+		script = script[n+2:]
+		i++
+	}
+	if i < len(parts) {
+		return "", fmt.Errorf("too many parts for %%s")
+	}
+	b.WriteString(script)
+	return b.String(), nil
+}
+
+// winrmScript returns the local PowerShell script to run body in a session
+// of host, then exit with the remote $LASTEXITCODE.
+func winrmScript(host, user, body string) string {
+	lines := []string{
+		"$ErrorActionPreference = 'Stop'",
+		"$o = @{ ComputerName = " + quotePowerShell(host) + " }",
+	}
+	if user != "" {
+		lines = append(lines,
+			"$p = ConvertTo-SecureString -String ([string]$env:"+winrmPasswordEnv+") -AsPlainText -Force",
+			"Remove-Item -Path Env:"+winrmPasswordEnv+" -ErrorAction SilentlyContinue",
+			"$o.Credential = New-Object System.Management.Automation.PSCredential("+quotePowerShell(user)+", $p)",
+		)
+	}
+	lines = append(lines,
+		"$s = New-PSSession @o",
+		"try {",
+		"Invoke-Command -Session $s -ScriptBlock {",
+		body,
+		"}",
+		"exit (Invoke-Command -Session $s -ScriptBlock { [int]$global:LASTEXITCODE })",
+		"} finally { Remove-PSSession -Session $s }",
+	)
+	return strings.Join(lines, "\n")
+}
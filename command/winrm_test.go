@@ -0,0 +1,49 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubstitutePowerShell(t *testing.T) {
+	got, err := substitutePowerShell("Get-Item %s; Write-Output %s", []string{"C:\\a b", "it's $x %s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Get-Item 'C:\a b'; Write-Output 'it''s $x %s'`
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err := substitutePowerShell("echo %s %s", []string{"a"}); err == nil {
+		t.Fatal("should fail with not enough parts")
+	}
+	if _, err := substitutePowerShell("echo", []string{"a"}); err == nil {
+		t.Fatal("should fail with too many parts")
+	}
+}
+
+func TestNewWinRM(t *testing.T) {
+	cmd := NewWinRM("host'1", WinRMCredential{User: "admin", Password: "secret"}, "Get-Item %s", "a")
+	if cmd.LastError != nil {
+		t.Fatal(cmd.LastError)
+	}
+	script := cmd.Cmd.Args[len(cmd.Cmd.Args)-1]
+	for _, want := range []string{"ComputerName = 'host''1'", "PSCredential('admin', $p)", "\nGet-Item 'a'\n"} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("%q not found in %s", want, script)
+		}
+	}
+	if strings.Contains(strings.Join(cmd.Cmd.Args, " "), "secret") {
+		t.Fatal("password should not be in args")
+	}
+	found := false
+	for _, v := range cmd.Cmd.Env {
+		found = found || v == winrmPasswordEnv+"=secret"
+	}
+	if !found {
+		t.Fatal("password should be passed by env")
+	}
+	if cmd.Redacted("secret") != redactMask {
+		t.Fatal("password should be redacted")
+	}
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// WriteErrorPolicy is what to do when the Stdout or Stderr writer of command
+// returns an error, see [Command.OnWriteError]
+type WriteErrorPolicy int
+
+const (
+	// WriteErrorAbort kills the command like it's canceled, and Wait returns the
+	// error of the writer
+	WriteErrorAbort WriteErrorPolicy = iota
+	// WriteErrorDrop drops the rest of output to the writer, and the command
+	// continues as if it's written
+	WriteErrorDrop
+	// WriteErrorReturn drops the rest of output to the writer like WriteErrorDrop,
+	// then Wait returns the error of the writer if the command succeeded
+	WriteErrorReturn
+)
+
+// OnWriteError set the policy when the Stdout or Stderr writer returns an error,
+// like a broken pipe to the downstream consumer. Without it, the output is no
+// longer read after the error, thus the command may block on writing, and the
+// error is returned by Wait after the command exited.
+//
+// The error returned by Wait wraps the error of the writer, like
+// `write stdout: io: read/write on closed pipe`.
+func (c *Command) OnWriteError(policy WriteErrorPolicy) *Command {
+	if policy < WriteErrorAbort || policy > WriteErrorReturn {
+		c.LastError = fmt.Errorf("OnWriteError: unknown policy %d", policy)
+		return c
+	}
+	return c.prepare(func(c *Command) error {
+		if sameWriter(c.Cmd.Stdout, c.Cmd.Stderr) {
+			w := &policyWriter{c: c, w: c.Cmd.Stdout, name: "output", policy: policy}
+			c.Cmd.Stdout, c.Cmd.Stderr = w, w
+			return nil
+		}
+		if c.Cmd.Stdout != nil {
+			c.Cmd.Stdout = &policyWriter{c: c, w: c.Cmd.Stdout, name: "stdout", policy: policy}
+		}
+		if c.Cmd.Stderr != nil {
+			c.Cmd.Stderr = &policyWriter{c: c, w: c.Cmd.Stderr, name: "stderr", policy: policy}
+		}
+		return nil
+	})
+}
+
+// policyWriter writes to w until it fails, then handles the error by policy
+type policyWriter struct {
+	c      *Command
+	w      io.Writer
+	name   string
+	policy WriteErrorPolicy
+	mu     sync.Mutex
+	failed bool
+}
+
+func (w *policyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed {
+		return len(p), nil
+	}
+	n, err := w.w.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		return n, nil
+	}
+	// keep reading the output, thus the command doesn't block on writing
+	w.failed = true
+	err = fmt.Errorf("write %s: %w", w.name, err)
+	if w.policy == WriteErrorDrop {
+		return len(p), nil
+	}
+	w.c.mu.Lock()
+	if w.c.writeErr == nil {
+		w.c.writeErr = err
+	}
+	w.c.mu.Unlock()
+	if w.policy == WriteErrorAbort {
+		w.c.cancelWithCause(err)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errBrokenWriter = errors.New("broken")
+
+// brokenWriter fails after n writes
+type brokenWriter struct {
+	n int
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errBrokenWriter
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestOnWriteError(t *testing.T) {
+	// the output is larger than the pipe buffer
+	script := `head -c 1000000 /dev/zero; echo done >&2`
+	c := NewSh(script).OnWriteError(WriteErrorDrop)
+	var stderr strings.Builder
+	c.Cmd.Stdout, c.Cmd.Stderr = &brokenWriter{1}, &stderr
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.String() != "done\n" {
+		t.Fatalf("got %q", stderr.String())
+	}
+
+	c = NewSh(script).OnWriteError(WriteErrorReturn)
+	stderr.Reset()
+	c.Cmd.Stdout, c.Cmd.Stderr = &brokenWriter{1}, &stderr
+	err := c.Run()
+	if !errors.Is(err, errBrokenWriter) || !strings.HasPrefix(err.Error(), "write stdout: ") {
+		t.Fatalf("got %v", err)
+	}
+	if stderr.String() != "done\n" {
+		t.Fatalf("got %q", stderr.String())
+	}
+
+	c = NewSh(`while :; do echo x; done`).OnWriteError(WriteErrorAbort)
+	c.Cmd.Stdout = &brokenWriter{1}
+	start := time.Now()
+	if err := c.Run(); !errors.Is(err, errBrokenWriter) {
+		t.Fatalf("got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("not killed, took %v", d)
+	}
+
+	if err := NewSh(`true`).OnWriteError(WriteErrorPolicy(5)).Run(); err == nil {
+		t.Fatal("want error")
+	}
+}
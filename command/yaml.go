@@ -0,0 +1,714 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OutputYAML runs the command and decodes its standard output as a single YAML
+// document into v. The document is converted to JSON then decoded by
+// [json.Unmarshal], thus v is populated by the json tags, like [Command.OutputJSON].
+//
+// Only the common subset of YAML is supported: block and flow collections,
+// plain, quoted and block scalars, and comments. The anchors, aliases, tags and
+// duplicate keys are rejected. An error is returned if the output has more than
+// one document, use [Command.OutputYAMLAll] for them.
+func (c *Command) OutputYAML(v interface{}) error {
+	b, err := c.Output()
+	if err != nil {
+		return err
+	}
+	docs, err := parseYAML(string(b))
+	if err != nil {
+		return fmt.Errorf("OutputYAML: %w", err)
+	}
+	switch len(docs) {
+	case 0:
+		return errors.New("OutputYAML: no document")
+	case 1:
+	default:
+		return fmt.Errorf("OutputYAML: %d documents, use OutputYAMLAll", len(docs))
+	}
+	if err = remarshal(docs[0], v); err != nil {
+		return fmt.Errorf("OutputYAML: %w", err)
+	}
+	return nil
+}
+
+// OutputYAMLAll runs the command and decodes each YAML document separated by
+// `---` of its standard output into an element of the slice pointed by v,
+// like `kubectl get -o yaml` of many resources, or `helm template`.
+// The empty documents are skipped, see [Command.OutputYAML].
+func (c *Command) OutputYAMLAll(v interface{}) error {
+	b, err := c.Output()
+	if err != nil {
+		return err
+	}
+	docs, err := parseYAML(string(b))
+	if err != nil {
+		return fmt.Errorf("OutputYAMLAll: %w", err)
+	}
+	if err = remarshal(docs, v); err != nil {
+		return fmt.Errorf("OutputYAMLAll: %w", err)
+	}
+	return nil
+}
+
+// remarshal decodes the parsed YAML value into v through JSON
+func remarshal(value, v interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// parseYAML parses the documents in s, the empty documents are skipped
+func parseYAML(s string) ([]interface{}, error) {
+	docs := make([]interface{}, 0, 1)
+	var lines []string
+	base, start := 0, 0
+	flush := func() error {
+		p := &yamlParser{lines: lines, base: base}
+		if p.skipBlank() {
+			v, err := p.parseNode(0)
+			if err != nil {
+				return err
+			}
+			if p.skipBlank() {
+				return p.errorf("unexpected %q", strings.TrimSpace(p.lines[p.i]))
+			}
+			docs = append(docs, v)
+		}
+		lines = nil
+		return nil
+	}
+	for n, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			base = n
+			start = n
+			// the content after the marker, like `--- |`
+			lines = append(lines, strings.TrimSpace(line[3:]))
+		case line == "..." || strings.HasPrefix(line, "... "):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			base = n + 1
+			start = n + 1
+		case strings.HasPrefix(line, "%") && n == start:
+			// the directives like %YAML 1.2 before the document
+			base = n + 1
+			start = n + 1
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// yamlParser parses the block structure of a YAML document by lines
+type yamlParser struct {
+	lines []string
+	i     int
+	// base is the line number of lines[0] in the output for the errors
+	base int
+}
+
+// errorf returns the error at the current line
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.i, format, args...)
+}
+
+// errorAt returns the error at the line i of lines
+func (p *yamlParser) errorAt(i int, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", p.base+i+1, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips the blank and comment lines, returns false at the end
+func (p *yamlParser) skipBlank() bool {
+	for ; p.i < len(p.lines); p.i++ {
+		if s := strings.TrimSpace(p.lines[p.i]); s != "" && s[0] != '#' {
+			return true
+		}
+	}
+	return false
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "-\t")
+}
+
+// parseNode parses the node at the current line, which must be indented at
+// least min, or nil is returned.
+func (p *yamlParser) parseNode(min int) (interface{}, error) {
+	if !p.skipBlank() {
+		return nil, nil
+	}
+	line := p.lines[p.i]
+	ind := indentOf(line)
+	if ind < min {
+		return nil, nil
+	}
+	text := line[ind:]
+	if text[0] == '\t' {
+		return nil, p.errorf("tabs are not allowed as indentation")
+	}
+	if isSeqItem(text) {
+		return p.parseSeq(ind)
+	}
+	if _, _, ok := splitMapEntry(text); ok {
+		return p.parseMap(ind)
+	}
+	p.i++
+	return p.parseValue(text, min-1)
+}
+
+// parseMap parses the block mapping with the keys indented ind
+func (p *yamlParser) parseMap(ind int) (interface{}, error) {
+	m := map[string]interface{}{}
+	for p.skipBlank() {
+		line := p.lines[p.i]
+		if n := indentOf(line); n < ind {
+			break
+		} else if n > ind {
+			return nil, p.errorf("unexpected indentation")
+		}
+		key, rest, ok := splitMapEntry(line[ind:])
+		if !ok {
+			if isSeqItem(line[ind:]) {
+				break
+			}
+			return nil, p.errorf("expected a mapping key, got %q", strings.TrimSpace(line))
+		}
+		if _, ok := m[key]; ok {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.i++
+		var v interface{}
+		var err error
+		if rest == "" || rest[0] == '#' {
+			// a sequence may be at the same indentation as the key
+			if p.skipBlank() && indentOf(p.lines[p.i]) == ind && isSeqItem(p.lines[p.i][ind:]) {
+				v, err = p.parseSeq(ind)
+			} else {
+				v, err = p.parseNode(ind + 1)
+			}
+		} else {
+			v, err = p.parseValue(rest, ind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// parseSeq parses the block sequence with the `-` indented ind
+func (p *yamlParser) parseSeq(ind int) (interface{}, error) {
+	list := []interface{}{}
+	for p.skipBlank() {
+		line := p.lines[p.i]
+		if n := indentOf(line); n < ind {
+			break
+		} else if n > ind {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if !isSeqItem(line[ind:]) {
+			break
+		}
+		rest := line[ind+1:]
+		if s := strings.TrimSpace(rest); s != "" && s[0] != '#' {
+			// parse the content after `- ` as a node at its column,
+			// thus the following lines of a compact mapping are aligned to it
+			k := len(rest) - len(strings.TrimLeft(rest, " \t"))
+			p.lines[p.i] = strings.Repeat(" ", ind+1+k) + rest[k:]
+		} else {
+			p.i++
+		}
+		v, err := p.parseNode(ind + 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// splitMapEntry splits the `key: rest` of a block mapping entry
+func splitMapEntry(text string) (string, string, bool) {
+	if strings.IndexByte("[{#?|>&*!", text[0]) >= 0 || isSeqItem(text) {
+		return "", "", false
+	}
+	var key, after string
+	if text[0] == '"' || text[0] == '\'' {
+		end, ok := scanQuoted(text)
+		if !ok {
+			return "", "", false
+		}
+		key = unquoteYAML(text[:end+1])
+		after = strings.TrimLeft(text[end+1:], " \t")
+		if !strings.HasPrefix(after, ":") {
+			return "", "", false
+		}
+		after = after[1:]
+	} else {
+		n := -1
+		for i := 0; i < len(text); i++ {
+			if text[i] == '#' && (text[i-1] == ' ' || text[i-1] == '\t') {
+				return "", "", false
+			}
+			if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+				n = i
+				break
+			}
+		}
+		if n < 0 {
+			return "", "", false
+		}
+		key = strings.TrimSpace(text[:n])
+		after = text[n+1:]
+	}
+	if after != "" && after[0] != ' ' && after[0] != '\t' {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(after), true
+}
+
+// parseValue parses the scalar or flow collection text of the line before the
+// current one, which may continue in the following lines indented more than
+// parent.
+func (p *yamlParser) parseValue(text string, parent int) (interface{}, error) {
+	switch text[0] {
+	case '|', '>':
+		return p.parseBlockScalar(text, parent)
+	case '&', '*', '!':
+		return nil, p.errorAt(p.i-1, "anchors, aliases and tags are not supported")
+	case '"', '\'':
+		line := p.i - 1
+		for {
+			if end, ok := scanQuoted(text); ok {
+				if rest := strings.TrimSpace(text[end+1:]); rest != "" && rest[0] != '#' {
+					return nil, p.errorAt(p.i-1, "unexpected %q after quoted scalar", rest)
+				}
+				return unquoteYAML(text[:end+1]), nil
+			}
+			if p.i == len(p.lines) {
+				return nil, p.errorAt(line, "unterminated quoted scalar")
+			}
+			text += "\n" + p.lines[p.i]
+			p.i++
+		}
+	case '[', '{':
+		line := p.i - 1
+		for {
+			f := yamlFlow{s: text}
+			v, err := f.value()
+			if err == nil {
+				f.space()
+				if f.pos < len(f.s) && f.s[f.pos] != '#' {
+					return nil, p.errorAt(p.i-1, "unexpected %q after flow collection", f.s[f.pos:])
+				}
+				return v, nil
+			}
+			if err != errFlowEOF {
+				return nil, p.errorAt(p.i-1, "%v", err)
+			}
+			if p.i == len(p.lines) {
+				return nil, p.errorAt(line, "unterminated flow collection")
+			}
+			text += " " + strings.TrimSpace(p.lines[p.i])
+			p.i++
+		}
+	}
+	s := stripComment(text)
+	if _, _, ok := splitMapEntry(s); ok {
+		// like `a: b: c`
+		return nil, p.errorAt(p.i-1, "unexpected mapping in plain scalar")
+	}
+	// the multi-line plain scalar is folded by spaces
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		t := strings.TrimSpace(line)
+		if t == "" || t[0] == '#' || indentOf(line) <= parent {
+			break
+		}
+		if _, _, ok := splitMapEntry(t); ok {
+			return nil, p.errorf("unexpected mapping in plain scalar")
+		}
+		s += " " + stripComment(t)
+		p.i++
+	}
+	return resolvePlain(s), nil
+}
+
+// parseBlockScalar parses the literal `|` or folded `>` scalar, the content
+// lines must be indented more than parent.
+func (p *yamlParser) parseBlockScalar(header string, parent int) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	indent := 0
+	for _, v := range []byte(stripComment(header[1:])) {
+		switch {
+		case v == '-' || v == '+':
+			chomp = v
+		case v >= '1' && v <= '9':
+			indent = int(v - '0')
+		default:
+			return nil, p.errorAt(p.i-1, "invalid block scalar header %q", header)
+		}
+	}
+	if indent > 0 {
+		if parent < 0 {
+			parent = 0
+		}
+		indent += parent
+	}
+	var lines []string
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := indentOf(line)
+		if indent == 0 {
+			if n <= parent {
+				break
+			}
+			indent = n
+		}
+		if n < indent {
+			break
+		}
+		lines = append(lines, line[indent:])
+	}
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case !folded:
+				b.WriteByte('\n')
+			case prev != "" && l != "" && prev[0] != ' ' && l[0] != ' ':
+				b.WriteByte(' ')
+			case prev != "" && prev[0] != ' ' && l == "" && nextFolded(lines[i:]):
+				// the line break before the empty lines is folded
+			default:
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(l)
+	}
+	s := b.String()
+	switch {
+	case s == "" || chomp == '-':
+	case chomp == '+':
+		s += strings.Repeat("\n", trailing+1)
+	default:
+		s += "\n"
+	}
+	return s, nil
+}
+
+// nextFolded reports whether the first non-empty line of lines is not more indented
+func nextFolded(lines []string) bool {
+	for _, l := range lines {
+		if l != "" {
+			return l[0] != ' '
+		}
+	}
+	return false
+}
+
+// scanQuoted returns the index of the closing quote of the quoted scalar at the start of s
+func scanQuoted(s string) (int, bool) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q:
+			if q == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v",
+	'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`,
+	'N': "\u0085", '_': " ", 'L': " ", 'P': " ",
+}
+
+// unquoteYAML returns the value of the single or double quoted scalar s,
+// the line breaks are folded.
+func unquoteYAML(s string) string {
+	q := s[0]
+	s = s[1 : len(s)-1]
+	if strings.Contains(s, "\n") {
+		lines := strings.Split(s, "\n")
+		var b []byte
+		breaks := 0
+		for i, l := range lines {
+			last := i == len(lines)-1
+			if i > 0 {
+				l = strings.TrimLeft(l, " \t")
+			}
+			if !last {
+				l = strings.TrimRight(l, " \t")
+			}
+			if i > 0 {
+				if l == "" && !last {
+					breaks++
+					continue
+				}
+				switch {
+				case breaks > 0:
+					// each empty line is a line break
+					b = append(b, strings.Repeat("\n", breaks)...)
+				case q == '"' && (len(b)-len(bytes.TrimRight(b, `\`)))%2 == 1:
+					// the escaped line break is removed
+					b = b[:len(b)-1]
+				default:
+					b = append(b, ' ')
+				}
+				breaks = 0
+			}
+			b = append(b, l...)
+		}
+		s = string(b)
+	}
+	if q == '\'' {
+		return strings.ReplaceAll(s, "''", "'")
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if v, ok := yamlEscapes[s[i]]; ok {
+			b.WriteString(v)
+			continue
+		}
+		size := 0
+		switch s[i] {
+		case 'x':
+			size = 2
+		case 'u':
+			size = 4
+		case 'U':
+			size = 8
+		}
+		if size > 0 && i+size < len(s) {
+			if r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32); err == nil {
+				b.WriteRune(rune(r))
+				i += size
+				continue
+			}
+		}
+		b.WriteByte('\\')
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// stripComment removes the trailing comment and spaces of the plain scalar s
+func stripComment(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			s = s[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+var (
+	yamlIntRe   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatRe = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// resolvePlain returns the value of plain scalar s by the YAML 1.2 core schema,
+// the .inf and .nan are kept as strings since JSON has no such numbers.
+func resolvePlain(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") {
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return n
+		}
+	}
+	if yamlIntRe.MatchString(s) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	if yamlFloatRe.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// errFlowEOF is returned when the flow collection continues in the next line
+var errFlowEOF = errors.New("unexpected end of flow collection")
+
+// yamlFlow parses the flow collections like `[a, {b: c}]`
+type yamlFlow struct {
+	s   string
+	pos int
+}
+
+func (f *yamlFlow) space() {
+	for f.pos < len(f.s) && (f.s[f.pos] == ' ' || f.s[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.space()
+	if f.pos == len(f.s) {
+		return nil, errFlowEOF
+	}
+	switch f.s[f.pos] {
+	case '[':
+		f.pos++
+		list := []interface{}{}
+		for {
+			f.space()
+			if f.pos == len(f.s) {
+				return nil, errFlowEOF
+			}
+			if f.s[f.pos] == ']' {
+				f.pos++
+				return list, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if err = f.next(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		m := map[string]interface{}{}
+		for {
+			f.space()
+			if f.pos == len(f.s) {
+				return nil, errFlowEOF
+			}
+			if f.s[f.pos] == '}' {
+				f.pos++
+				return m, nil
+			}
+			k, err := f.scalar(true)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := m[fmt.Sprint(k)]; ok {
+				return nil, fmt.Errorf("duplicate key %q", k)
+			}
+			f.space()
+			var v interface{}
+			if f.pos < len(f.s) && f.s[f.pos] == ':' {
+				f.pos++
+				if v, err = f.value(); err != nil {
+					return nil, err
+				}
+			}
+			m[fmt.Sprint(k)] = v
+			if err = f.next('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	}
+	return f.scalar(false)
+}
+
+// next skips the `,` between the items, or stops before the close
+func (f *yamlFlow) next(close byte) error {
+	f.space()
+	if f.pos == len(f.s) {
+		return errFlowEOF
+	}
+	switch f.s[f.pos] {
+	case ',':
+		f.pos++
+	case close:
+	default:
+		return fmt.Errorf("unexpected %q in flow collection", f.s[f.pos:])
+	}
+	return nil
+}
+
+// scalar parses the quoted or plain scalar, the plain key is kept as string
+func (f *yamlFlow) scalar(key bool) (interface{}, error) {
+	s := f.s[f.pos:]
+	if s[0] == '"' || s[0] == '\'' {
+		end, ok := scanQuoted(s)
+		if !ok {
+			return nil, errFlowEOF
+		}
+		f.pos += end + 1
+		return unquoteYAML(s[:end+1]), nil
+	}
+	n := 0
+	for ; n < len(s); n++ {
+		v := s[n]
+		if v == ',' || v == '[' || v == ']' || v == '{' || v == '}' {
+			break
+		}
+		if v == ':' && (n+1 == len(s) || strings.IndexByte(" \t,]}", s[n+1]) >= 0) {
+			break
+		}
+		if v == '#' && n > 0 && (s[n-1] == ' ' || s[n-1] == '\t') {
+			break
+		}
+	}
+	f.pos += n
+	if key {
+		return strings.TrimSpace(s[:n]), nil
+	}
+	return resolvePlain(strings.TrimSpace(s[:n])), nil
+}
@@ -0,0 +1,144 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseYAML(t *testing.T) {
+	for _, v := range []struct {
+		in   string
+		want interface{}
+	}{
+		{"a: 1\nb: [x, 'y z', {c: true}]\n", map[string]interface{}{
+			"a": int64(1),
+			"b": []interface{}{"x", "y z", map[string]interface{}{"c": true}},
+		}},
+		{"# comment\nitems:\n- name: a # trailing\n  ports:\n    - 80\n    - 443\n- name: \"b: c\"\n  env: ~\n", map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "ports": []interface{}{int64(80), int64(443)}},
+				map[string]interface{}{"name": "b: c", "env": nil},
+			},
+		}},
+		{"s: |\n  line 1\n    line 2\n\nf: >-\n  a\n  b\n\n  c\nurl: http://x/#y\n", map[string]interface{}{
+			"s":   "line 1\n  line 2\n",
+			"f":   "a b\nc",
+			"url": "http://x/#y",
+		}},
+		{"- - 1.5\n  - -2\n- 'it''s'\n- \"tab\\there\\u00e9\"\n- plain\n  folded\n", []interface{}{
+			[]interface{}{1.5, int64(-2)},
+			"it's",
+			"tab\thereé",
+			"plain folded",
+		}},
+		{"flow: [a,\n  b]\nversion: \"1.10\"\n", map[string]interface{}{
+			"flow":    []interface{}{"a", "b"},
+			"version": "1.10",
+		}},
+	} {
+		docs, err := parseYAML(v.in)
+		if err != nil {
+			t.Errorf("parse %q: %v", v.in, err)
+			continue
+		}
+		if len(docs) != 1 {
+			t.Errorf("parse %q: got %d documents", v.in, len(docs))
+			continue
+		}
+		if diff := cmp.Diff(v.want, docs[0]); diff != "" {
+			t.Errorf("parse %q (-want +got):\n%s", v.in, diff)
+		}
+	}
+}
+
+func TestParseYAMLError(t *testing.T) {
+	for _, v := range []string{
+		"a: &x 1\nb: *x\n",
+		"a: 1\n  b: 2\n",
+		"a: 'open\n",
+		"a: [1, 2\n",
+	} {
+		if _, err := parseYAML(v); err == nil {
+			t.Errorf("parse %q: want error", v)
+		}
+	}
+}
+
+func TestParseYAMLErrorLine(t *testing.T) {
+	for _, v := range []struct {
+		in, want string
+	}{
+		{"a: &x 1\n", "yaml: line 1: anchors"},
+		{"x: 1\ny: 2\na: 'x'y\n", "yaml: line 3: unexpected \"y\""},
+		{"a: 'x\n  y'z\n", "yaml: line 2: unexpected \"z\""},
+		{"a: 'open\n\n", "yaml: line 1: unterminated"},
+		{"x: 1\na: [1,\n  2\n", "yaml: line 2: unterminated"},
+		{"a: |x\n", "yaml: line 1: invalid block scalar"},
+		{"---\na: 1\n  b: 2\n", "yaml: line 3: unexpected mapping"},
+		{"x: 1\na: b: c\n", "yaml: line 2: unexpected mapping"},
+		{"- a: 1\n  b: 2\n  a: 3\n", "yaml: line 3: duplicate key \"a\""},
+		{"a: {b: 1, b: 2}\n", "yaml: line 1: duplicate key \"b\""},
+	} {
+		_, err := parseYAML(v.in)
+		if err == nil || !strings.HasPrefix(err.Error(), v.want) {
+			t.Errorf("parse %q: got %v, want %s", v.in, err, v.want)
+		}
+	}
+}
+
+func TestOutputYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.yaml")
+	doc := "kind: Pod\nmetadata:\n  name: web\nspec:\n  replicas: 2\n"
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	type object struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec map[string]int `json:"spec"`
+	}
+	var o object
+	if err := NewSh(`cat %s`, path).OutputYAML(&o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Kind != "Pod" || o.Metadata.Name != "web" || o.Spec["replicas"] != 2 {
+		t.Fatalf("got %+v", o)
+	}
+
+	multi := "---\n" + doc + "---\n# empty\n---\n" + strings.Replace(doc, "web", "db", 1) + "...\n"
+	if err := os.WriteFile(path, []byte(multi), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSh(`cat %s`, path).OutputYAML(&o); err == nil || !strings.Contains(err.Error(), "2 documents") {
+		t.Fatalf("got %v", err)
+	}
+	var list []object
+	if err := NewSh(`cat %s`, path).OutputYAMLAll(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Metadata.Name != "web" || list[1].Metadata.Name != "db" {
+		t.Fatalf("got %+v", list)
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	var v struct {
+		A []int `json:"a"`
+	}
+	if err := NewSh(`echo '{"a": [1, 2]}'`).OutputJSON(&v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.A) != 2 || v.A[1] != 2 {
+		t.Fatalf("got %+v", v)
+	}
+	if err := NewSh(`echo '{'`).OutputJSON(&v); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,81 @@
+package commandtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+// UpdateEnv is the env to set as 1 to update the golden files by [AssertGolden]
+const UpdateEnv = "COMMANDTEST_UPDATE"
+
+// AssertRuns runs c with [command.Command.Output], and reports the test failed
+// if the stdout or the exit code is not expected.
+func AssertRuns(t testing.TB, c *command.Command, wantStdout string, wantCode int) {
+	t.Helper()
+	out, err := c.Output()
+	code := 0
+	if err != nil {
+		var e interface{ ExitCode() int }
+		if !errors.As(err, &e) {
+			t.Errorf("%s: %v", c.Redacted(c.String()), err)
+			return
+		}
+		code = e.ExitCode()
+	}
+	if string(out) != wantStdout {
+		t.Errorf("%s: stdout = %q, want %q", c.Redacted(c.String()), out, wantStdout)
+	}
+	if code != wantCode {
+		t.Errorf("%s: exit code = %d, want %d", c.Redacted(c.String()), code, wantCode)
+	}
+}
+
+var trailingSpaces = regexp.MustCompile(`[ \t]+(\r?\n|$)`)
+
+// Normalize returns s with the trailing whitespace of lines removed, CRLF
+// converted to LF, and the temp dir replaced by $TMPDIR, so the output can be
+// compared across runs and platforms.
+func Normalize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = trailingSpaces.ReplaceAllString(s, "$1")
+	tmp := filepath.Clean(os.TempDir())
+	dirs := []string{tmp}
+	if real, err := filepath.EvalSymlinks(tmp); err == nil && real != tmp {
+		// the resolved one is longer in general, like /private/var on macOS
+		dirs = append([]string{real}, dirs...)
+	}
+	for _, v := range dirs {
+		s = strings.ReplaceAll(s, v, "$TMPDIR")
+	}
+	return s
+}
+
+// AssertGolden reports the test failed if the normalized got is not the content
+// of the golden file at path, see [Normalize], the golden file is written
+// instead when env COMMANDTEST_UPDATE=1.
+func AssertGolden(t testing.TB, path string, got string) {
+	t.Helper()
+	got = Normalize(got)
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with %s=1 to create it", err, UpdateEnv)
+	}
+	if got != Normalize(string(want)) {
+		t.Errorf("output not match golden file %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
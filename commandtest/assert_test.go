@@ -0,0 +1,35 @@
+package commandtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+func TestAssertRuns(t *testing.T) {
+	AssertRuns(t, command.NewSh("echo %s; exit 3", "a"), "a\n", 3)
+}
+
+func TestNormalize(t *testing.T) {
+	got := Normalize("a  \r\nb\t\n" + filepath.Join(os.TempDir(), "x") + " ")
+	if want := "a\nb\n" + filepath.Join("$TMPDIR", "x"); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "out.golden")
+	t.Setenv(UpdateEnv, "1")
+	AssertGolden(t, golden, "line  \n")
+	b, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "line\n" {
+		t.Fatalf("got golden %q", b)
+	}
+	t.Setenv(UpdateEnv, "")
+	AssertGolden(t, golden, "line\r\n")
+}
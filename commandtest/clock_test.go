@@ -0,0 +1,41 @@
+package commandtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/futurist/better-command/command"
+)
+
+func TestFakeClockTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	cmd := command.NewSh("sleep 10").WithClock(clock).Timeout(time.Minute)
+	if err := cmd.Spawn(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("should be killed by timeout")
+	}
+	if cmd.Stats().WallTime != time.Minute {
+		t.Fatalf("got wall time %v", cmd.Stats().WallTime)
+	}
+}
+
+func TestFakeClockStop(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	called := 0
+	clock.AfterFunc(time.Second, func() { called++ })
+	timer := clock.AfterFunc(time.Second, func() { called += 10 })
+	if !timer.Stop() {
+		t.Fatal("should stop the timer")
+	}
+	clock.Advance(time.Second / 2)
+	if called != 0 {
+		t.Fatal("should not be called before due")
+	}
+	clock.Advance(time.Second)
+	if called != 1 || clock.Now() != time.Unix(1, 5e8) {
+		t.Fatalf("got called %d at %v", called, clock.Now())
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package commandtest
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupFile returns a duplicate of f, which is kept open after f is closed
+func dupFile(f *os.File) (*os.File, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	p, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return nil, err
+	}
+	var h syscall.Handle
+	var dupErr error
+	err = rc.Control(func(v uintptr) {
+		dupErr = syscall.DuplicateHandle(p, syscall.Handle(v), p, &h, 0, false, syscall.DUPLICATE_SAME_ACCESS)
+	})
+	if err == nil {
+		err = dupErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), f.Name()), nil
+}
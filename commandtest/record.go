@@ -0,0 +1,387 @@
+// Package commandtest provides the helpers to test the tools built on the
+// command package, like recording the real executions into the golden files,
+// and replaying them without spawning processes.
+package commandtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+// Recording is an execution of command stored in the golden file
+type Recording struct {
+	// Args is the final argv of the command
+	Args []string `json:"args"`
+	// StdinSHA256 is the hex sha256 of the stdin consumed by the command
+	StdinSHA256 string `json:"stdin_sha256"`
+	// Stdout is the output of stdout, the output not valid utf-8 is not preserved
+	Stdout string `json:"stdout"`
+	// Stderr is the output of stderr
+	Stderr string `json:"stderr"`
+	// ExitCode is the exit code of the command
+	ExitCode int `json:"exit_code"`
+}
+
+// Use sets e as the [command.Executor] until the test finished
+func Use(t testing.TB, e command.Executor) {
+	command.SetExecutor(e)
+	t.Cleanup(func() { command.SetExecutor(nil) })
+}
+
+// Recorder is the [command.Executor] running the commands, and records every
+// execution into the golden file after the command exit.
+type Recorder struct {
+	path       string
+	mu         sync.Mutex
+	recordings []Recording
+}
+
+// NewRecorder returns a Recorder writing the golden file at path, the file is
+// overwritten by the first recorded command.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Recordings returns the recorded executions
+func (r *Recorder) Recordings() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Recording(nil), r.recordings...)
+}
+
+// Start implements [command.Executor]
+func (r *Recorder) Start(c *command.Command) (func() error, error) {
+	s := &stdio{}
+	h := &lockedHash{h: sha256.New()}
+	var mu sync.Mutex
+	var stdout, stderr bytes.Buffer
+	err := s.hash(&c.Cmd.Stdin, h)
+	if err == nil {
+		err = s.record(&c.Cmd.Stdout, &stdout, &mu)
+	}
+	if err == nil {
+		err = s.record(&c.Cmd.Stderr, &stderr, &mu)
+	}
+	if err == nil {
+		err = c.Cmd.Start()
+	}
+	if err != nil {
+		s.closeChild()
+		s.close()
+		return nil, err
+	}
+	s.start()
+	return func() error {
+		err := c.Cmd.Wait()
+		s.wait()
+		code := 0
+		if err != nil {
+			var e interface{ ExitCode() int }
+			if !errors.As(err, &e) {
+				return err
+			}
+			code = e.ExitCode()
+		}
+		rec := Recording{
+			Args:        append([]string(nil), c.Cmd.Args...),
+			StdinSHA256: h.sum(),
+			Stdout:      stdout.String(),
+			Stderr:      stderr.String(),
+			ExitCode:    code,
+		}
+		if e := r.add(rec); err == nil {
+			err = e
+		}
+		return err
+	}, nil
+}
+
+// add appends rec and writes the golden file
+func (r *Recorder) add(rec Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordings = append(r.recordings, rec)
+	b, err := json.MarshalIndent(r.recordings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+// teeWriter writes to w and buf, the writes of stdout and stderr are
+// serialized by mu, since they may share the same w.
+type teeWriter struct {
+	w   io.Writer
+	buf *bytes.Buffer
+	mu  *sync.Mutex
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf.Write(p)
+	if t.w == nil {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
+
+// stdio serves the stdio of a command for the Executors. The *os.File ones,
+// like the pipe ends of [command.Command.StdoutReader] and
+// [command.Command.StdinWriter], are closed by the command right after start,
+// so they are duplicated, and recorded through the pipes owned by the stdio.
+type stdio struct {
+	// files are the duplicates and the ends of pipes kept by the stdio
+	files []*os.File
+	// child are the ends of pipes for the child, closed after start
+	child []*os.File
+	// stdin is the duplicate of stdin, closed to stop copying after exit
+	stdin *os.File
+	// copyStdin is not waited, since the stdin may be never closed
+	copyStdin func()
+	copies    []func()
+	wg        sync.WaitGroup
+}
+
+// keep returns v, or the duplicate of v if it's an *os.File
+func (s *stdio) keep(v interface{}) (interface{}, error) {
+	f, ok := v.(*os.File)
+	if !ok {
+		return v, nil
+	}
+	d, err := dupFile(f)
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, d)
+	return d, nil
+}
+
+// pipe returns a new pipe, the end for the child is closed after start
+func (s *stdio) pipe(childReads bool) (r, w *os.File, err error) {
+	if r, w, err = os.Pipe(); err != nil {
+		return nil, nil, err
+	}
+	if childReads {
+		s.child, s.files = append(s.child, r), append(s.files, w)
+	} else {
+		s.child, s.files = append(s.child, w), append(s.files, r)
+	}
+	return r, w, nil
+}
+
+// hash sets the stdin *in of command to write what it reads into h
+func (s *stdio) hash(in *io.Reader, h io.Writer) error {
+	f, ok := (*in).(*os.File)
+	if !ok {
+		if *in != nil {
+			*in = io.TeeReader(*in, h)
+		}
+		return nil
+	}
+	d, err := s.keep(f)
+	if err != nil {
+		return err
+	}
+	pr, pw, err := s.pipe(true)
+	if err != nil {
+		return err
+	}
+	s.stdin = d.(*os.File)
+	*in = pr
+	s.copyStdin = func() {
+		io.Copy(pw, io.TeeReader(s.stdin, h))
+		pw.Close()
+	}
+	return nil
+}
+
+// record sets the output *out of command to write into buf too
+func (s *stdio) record(out *io.Writer, buf *bytes.Buffer, mu *sync.Mutex) error {
+	f, ok := (*out).(*os.File)
+	if !ok {
+		*out = &teeWriter{w: *out, buf: buf, mu: mu}
+		return nil
+	}
+	d, err := s.keep(f)
+	if err != nil {
+		return err
+	}
+	pr, pw, err := s.pipe(false)
+	if err != nil {
+		return err
+	}
+	*out = pw
+	// the readers of f get EOF once the copying finished
+	t := &teeWriter{w: d.(*os.File), buf: buf, mu: mu}
+	s.copies = append(s.copies, func() {
+		io.Copy(t, pr)
+		t.w.(*os.File).Close()
+	})
+	return nil
+}
+
+// start closes the ends of child after the command started, and starts copying
+func (s *stdio) start() {
+	s.closeChild()
+	if s.copyStdin != nil {
+		go s.copyStdin()
+	}
+	for _, v := range s.copies {
+		s.wg.Add(1)
+		go func(copy func()) {
+			defer s.wg.Done()
+			copy()
+		}(v)
+	}
+}
+
+// wait waits for the copying of outputs to finish after the command exited,
+// the stdin is not copied anymore.
+func (s *stdio) wait() {
+	if s.stdin != nil {
+		s.stdin.Close()
+	}
+	s.wg.Wait()
+	s.close()
+}
+
+func (s *stdio) closeChild() {
+	for _, f := range s.child {
+		f.Close()
+	}
+}
+
+func (s *stdio) close() {
+	for _, f := range s.files {
+		f.Close()
+	}
+}
+
+// lockedHash is the hash of stdin, which may be still copied after exit
+type lockedHash struct {
+	mu sync.Mutex
+	h  hash.Hash
+}
+
+func (l *lockedHash) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.h.Write(p)
+}
+
+// sum returns the hex of the hash
+func (l *lockedHash) sum() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return hex.EncodeToString(l.h.Sum(nil))
+}
+
+// Replayer is the [command.Executor] serving the recorded executions of golden
+// file without spawning processes, the recording is matched by the argv and
+// the sha256 of stdin, the same executions are served in the recorded order,
+// and the last one is repeated.
+type Replayer struct {
+	mu         sync.Mutex
+	recordings []Recording
+	served     map[int]bool
+}
+
+// NewReplayer returns a Replayer reading the golden file written by [Recorder]
+func NewReplayer(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recordings []Recording
+	if err := json.Unmarshal(b, &recordings); err != nil {
+		return nil, fmt.Errorf("NewReplayer: %w", err)
+	}
+	return &Replayer{recordings: recordings, served: map[int]bool{}}, nil
+}
+
+// Start implements [command.Executor], the stdin is read after start, thus it
+// can be written by the pipe of [command.Command.StdinWriter], and the recording
+// is served once the stdin is closed.
+func (r *Replayer) Start(c *command.Command) (func() error, error) {
+	s := &stdio{}
+	stdin, err := s.keep(c.Cmd.Stdin)
+	var stdout, stderr interface{}
+	if err == nil {
+		stdout, err = s.keep(c.Cmd.Stdout)
+	}
+	if err == nil {
+		stderr, err = s.keep(c.Cmd.Stderr)
+	}
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer s.close()
+		done <- r.serve(c.Cmd.Args, stdin, stdout, stderr)
+	}()
+	return func() error { return <-done }, nil
+}
+
+// serve writes the output of the recording matched by args and stdin
+func (r *Replayer) serve(args []string, stdin, stdout, stderr interface{}) error {
+	h := sha256.New()
+	if in, ok := stdin.(io.Reader); ok {
+		if _, err := io.Copy(h, in); err != nil {
+			return err
+		}
+	}
+	rec, ok := r.match(args, hex.EncodeToString(h.Sum(nil)))
+	if !ok {
+		return fmt.Errorf("commandtest: no recording for %q", args)
+	}
+	if w, ok := stdout.(io.Writer); ok {
+		if _, err := io.WriteString(w, rec.Stdout); err != nil {
+			return err
+		}
+	}
+	if w, ok := stderr.(io.Writer); ok {
+		if _, err := io.WriteString(w, rec.Stderr); err != nil {
+			return err
+		}
+	}
+	if rec.ExitCode != 0 {
+		return &command.ExitStatusError{Code: rec.ExitCode}
+	}
+	return nil
+}
+
+// match returns the first recording not served, or the last served one
+func (r *Replayer) match(args []string, stdinHash string) (Recording, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, v := range r.recordings {
+		if v.StdinSHA256 != stdinHash || strings.Join(v.Args, "\x00") != strings.Join(args, "\x00") || len(v.Args) != len(args) {
+			continue
+		}
+		if !r.served[i] {
+			r.served[i] = true
+			return v, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Recording{}, false
+	}
+	return r.recordings[last], true
+}
@@ -0,0 +1,121 @@
+package commandtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+func TestRecordReplay(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.json")
+	run := func() (string, string, string, error) {
+		out, err := command.NewSh("echo out %s; echo err >&2; exit 2", "a").Output()
+		var stderr string
+		var e *command.ExitStatusError
+		if errors.As(err, &e) {
+			stderr = "replayed"
+		}
+		in, err2 := command.NewSh("cat").StdinString("input").Output()
+		if err2 != nil {
+			return "", "", "", err2
+		}
+		return string(out), stderr, string(in), err
+	}
+
+	rec := NewRecorder(golden)
+	command.SetExecutor(rec)
+	out, _, in, err := run()
+	command.SetExecutor(nil)
+	if out != "out a\n" || in != "input" {
+		t.Fatalf("got %q %q", out, in)
+	}
+	if err == nil || !strings.Contains(err.Error(), "exit status 2") {
+		t.Fatalf("got error %v", err)
+	}
+	recs := rec.Recordings()
+	if len(recs) != 2 || recs[0].Stderr != "err\n" || recs[0].ExitCode != 2 {
+		t.Fatalf("got recordings %+v", recs)
+	}
+
+	replayer, err := NewReplayer(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Use(t, replayer)
+	for i := 0; i < 2; i++ {
+		out, stderr, in, err := run()
+		if out != "out a\n" || in != "input" || stderr != "replayed" {
+			t.Fatalf("got %q %q %q", out, stderr, in)
+		}
+		if err == nil || err.Error() != "exit status 2" {
+			t.Fatalf("got error %v", err)
+		}
+	}
+	if err := command.NewSh("cat").StdinString("other").Run(); err == nil {
+		t.Fatal("should fail with different stdin")
+	}
+}
+
+func TestRecordReplayPipes(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.json")
+	run := func() (string, error) {
+		c := command.NewSh("cat; echo err >&2")
+		in, err := c.StdinWriter()
+		if err != nil {
+			return "", err
+		}
+		out, err := c.StdoutReader()
+		if err != nil {
+			return "", err
+		}
+		stderr, err := c.StderrReader()
+		if err != nil {
+			return "", err
+		}
+		if err := c.Spawn(); err != nil {
+			return "", err
+		}
+		// the stdin is written after start
+		go func() {
+			io.WriteString(in, "input")
+			in.Close()
+		}()
+		b, err := io.ReadAll(out)
+		if err != nil {
+			return "", err
+		}
+		e, err := io.ReadAll(stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "|" + string(e), c.Wait()
+	}
+
+	rec := NewRecorder(golden)
+	command.SetExecutor(rec)
+	got, err := run()
+	command.SetExecutor(nil)
+	if err != nil || got != "input|err\n" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	sum := sha256.Sum256([]byte("input"))
+	recs := rec.Recordings()
+	if len(recs) != 1 || recs[0].Stdout != "input" || recs[0].StdinSHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("got recordings %+v", recs)
+	}
+
+	replayer, err := NewReplayer(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Use(t, replayer)
+	if got, err := run(); err != nil || got != "input|err\n" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,71 @@
+package commandtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+// stubEnv is the env telling the test binary to run as the stub
+const stubEnv = "COMMANDTEST_STUB"
+
+var stubs map[string]func(args []string) int
+
+// Main runs the tests, or runs as the stub program when the test binary is
+// executed by the stubs of [Stub], it should be called by TestMain with the
+// stub functions by name, which exit with the returned code.
+//
+//	func TestMain(m *testing.M) {
+//		commandtest.Main(m, map[string]func(args []string) int{
+//			"git": func(args []string) int {
+//				fmt.Println("git version 2.0.0")
+//				return 0
+//			},
+//		})
+//	}
+func Main(m *testing.M, funcs map[string]func(args []string) int) {
+	if name := os.Getenv(stubEnv); name != "" {
+		fn, ok := funcs[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "commandtest: stub %q not registered\n", name)
+			os.Exit(127)
+		}
+		os.Exit(fn(os.Args[1:]))
+	}
+	stubs = funcs
+	os.Exit(m.Run())
+}
+
+// Stub puts the programs of names on the front of PATH until the test finished,
+// which run the stub functions registered by [Main] in the test binary.
+//
+// The executable is looked up when the command is created, so it should be
+// called before creating the commands.
+func Stub(t testing.TB, names ...string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range names {
+		if _, ok := stubs[name]; !ok {
+			t.Fatalf("commandtest: stub %q not registered by Main", name)
+		}
+		path, script := filepath.Join(dir, name), ""
+		if runtime.GOOS == "windows" {
+			path += ".bat"
+			script = "@echo off\r\nset " + stubEnv + "=" + name + "\r\n\"" + exe + "\" %*\r\nexit /b %ERRORLEVEL%\r\n"
+		} else {
+			script = "#!/bin/sh\n" + stubEnv + "=" + name + " exec " + command.Quote(exe) + " \"$@\"\n"
+		}
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
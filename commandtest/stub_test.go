@@ -0,0 +1,24 @@
+package commandtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/futurist/better-command/command"
+)
+
+func TestMain(m *testing.M) {
+	Main(m, map[string]func(args []string) int{
+		"greet": func(args []string) int {
+			fmt.Println("hello " + strings.Join(args, " "))
+			return len(args)
+		},
+	})
+}
+
+func TestStub(t *testing.T) {
+	Stub(t, "greet")
+	AssertRuns(t, command.New([]string{"greet", "a b", "c"}), "hello a b c\n", 2)
+	AssertRuns(t, command.NewSh("greet"), "hello \n", 0)
+}
@@ -43,6 +43,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 // TokenType is a top-level token classification: A word, space, comment, unknown.
@@ -59,6 +60,32 @@ type Token struct {
 	TokenClass runeTokenClass
 	TokenType  TokenType
 	Value      string
+	// Offset is the byte offset of the token in the input, including the leading spaces in Value
+	Offset int
+	// End is the byte offset after the last byte consumed for the token
+	End int
+	// Quotes is the kinds of quoting used in the token
+	Quotes QuoteKind
+}
+
+// QuoteKind is a bit set of the kinds of quoting in a token
+type QuoteKind int
+
+// Kinds of quoting
+const (
+	SingleQuote QuoteKind = 1 << iota // '...'
+	DoubleQuote                       // "..."
+	Backslash                         // \x, outside or inside double quotes
+)
+
+// Error is a syntax error in the input, at the byte Offset
+type Error struct {
+	Offset int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Msg, e.Offset)
 }
 
 // Equal reports whether tokens a, and b, are equal.
@@ -171,34 +198,75 @@ func (l *Lexer) Next() (string, error) {
 
 // Tokenizer turns an input stream into a sequence of typed tokens
 type Tokenizer struct {
-	input      bufio.Reader
+	input      io.RuneReader
 	classifier tokenClassifier
+	// offset is the number of bytes read from input
+	offset int
 }
 
+// defaultClassifier is shared by the tokenizers, it's never modified
+var defaultClassifier = newDefaultClassifier()
+
 // NewTokenizer creates a new tokenizer from an input stream.
+// The input is read directly if it's an [io.RuneReader] like [strings.Reader],
+// otherwise it's buffered.
 func NewTokenizer(r io.Reader) *Tokenizer {
-	input := bufio.NewReader(r)
-	classifier := newDefaultClassifier()
+	input, ok := r.(io.RuneReader)
+	if !ok {
+		input = bufio.NewReader(r)
+	}
 	return &Tokenizer{
-		input:      *input,
-		classifier: classifier,
+		input:      input,
+		classifier: defaultClassifier,
 	}
 }
 
+// NewStringTokenizer creates a new tokenizer reading s.
+func NewStringTokenizer(s string) *Tokenizer {
+	return NewTokenizer(strings.NewReader(s))
+}
+
+// Offset returns the byte offset of the next token in the input.
+func (t *Tokenizer) Offset() int {
+	return t.offset
+}
+
 // scanStream scans the stream for the next token using the internal state machine.
-// It will panic if it encounters a rune which it does not know how to handle.
+// The returned error is an [*Error] with the offset of the unterminated quote or
+// escape, along with the partial token.
 func (t *Tokenizer) scanStream() (*Token, error) {
 	state := startState
 	var tokenType TokenType
-	var startSpace []rune
-	var value []rune
+	// value is the leading spaces followed by the word
+	var value []byte
+	// spaceEnd is the length of the leading spaces in value
+	spaceEnd := 0
+	var quotes QuoteKind
 	var nextRune rune
 	var nextRuneType runeTokenClass
 	var startRuneType runeTokenClass
 	var err error
+	start := t.offset
+	// quoteAt is the offset of the last opening quote or escape
+	quoteAt := 0
+	var size int
+
+	token := func() *Token {
+		return &Token{
+			TokenClass: startRuneType,
+			TokenType:  tokenType,
+			Value:      string(value),
+			Offset:     start,
+			End:        t.offset,
+			Quotes:     quotes,
+		}
+	}
+	unterminated := func(msg string) (*Token, error) {
+		return token(), &Error{Offset: quoteAt, Msg: msg}
+	}
 
 	for {
-		nextRune, _, err = t.input.ReadRune()
+		nextRune, size, err = t.input.ReadRune()
 		nextRuneType = t.classifier.ClassifyRune(nextRune)
 
 		if err == io.EOF {
@@ -207,11 +275,14 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 		} else if err != nil {
 			return nil, err
 		}
+		at := t.offset
+		t.offset += size
 
 		switch state {
 		case startState: // no runes read yet
 			{
 				startRuneType = nextRuneType
+				spaceEnd = len(value)
 				switch nextRuneType {
 				case EofRuneClass:
 					{
@@ -219,23 +290,29 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 					}
 				case SpaceRuneClass:
 					{
-						// prepend startSpace when return the Token
-						startSpace = append(startSpace, nextRune)
+						// prepend the spaces when return the Token
+						value = appendRune(value, nextRune)
 					}
 				case EscapingQuoteRuneClass:
 					{
 						tokenType = WordToken
 						state = quotingEscapingState
+						quotes |= DoubleQuote
+						quoteAt = at
 					}
 				case NonEscapingQuoteRuneClass:
 					{
 						tokenType = WordToken
 						state = quotingState
+						quotes |= SingleQuote
+						quoteAt = at
 					}
 				case EscapeRuneClass:
 					{
 						tokenType = WordToken
 						state = escapingState
+						quotes |= Backslash
+						quoteAt = at
 					}
 				case CommentRuneClass:
 					{
@@ -245,7 +322,7 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				default:
 					{
 						tokenType = WordToken
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 						state = inWordState
 					}
 				}
@@ -255,37 +332,34 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						return token(), err
 					}
 				case SpaceRuneClass:
 					{
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(append(startSpace, value...), nextRune)),
-						}
-						return token, err
+						value = appendRune(value, nextRune)
+						return token(), err
 					}
 				case EscapingQuoteRuneClass:
 					{
 						state = quotingEscapingState
+						quotes |= DoubleQuote
+						quoteAt = at
 					}
 				case NonEscapingQuoteRuneClass:
 					{
 						state = quotingState
+						quotes |= SingleQuote
+						quoteAt = at
 					}
 				case EscapeRuneClass:
 					{
 						state = escapingState
+						quotes |= Backslash
+						quoteAt = at
 					}
 				default:
 					{
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -294,18 +368,12 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						err = fmt.Errorf("EOF found after escape character")
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						return unterminated("EOF found after escape character")
 					}
 				default:
 					{
 						state = inWordState
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -314,18 +382,12 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						err = fmt.Errorf("EOF found after escape character")
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						return unterminated("EOF found after escape character")
 					}
 				default:
 					{
 						state = quotingEscapingState
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -334,33 +396,23 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						err = fmt.Errorf("EOF found when expecting closing quote")
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						return unterminated("unterminated quote")
 					}
 				case EscapingQuoteRuneClass:
 					{
 						if startRuneType == EscapingQuoteRuneClass {
-							token := &Token{
-								TokenClass: startRuneType,
-								TokenType:  tokenType,
-								Value:      string(append(startSpace, value...)),
-							}
-							return token, err
+							return token(), err
 						}
 						state = inWordState
 					}
 				case EscapeRuneClass:
 					{
 						state = escapingQuotedState
+						quotes |= Backslash
 					}
 				default:
 					{
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -369,31 +421,20 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						err = fmt.Errorf("EOF found when expecting closing quote")
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						return unterminated("unterminated quote")
 					}
 				case NonEscapingQuoteRuneClass:
 					{
 						if startRuneType == NonEscapingQuoteRuneClass {
-							value = append([]rune{'\''}, value...)
+							value = insertByte(value, spaceEnd, '\'')
 							value = append(value, '\'')
-							token := &Token{
-								TokenClass: startRuneType,
-								TokenType:  tokenType,
-								Value:      string(append(startSpace, value...)),
-							}
-							return token, err
+							return token(), err
 						}
 						state = inWordState
 					}
 				default:
 					{
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -402,30 +443,19 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 				switch nextRuneType {
 				case EofRuneClass:
 					{
-						value = append([]rune{'#'}, value...)
-						token := &Token{
-							TokenClass: startRuneType,
-							TokenType:  tokenType,
-							Value:      string(append(startSpace, value...)),
-						}
-						return token, err
+						value = insertByte(value, spaceEnd, '#')
+						return token(), err
 					}
 				case SpaceRuneClass:
 					{
+						value = appendRune(value, nextRune)
 						if nextRune == '\n' {
-							token := &Token{
-								TokenClass: startRuneType,
-								TokenType:  tokenType,
-								Value:      string(append(append(startSpace, value...), nextRune)),
-							}
-							return token, err
-						} else {
-							value = append(value, nextRune)
+							return token(), err
 						}
 					}
 				default:
 					{
-						value = append(value, nextRune)
+						value = appendRune(value, nextRune)
 					}
 				}
 			}
@@ -437,6 +467,24 @@ func (t *Tokenizer) scanStream() (*Token, error) {
 	}
 }
 
+// appendRune appends the UTF-8 encoding of r to b
+func appendRune(b []byte, r rune) []byte {
+	if r < utf8.RuneSelf {
+		return append(b, byte(r))
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
+
+// insertByte inserts c into b at i
+func insertByte(b []byte, i int, c byte) []byte {
+	b = append(b, 0)
+	copy(b[i+1:], b[i:])
+	b[i] = c
+	return b
+}
+
 // Next returns the next token in the stream.
 func (t *Tokenizer) Next() (*Token, error) {
 	return t.scanStream()
@@ -14,6 +14,8 @@ limitations under the License.
 package shlex
 
 import (
+	"errors"
+	"io"
 	"strings"
 	"testing"
 )
@@ -96,3 +98,67 @@ func TestSplit(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenizerPosition(t *testing.T) {
+	input := `a "b c" d\ e 'f'`
+	expectedTokens := []*Token{
+		{TokenType: WordToken, Value: "a ", Offset: 0, End: 2},
+		{TokenType: WordToken, Value: "b c", Offset: 2, End: 7, Quotes: DoubleQuote},
+		{TokenType: WordToken, Value: " d e ", Offset: 7, End: 13, Quotes: Backslash},
+		{TokenType: WordToken, Value: "'f'", Offset: 13, End: 16, Quotes: SingleQuote},
+	}
+	tokenizer := NewStringTokenizer(input)
+	for i, want := range expectedTokens {
+		got, err := tokenizer.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) || got.Offset != want.Offset || got.End != want.End || got.Quotes != want.Quotes {
+			t.Errorf("Tokenizer.Next()[%v] of %q -> %+v. Want: %+v", i, input, got, want)
+		}
+	}
+	if _, err := tokenizer.Next(); err != io.EOF {
+		t.Errorf("want io.EOF, got %v", err)
+	}
+}
+
+func TestTokenizerError(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"single": {`echo 'abc`, "unterminated quote at offset 5"},
+		"double": {`echo "a\"bc`, "unterminated quote at offset 5"},
+		"word":   {`echo a"bc`, "unterminated quote at offset 6"},
+		"escape": {`echo a\`, "EOF found after escape character at offset 6"},
+		"utf8":   {`日本 '`, "unterminated quote at offset 7"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokenizer := NewStringTokenizer(tc.input)
+			var err error
+			for err == nil {
+				_, err = tokenizer.Next()
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("want *Error, got %v", err)
+			}
+			if e.Error() != tc.want {
+				t.Errorf("got %q, want %q", e.Error(), tc.want)
+			}
+		})
+	}
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tokenizer := NewStringTokenizer(testString)
+		for {
+			if _, err := tokenizer.Next(); err != nil {
+				break
+			}
+		}
+	}
+}